@@ -0,0 +1,245 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "log/slog"
+    "os"
+    "strconv"
+    "time"
+
+    "k8s.io/client-go/kubernetes"
+    "k8s-workload-analyzer/pkg/ai"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/logging"
+    "k8s-workload-analyzer/pkg/tracing"
+    "k8s-workload-analyzer/pkg/ui"
+    "k8s-workload-analyzer/pkg/watch"
+)
+
+var analyzeCommand = &command{
+    name:    "analyze",
+    args:    "-name <workload> [flags]",
+    summary: "Analyze one workload's metrics, findings and, with an API key, AI recommendations.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        fs.Lookup("output").Usage = "Output format (" + reportFormatList + ")"
+        workloadType := fs.String("type", "deployment", "Workload type (deployment, statefulset, daemonset)")
+        workloadName := fs.String("name", "", "Workload name")
+        apiKey := fs.String("api-key", "", "GPT API key (defaults to ai.api_key or $OPENAI_API_KEY)")
+        noAI := fs.Bool("no-ai", false, "Skip AI analysis even when an API key is available")
+        focus := fs.String("focus", "", "AI analysis focus: general, cost, security, reliability or a custom one (defaults to ai.focus)")
+        gateOpts := addGateFlags(fs)
+        watchMode := fs.Bool("watch", false, "Keep watching the workload, refreshing a live dashboard of usage, restarts and replicas")
+        interval := fs.Duration("interval", 5*time.Second, "How often -watch samples usage")
+        window := fs.Duration("window", 5*time.Minute, "How far back -watch's rolling min/avg/max go")
+
+        return func(ctx context.Context) error {
+            if *workloadName == "" {
+                return usagef("-name is required")
+            }
+            if err := gateOpts.validate(); err != nil {
+                return err
+            }
+            if *watchMode {
+                if gateOpts.failOn != "" || gateOpts.writeBaseline != "" {
+                    return usagef("-watch can't be combined with -fail-on or -write-baseline")
+                }
+                if *interval <= 0 || *window < *interval {
+                    return usagef("-interval must be positive and -window at least -interval")
+                }
+            }
+            interrupt := ctx
+            rt, ctx, cancel, err := g.load(ctx, reportFormats...)
+            if err != nil {
+                return err
+            }
+            defer cancel()
+            if *watchMode && rt.output != "text" {
+                return usagef("-watch only supports -output=text")
+            }
+
+            cluster, k8sClient, metricsClient, namespace, err := rt.connect(g.namespace)
+            if err != nil {
+                return err
+            }
+
+            var enrich func(ctx context.Context, details *analyzer.WorkloadDetails) error
+            if !*noAI {
+                aiCfg := rt.aiConfig(*apiKey)
+                if *focus != "" {
+                    aiCfg.Focus = *focus
+                }
+                if aiCfg.APIKey == "" {
+                    slog.WarnContext(ctx, "skipping AI analysis: no API key", "hint", "use -api-key, ai.api_key or $OPENAI_API_KEY, or -no-ai to silence")
+                } else {
+                    provider, err := ai.NewProvider(aiCfg)
+                    if err != nil {
+                        return fmt.Errorf("failed to create AI client: %v", err)
+                    }
+                    enrich = aiEnricher(provider, k8sClient)
+                }
+            }
+
+            analyze := func(ctx context.Context) (*analyzer.WorkloadDetails, error) {
+                // Get workload details with metrics
+                details, err := analyzer.AnalyzeWorkload(ctx, k8sClient, metricsClient, namespace, *workloadType, *workloadName)
+                if err != nil {
+                    return nil, fmt.Errorf("[%s] failed to analyze workload: %w", cluster.Name, err)
+                }
+                rt.settings.finish(ctx, details, cluster.Name)
+                if enrich != nil {
+                    if err := enrich(ctx, details); err != nil {
+                        return nil, fmt.Errorf("[%s] %w", cluster.Name, err)
+                    }
+                }
+                rt.record(ctx, []*analyzer.WorkloadDetails{details})
+                return details, nil
+            }
+
+            if *watchMode {
+                // The timeout applies to each analysis rather than the whole
+                // watch, which still carries the request ID and root span
+                watchCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+                defer stop()
+                context.AfterFunc(interrupt, stop)
+                return watchWorkload(watchCtx, g.timeout, watch.Config{
+                    Client:    k8sClient,
+                    Metrics:   metricsClient,
+                    Namespace: namespace,
+                    Type:      *workloadType,
+                    Name:      *workloadName,
+                    Interval:  *interval,
+                    Window:    *window,
+                    Analyze:   analyze,
+                })
+            }
+
+            details, err := analyze(ctx)
+            if err != nil {
+                return err
+            }
+            rep := rt.settings.newReport(analyzer.ClusterResult{
+                Cluster:   cluster.Name,
+                Workloads: []*analyzer.WorkloadDetails{details},
+            })
+            if err := renderReport(os.Stdout, rep, rt.output); err != nil {
+                return err
+            }
+            return gateOpts.check(rep.Workloads())
+        }
+    },
+}
+
+// watchWorkload runs the live dashboard until Ctrl-C. The timeout applies
+// to each analysis rather than to the whole watch.
+func watchWorkload(ctx context.Context, timeout time.Duration, cfg watch.Config) error {
+    analyze := cfg.Analyze
+    cfg.Analyze = func(ctx context.Context) (*analyzer.WorkloadDetails, error) {
+        if timeout > 0 {
+            var cancel context.CancelFunc
+            ctx, cancel = context.WithTimeout(ctx, timeout)
+            defer cancel()
+        }
+        return analyze(ctx)
+    }
+    cfg.Render = func(snap *watch.Snapshot) {
+        // Clear the screen and redraw from the top
+        fmt.Print("\033[H\033[2J" + ui.RenderWatch(snap, cfg.Interval))
+    }
+    if err := watch.New(cfg).Run(ctx); err != nil {
+        return err
+    }
+    fmt.Fprintln(os.Stderr, "Stopped watching")
+    return nil
+}
+
+// aiEnricher returns a function that adds the provider's analysis of a
+// workload's live manifest to its details, keeping only claims grounded in
+// the details' findings and metrics.
+func aiEnricher(provider ai.Provider, client kubernetes.Interface) func(ctx context.Context, details *analyzer.WorkloadDetails) error {
+    return func(ctx context.Context, details *analyzer.WorkloadDetails) (err error) {
+        defer logging.Phase(ctx, "ai", "namespace", details.Namespace, "kind", details.Kind, "name", details.Deployment)()
+        ctx, span := tracing.Start(ctx, "ai.enrich",
+            tracing.Namespace.String(details.Namespace),
+            tracing.Kind.String(details.Kind),
+            tracing.Workload.String(details.Deployment))
+        defer func() { tracing.End(span, err) }()
+
+        // Get workload YAML and analyze
+        yaml, err := analyzer.GetWorkloadYAML(ctx, client, details.Namespace, details.Kind, details.Deployment)
+        if err != nil {
+            return fmt.Errorf("failed to get workload: %w", err)
+        }
+        evidence := evidenceOf(details)
+        analysis, err := provider.AnalyzeWorkload(ctx, details.Kind, yaml, evidence)
+        if err != nil {
+            return fmt.Errorf("failed to analyze workload: %w", err)
+        }
+        details.DroppedClaims = analysis.Ground(evidence)
+        slog.DebugContext(ctx, "ai analysis",
+            "prompt_version", analysis.PromptVersion,
+            "focus", analysis.Focus,
+            "prompt_tokens", analysis.Usage.PromptTokens,
+            "completion_tokens", analysis.Usage.CompletionTokens,
+            "dropped_claims", details.DroppedClaims)
+        applyAnalysis(details, analysis)
+        return nil
+    }
+}
+
+// applyAnalysis copies the AI's qualitative output onto the details. The
+// efficiency rate always comes from metrics, never from the model.
+func applyAnalysis(details *analyzer.WorkloadDetails, analysis *ai.WorkloadAnalysis) {
+    details.ReliabilityRisk = analysis.ReliabilityRisk
+    details.Analysis = analysis.Analysis
+    details.Opportunities = claims(analysis.Opportunities)
+    details.Cautions = claims(analysis.Cautions)
+    details.Blockers = analysis.Blockers
+    details.Recommendations = analysis.Recommendations
+    details.PromptVersion = analysis.PromptVersion
+    details.AIFocus = analysis.Focus
+}
+
+func claims(in []ai.Claim) []analyzer.Claim {
+    var out []analyzer.Claim
+    for _, c := range in {
+        out = append(out, analyzer.Claim{Text: c.Text, Findings: c.Findings, Metrics: c.Metrics, Unverified: c.Unverified})
+    }
+    return out
+}
+
+// evidenceOf lists what the AI may cite: findings by ID, and the workload's
+// metrics and each container's requests, limits and usage by name.
+func evidenceOf(details *analyzer.WorkloadDetails) ai.Evidence {
+    ev := ai.Evidence{Findings: map[string]string{}, Metrics: map[string]string{}}
+    for _, f := range details.Findings {
+        ev.Findings[f.ID] = fmt.Sprintf("%s: %s", f.Severity, f.Message)
+    }
+
+    metric := func(name, value string) {
+        if value != "" {
+            ev.Metrics[name] = value
+        }
+    }
+    metric("replica_count", details.ReplicaCount)
+    metric("cpu_utilization", details.CPUUtilization)
+    metric("memory_utilization", details.MemoryUtilization)
+    metric("efficiency_rate", details.EfficiencyRate)
+    for _, c := range details.Containers {
+        metric(c.Name+".cpu_request_millicores", strconv.FormatInt(c.CPURequest, 10))
+        metric(c.Name+".cpu_limit_millicores", strconv.FormatInt(c.CPULimit, 10))
+        metric(c.Name+".memory_request_bytes", strconv.FormatInt(c.MemoryRequest, 10))
+        metric(c.Name+".memory_limit_bytes", strconv.FormatInt(c.MemoryLimit, 10))
+        if c.HasUsage {
+            metric(c.Name+".cpu_usage_millicores", strconv.FormatInt(c.CPUUsage, 10))
+            metric(c.Name+".memory_usage_bytes", strconv.FormatInt(c.MemoryUsage, 10))
+        }
+    }
+    if details.Cost != nil {
+        metric("monthly_cost", fmt.Sprintf("%.2f %s", details.Cost.MonthlyRequested, details.Cost.Currency))
+        metric("monthly_waste", fmt.Sprintf("%.2f %s", details.Cost.MonthlyWaste, details.Cost.Currency))
+    }
+    return ev
+}
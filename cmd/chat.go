@@ -0,0 +1,214 @@
+package main
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+
+    "k8s.io/client-go/kubernetes"
+    metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+    "k8s-workload-analyzer/pkg/ai"
+    "k8s-workload-analyzer/pkg/ai/prompts"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+var chatCommand = &command{
+    name:    "chat",
+    args:    "-name <workload> [-q <question>] [flags]",
+    summary: "Ask the AI follow-up questions about a workload, with its analysis as context.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        fs.Lookup("timeout").Usage = "Timeout for the analysis and for each answer (0 disables)"
+        workloadType := fs.String("type", "deployment", "Workload type (deployment, statefulset, daemonset)")
+        workloadName := fs.String("name", "", "Workload name")
+        apiKey := fs.String("api-key", "", "GPT API key (defaults to ai.api_key or $OPENAI_API_KEY)")
+        question := fs.String("q", "", "Ask one question and exit instead of chatting interactively")
+
+        return func(ctx context.Context) error {
+            if *workloadName == "" {
+                return usagef("-name is required")
+            }
+            sessionCtx := ctx
+            rt, ctx, cancel, err := g.load(ctx)
+            if err != nil {
+                return err
+            }
+            defer cancel()
+
+            aiCfg := rt.aiConfig(*apiKey)
+            if aiCfg.APIKey == "" {
+                return usagef("chat needs an API key (use -api-key, ai.api_key or $OPENAI_API_KEY)")
+            }
+            provider, err := ai.NewProvider(aiCfg)
+            if err != nil {
+                return fmt.Errorf("failed to create AI client: %v", err)
+            }
+            promptSet, err := prompts.Load(aiCfg.PromptsDir)
+            if err != nil {
+                return err
+            }
+
+            cluster, k8sClient, metricsClient, namespace, err := rt.connect(g.namespace)
+            if err != nil {
+                return err
+            }
+            details, err := analyzer.AnalyzeWorkload(ctx, k8sClient, metricsClient, namespace, *workloadType, *workloadName)
+            if err != nil {
+                return fmt.Errorf("[%s] failed to analyze workload: %w", cluster.Name, err)
+            }
+            rt.settings.finish(ctx, details, cluster.Name)
+            yaml, err := analyzer.GetWorkloadYAML(ctx, k8sClient, namespace, *workloadType, *workloadName)
+            if err != nil {
+                return fmt.Errorf("failed to get workload: %w", err)
+            }
+            analysis, err := json.MarshalIndent(details, "", "  ")
+            if err != nil {
+                return fmt.Errorf("failed to encode analysis: %v", err)
+            }
+            cancel()
+            system, err := promptSet.Render(prompts.Chat, prompts.ChatData{Analysis: string(analysis), Manifest: yaml})
+            if err != nil {
+                return err
+            }
+
+            conv := ai.NewConversation(provider, system,
+                chatTools(k8sClient, metricsClient, namespace, *workloadType, *workloadName),
+            )
+            conv.OnToolCall = func(call ai.ToolCall) {
+                fmt.Fprintf(os.Stderr, "  ↳ %s %s\n", call.Name, call.Arguments)
+            }
+
+            // Each answer gets the timeout; the session has none
+            ask := func(q string) error {
+                ctx := sessionCtx
+                if g.timeout > 0 {
+                    var cancel context.CancelFunc
+                    ctx, cancel = context.WithTimeout(ctx, g.timeout)
+                    defer cancel()
+                }
+                answer, err := conv.Ask(ctx, q)
+                if err != nil {
+                    return err
+                }
+                fmt.Println(strings.TrimSpace(answer))
+                return nil
+            }
+
+            if *question != "" {
+                return ask(*question)
+            }
+            return chatLoop(sessionCtx, os.Stdin, fmt.Sprintf("%s/%s in %s/%s", *workloadType, *workloadName, cluster.Name, namespace), ask)
+        }
+    },
+}
+
+// chatLoop reads questions until EOF, /exit or Ctrl-C. A failed answer is
+// reported and the conversation goes on.
+func chatLoop(ctx context.Context, in io.Reader, workload string, ask func(q string) error) error {
+    fmt.Fprintf(os.Stderr, "Chatting about %s. Ask a question, or /exit to quit.\n", workload)
+    scanner := bufio.NewScanner(in)
+    for {
+        fmt.Fprint(os.Stderr, "\n> ")
+        if !scanner.Scan() {
+            fmt.Fprintln(os.Stderr)
+            return scanner.Err()
+        }
+        q := strings.TrimSpace(scanner.Text())
+        switch q {
+        case "":
+            continue
+        case "/exit", "/quit":
+            return nil
+        }
+        if err := ask(q); err != nil {
+            if ctx.Err() != nil {
+                return ctx.Err()
+            }
+            fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+        }
+    }
+}
+
+// chatTools are the local functions the model can call for more evidence
+// about the workload.
+func chatTools(client kubernetes.Interface, metricsClient metricsv.Interface, namespace, workloadType, name string) []ai.Tool {
+    return []ai.Tool{
+        {
+            Name:        "get_events",
+            Description: "Recent Kubernetes events about the workload, its ReplicaSets and pods, oldest first.",
+            Parameters: map[string]interface{}{
+                "type": "object",
+                "properties": map[string]interface{}{
+                    "limit": map[string]interface{}{"type": "integer", "description": "Most recent events to return (default 20)"},
+                },
+            },
+            Run: func(ctx context.Context, raw json.RawMessage) (string, error) {
+                var args struct {
+                    Limit int `json:"limit"`
+                }
+                if err := json.Unmarshal(raw, &args); err != nil {
+                    return "", fmt.Errorf("invalid arguments: %v", err)
+                }
+                if args.Limit <= 0 {
+                    args.Limit = 20
+                }
+                events, err := analyzer.WorkloadEvents(ctx, client, namespace, workloadType, name, args.Limit)
+                if err != nil {
+                    return "", err
+                }
+                return toolJSON(events)
+            },
+        },
+        {
+            Name:        "get_logs",
+            Description: "The last lines of a container's logs, from the named pod or the first running pod of the workload.",
+            Parameters: map[string]interface{}{
+                "type": "object",
+                "properties": map[string]interface{}{
+                    "container": map[string]interface{}{"type": "string", "description": "Container name (defaults to the pod's only container)"},
+                    "pod":       map[string]interface{}{"type": "string", "description": "Pod name (defaults to the first running pod)"},
+                    "lines":     map[string]interface{}{"type": "integer", "description": "Lines to return (default 50, at most 200)"},
+                },
+            },
+            Run: func(ctx context.Context, raw json.RawMessage) (string, error) {
+                var args struct {
+                    Container string `json:"container"`
+                    Pod       string `json:"pod"`
+                    Lines     int64  `json:"lines"`
+                }
+                if err := json.Unmarshal(raw, &args); err != nil {
+                    return "", fmt.Errorf("invalid arguments: %v", err)
+                }
+                if args.Lines <= 0 {
+                    args.Lines = 50
+                }
+                return analyzer.LogsTail(ctx, client, namespace, workloadType, name, args.Pod, args.Container, min(args.Lines, 200))
+            },
+        },
+        {
+            Name:        "get_pod_usage",
+            Description: "Per-pod phase, node, current CPU (millicores) and memory (bytes) usage, restart counts and last termination reasons such as OOMKilled.",
+            Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+            Run: func(ctx context.Context, _ json.RawMessage) (string, error) {
+                usage, err := analyzer.WorkloadPodUsage(ctx, client, metricsClient, namespace, workloadType, name)
+                if err != nil {
+                    return "", err
+                }
+                return toolJSON(usage)
+            },
+        },
+    }
+}
+
+func toolJSON(v interface{}) (string, error) {
+    data, err := json.Marshal(v)
+    if err != nil {
+        return "", fmt.Errorf("failed to encode result: %v", err)
+    }
+    return string(data), nil
+}
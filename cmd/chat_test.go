@@ -0,0 +1,122 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "strings"
+    "testing"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/resource"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/client-go/kubernetes/fake"
+    metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+    metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+    "k8s-workload-analyzer/pkg/ai"
+)
+
+func runTool(t *testing.T, tools []ai.Tool, name, args string) string {
+    t.Helper()
+    for _, tool := range tools {
+        if tool.Name == name {
+            out, err := tool.Run(context.Background(), json.RawMessage(args))
+            if err != nil {
+                t.Fatalf("%s failed: %v", name, err)
+            }
+            return out
+        }
+    }
+    t.Fatalf("no tool %s", name)
+    return ""
+}
+
+func TestChatTools(t *testing.T) {
+    labels := map[string]string{"app": "web"}
+    replicas := int32(1)
+    client := fake.NewSimpleClientset(
+        &appsv1.Deployment{
+            ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+            Spec: appsv1.DeploymentSpec{
+                Replicas: &replicas,
+                Selector: &metav1.LabelSelector{MatchLabels: labels},
+                Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+            },
+        },
+        &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+            Name: "web-abc", Namespace: "default", UID: "rs-uid", Labels: labels,
+            OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: "web-uid"}},
+        }},
+        &corev1.Pod{
+            ObjectMeta: metav1.ObjectMeta{
+                Name: "web-abc-1", Namespace: "default", UID: "pod-uid", Labels: labels,
+                OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-abc", UID: "rs-uid"}},
+            },
+            Spec: corev1.PodSpec{NodeName: "node-1"},
+            Status: corev1.PodStatus{
+                Phase: corev1.PodRunning,
+                ContainerStatuses: []corev1.ContainerStatus{{
+                    Name:         "app",
+                    RestartCount: 2,
+                    LastTerminationState: corev1.ContainerState{
+                        Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137},
+                    },
+                }},
+            },
+        },
+        &corev1.Event{
+            ObjectMeta:     metav1.ObjectMeta{Name: "oom", Namespace: "default"},
+            InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-abc-1", UID: "pod-uid"},
+            Reason:         "BackOff",
+            Message:        "Back-off restarting failed container",
+        },
+        &corev1.Event{
+            ObjectMeta:     metav1.ObjectMeta{Name: "other", Namespace: "default"},
+            InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-frontend-1", UID: "other-uid"},
+            Reason:         "Pulled",
+        },
+    )
+    metricsClient := metricsfake.NewSimpleClientset()
+    err := metricsClient.Tracker().Create(metricsv1beta1.SchemeGroupVersion.WithResource("pods"), &metricsv1beta1.PodMetrics{
+        ObjectMeta: metav1.ObjectMeta{Name: "web-abc-1", Namespace: "default"},
+        Containers: []metricsv1beta1.ContainerMetrics{{
+            Name: "app",
+            Usage: corev1.ResourceList{
+                corev1.ResourceCPU:    resource.MustParse("250m"),
+                corev1.ResourceMemory: resource.MustParse("64Mi"),
+            },
+        }},
+    }, "default")
+    if err != nil {
+        t.Fatal(err)
+    }
+    tools := chatTools(client, metricsClient, "default", "deployment", "web")
+
+    var events []struct {
+        Object string `json:"object"`
+        Reason string `json:"reason"`
+    }
+    if err := json.Unmarshal([]byte(runTool(t, tools, "get_events", `{}`)), &events); err != nil {
+        t.Fatal(err)
+    }
+    if len(events) != 1 || events[0].Object != "pod/web-abc-1" || events[0].Reason != "BackOff" {
+        t.Errorf("expected only the event of web's pod, got %+v", events)
+    }
+
+    if logs := runTool(t, tools, "get_logs", `{"lines": 500}`); logs != "pod/web-abc-1:\nfake logs" {
+        t.Errorf("unexpected logs %q", logs)
+    }
+
+    usage := runTool(t, tools, "get_pod_usage", `{}`)
+    for _, want := range []string{`"pod":"web-abc-1"`, `"node":"node-1"`, `"app":2`, `OOMKilled (exit code 137)`, `"cpu_millicores":250`} {
+        if !strings.Contains(usage, want) {
+            t.Errorf("expected pod usage to contain %s:\n%s", want, usage)
+        }
+    }
+
+    for _, tool := range tools {
+        if _, err := tool.Run(context.Background(), json.RawMessage(`[`)); err == nil && tool.Name != "get_pod_usage" {
+            t.Errorf("expected %s to reject invalid arguments", tool.Name)
+        }
+    }
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "sort"
+    "strings"
+)
+
+var completionCommand = &command{
+    name:    "completion",
+    args:    "bash|zsh|fish",
+    summary: "Generate a shell completion script.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        return func(ctx context.Context) error {
+            if fs.NArg() != 1 {
+                return usagef("completion needs exactly one shell: bash, zsh or fish")
+            }
+            switch fs.Arg(0) {
+            case "bash":
+                writeBashCompletion(os.Stdout)
+            case "zsh":
+                fmt.Fprintln(os.Stdout, "autoload -U +X bashcompinit && bashcompinit")
+                writeBashCompletion(os.Stdout)
+            case "fish":
+                writeFishCompletion(os.Stdout)
+            default:
+                return usagef("unsupported shell %q (want bash, zsh or fish)", fs.Arg(0))
+            }
+            return nil
+        }
+    },
+}
+
+// commandFlags returns the sorted flag names of a command.
+func commandFlags(cmd *command) []string {
+    fs, _ := newFlagSet(cmd)
+    var names []string
+    fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+    sort.Strings(names)
+    return names
+}
+
+func commandNames() []string {
+    names := []string{"help"}
+    for _, cmd := range commands {
+        names = append(names, cmd.name)
+    }
+    return names
+}
+
+func writeBashCompletion(w io.Writer) {
+    fmt.Fprintln(w, "# bash completion for kwa")
+    fmt.Fprintln(w, "_kwa() {")
+    fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}"`)
+    fmt.Fprintln(w, `    if [ "$COMP_CWORD" -eq 1 ]; then`)
+    fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(commandNames(), " "))
+    fmt.Fprintln(w, "        return")
+    fmt.Fprintln(w, "    fi")
+    fmt.Fprintln(w, `    case "${COMP_WORDS[1]}" in`)
+    for _, cmd := range commands {
+        var words []string
+        for _, name := range commandFlags(cmd) {
+            words = append(words, "-"+name)
+        }
+        if cmd.name == "completion" {
+            words = append(words, "bash", "zsh", "fish")
+        }
+        if cmd.name == "config" {
+            words = append(words, "print")
+        }
+        if cmd.name == "prompts" {
+            words = append(words, "list", "show")
+        }
+        fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", cmd.name, strings.Join(words, " "))
+    }
+    fmt.Fprintln(w, "    esac")
+    fmt.Fprintln(w, "}")
+    fmt.Fprintln(w, "complete -F _kwa kwa")
+}
+
+func writeFishCompletion(w io.Writer) {
+    fmt.Fprintln(w, "# fish completion for kwa")
+    fmt.Fprintf(w, "complete -c kwa -f -n '__fish_use_subcommand' -a %q\n", strings.Join(commandNames(), " "))
+    for _, cmd := range commands {
+        for _, name := range commandFlags(cmd) {
+            fmt.Fprintf(w, "complete -c kwa -f -n '__fish_seen_subcommand_from %s' -o %s\n", cmd.name, name)
+        }
+    }
+    fmt.Fprintln(w, "complete -c kwa -f -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'")
+    fmt.Fprintln(w, "complete -c kwa -f -n '__fish_seen_subcommand_from config' -a 'print'")
+    fmt.Fprintln(w, "complete -c kwa -f -n '__fish_seen_subcommand_from prompts' -a 'list show'")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "net/http"
+
+    "k8s-workload-analyzer/pkg/ai"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/config"
+    "k8s-workload-analyzer/pkg/logging"
+    "k8s-workload-analyzer/pkg/replay"
+    "k8s-workload-analyzer/pkg/report"
+    "k8s-workload-analyzer/pkg/rules"
+    "k8s-workload-analyzer/pkg/tracing"
+)
+
+// settings carries what the config file decides into each analysis.
+type settings struct {
+    engine         *rules.Engine
+    pricing        analyzer.Pricing
+    bands          analyzer.EfficiencyBands
+    metricsEnabled bool
+}
+
+func newSettings(cfg *config.Config) (*settings, error) {
+    opts := rules.Options{
+        Overrides:            make(map[string]rules.Override),
+        OverProvisionedBelow: cfg.Efficiency.OverProvisionedBelow,
+        NearLimitAbove:       cfg.Efficiency.NearLimitAbove,
+    }
+    for key, rule := range cfg.Rules {
+        // Severities were validated when the config was loaded
+        severity, _ := analyzer.ParseSeverity(rule.Severity)
+        opts.Overrides[key] = rules.Override{Enabled: rule.Enabled, Severity: severity}
+    }
+    engine, err := rules.NewEngine(opts)
+    if err != nil {
+        return nil, fmt.Errorf("invalid config: %v", err)
+    }
+
+    return &settings{
+        engine: engine,
+        pricing: analyzer.Pricing{
+            Currency:      cfg.Pricing.Currency,
+            CPUCoreHour:   cfg.Pricing.CPUCoreHour,
+            MemoryGiBHour: cfg.Pricing.MemoryGiBHour,
+        },
+        bands:          analyzer.EfficiencyBands{Low: cfg.Efficiency.Low, High: cfg.Efficiency.High},
+        metricsEnabled: cfg.Metrics.Source != "none",
+    }, nil
+}
+
+// finish fills in what every analyzed workload gets regardless of mode.
+func (s *settings) finish(ctx context.Context, details *analyzer.WorkloadDetails, cluster string) {
+    _, span := tracing.Start(ctx, "rules.evaluate",
+        tracing.Cluster.String(cluster),
+        tracing.Namespace.String(details.Namespace),
+        tracing.Kind.String(details.Kind),
+        tracing.Workload.String(details.Deployment))
+    defer span.End()
+
+    details.Cluster = cluster
+    if percent, ok := analyzer.EfficiencyPercent(details.EfficiencyRate); ok {
+        details.EfficiencyRate = s.bands.Rate(percent)
+    }
+    details.Findings = s.engine.Evaluate(details)
+    details.SkippedRules = s.engine.Skipped(details)
+    details.Cost = analyzer.EstimateCost(details, s.pricing)
+    span.SetAttributes(tracing.Findings.Int(len(details.Findings)))
+}
+
+// newReport wraps results in a report carrying the metadata of the rules
+// the findings were evaluated with.
+func (s *settings) newReport(results ...analyzer.ClusterResult) *report.Report {
+    rep := report.New(results...)
+    for _, rule := range s.engine.Rules() {
+        rep.Rules = append(rep.Rules, report.RuleInfo{
+            ID:          rule.ID,
+            Name:        rule.Name,
+            Description: rule.Description,
+            Severity:    rule.Severity,
+        })
+    }
+    return rep
+}
+
+// aiConfig returns the AI client settings. With -record or -replay the
+// exchanges go through the bundle; a replay needs no API key.
+func (rt *runtime) aiConfig(apiKey string) ai.Config {
+    cfg := rt.cfg
+    if apiKey == "" {
+        apiKey = cfg.AI.ResolveAPIKey()
+    }
+    if apiKey == "" && rt.replaying() && rt.bundle.Has(replay.AI) {
+        apiKey = "replay"
+    }
+    aiCfg := ai.Config{
+        Provider:    cfg.AI.Provider,
+        Model:       cfg.AI.Model,
+        Temperature: cfg.AI.Temperature,
+        BaseURL:     cfg.AI.BaseURL,
+        APIKey:      apiKey,
+        Timeout:     cfg.AI.Timeout,
+        MaxRetries:  cfg.AI.MaxRetries,
+        Focus:       cfg.AI.Focus,
+        PromptsDir:  cfg.AI.PromptsDir,
+    }
+    transport := http.DefaultTransport
+    if rt.bundle != nil {
+        transport = rt.bundle.Transport(replay.AI, transport)
+    }
+    aiCfg.Transport = logging.Transport("ai", transport)
+    return aiCfg
+}
+
+var configCommand = &command{
+    name:    "config",
+    args:    "print [-config path]",
+    summary: "Print the effective config after merging files and KWA_* environment variables.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        configPath := fs.String("config", "", "Path to a config file (skips discovery)")
+
+        return func(ctx context.Context) error {
+            if fs.NArg() == 0 || fs.Arg(0) != "print" {
+                return usagef("usage: kwa config print [-config path]")
+            }
+            // Flags may also follow the "print" action
+            if err := fs.Parse(fs.Args()[1:]); err != nil || fs.NArg() != 0 {
+                return usagef("usage: kwa config print [-config path]")
+            }
+
+            cfg, err := config.Load(*configPath)
+            if err != nil {
+                return err
+            }
+            if _, err := newSettings(cfg); err != nil {
+                return err
+            }
+
+            data, err := cfg.YAML()
+            if err != nil {
+                return fmt.Errorf("failed to render config: %v", err)
+            }
+            if len(cfg.Sources) == 0 {
+                fmt.Println("# sources: defaults")
+            }
+            for _, source := range cfg.Sources {
+                fmt.Printf("# source: %s\n", source)
+            }
+            fmt.Print(string(data))
+            return nil
+        }
+    },
+}
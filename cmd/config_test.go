@@ -0,0 +1,60 @@
+package main
+
+import (
+    "context"
+    "testing"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/config"
+)
+
+func TestSettingsEfficiencyBands(t *testing.T) {
+    strict := config.Default()
+    strict.Efficiency.Low, strict.Efficiency.High = 70, 95
+    loose := config.Default()
+    loose.Efficiency.Low, loose.Efficiency.High = 20, 40
+
+    strictSettings, err := newSettings(strict)
+    if err != nil {
+        t.Fatal(err)
+    }
+    looseSettings, err := newSettings(loose)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // Settings built from different configs must not share bands
+    tests := []struct {
+        name     string
+        settings *settings
+        want     string
+    }{
+        {"strict", strictSettings, "Low (60.0%)"},
+        {"loose", looseSettings, "High (60.0%)"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            details := &analyzer.WorkloadDetails{EfficiencyRate: "Medium (60.0%)"}
+            tt.settings.finish(context.Background(), details, "")
+            if details.EfficiencyRate != tt.want {
+                t.Errorf("expected %q, got %q", tt.want, details.EfficiencyRate)
+            }
+        })
+    }
+
+    if analyzer.DefaultEfficiencyBands.Rate(60) != "Medium (60.0%)" {
+        t.Errorf("expected the default bands to be unchanged, got %q", analyzer.DefaultEfficiencyBands.Rate(60))
+    }
+}
+
+func TestSettingsKeepsUnmeasuredRate(t *testing.T) {
+    s, err := newSettings(config.Default())
+    if err != nil {
+        t.Fatal(err)
+    }
+    details := &analyzer.WorkloadDetails{EfficiencyRate: "N/A (No metrics)"}
+    s.finish(context.Background(), details, "")
+    if details.EfficiencyRate != "N/A (No metrics)" {
+        t.Errorf("expected an unmeasured rate to be kept, got %q", details.EfficiencyRate)
+    }
+}
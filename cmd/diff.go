@@ -0,0 +1,146 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "os"
+    "time"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/report"
+    "k8s-workload-analyzer/pkg/store"
+    "k8s-workload-analyzer/pkg/ui"
+)
+
+var diffCommand = &command{
+    name:    "diff",
+    args:    "<before.json> <after.json> | -name <workload> [-from id] [-to id] [-since 168h]",
+    summary: "Compare two JSON results, or two stored analyses of a workload: requests, efficiency, findings and cost.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        workloadType := fs.String("type", "deployment", "Workload type when comparing stored analyses")
+        workloadName := fs.String("name", "", "Compare stored analyses of this workload")
+        cluster := fs.String("cluster", "", "Cluster the workload was analyzed in (defaults to the current context)")
+        from := fs.Uint64("from", 0, "History ID of the earlier analysis (defaults to the one before -to, or -since)")
+        to := fs.Uint64("to", 0, "History ID of the later analysis (defaults to the latest)")
+        since := fs.Duration("since", 0, "Compare with the latest analysis at least this old, e.g. 168h")
+
+        return func(ctx context.Context) error {
+            files := fs.Args()
+            switch {
+            case *workloadName == "" && len(files) != 2:
+                return usagef("pass two JSON result files, or -name to compare stored analyses")
+            case *workloadName != "" && len(files) != 0:
+                return usagef("-name compares stored analyses and takes no files")
+            case *workloadName == "" && (*from != 0 || *to != 0 || *since != 0):
+                return usagef("-from, -to and -since pick stored analyses and need -name instead of files")
+            case *from != 0 && *since != 0:
+                return usagef("-from and -since cannot be combined")
+            case *from != 0 && *to != 0 && *from >= *to:
+                return usagef("-from %d must be earlier than -to %d", *from, *to)
+            }
+
+            rt, _, cancel, err := g.load(ctx)
+            if err != nil {
+                return err
+            }
+            defer cancel()
+
+            var beforeLabel, afterLabel string
+            var before, after []*analyzer.WorkloadDetails
+            if *workloadName == "" {
+                beforeRep, err := report.ReadFile(files[0])
+                if err != nil {
+                    return err
+                }
+                afterRep, err := report.ReadFile(files[1])
+                if err != nil {
+                    return err
+                }
+                before, after = beforeRep.Workloads(), afterRep.Workloads()
+                beforeLabel = fmt.Sprintf("%s (%s)", files[0], beforeRep.GeneratedAt.Local().Format(time.RFC3339))
+                afterLabel = fmt.Sprintf("%s (%s)", files[1], afterRep.GeneratedAt.Local().Format(time.RFC3339))
+            } else {
+                key, err := historyKey(rt, *cluster, g.namespace, *workloadType, *workloadName)
+                if err != nil {
+                    return err
+                }
+                b, a, err := storedPair(rt, key, *from, *to, *since)
+                if err != nil {
+                    return err
+                }
+                before, after = []*analyzer.WorkloadDetails{b.Workload}, []*analyzer.WorkloadDetails{a.Workload}
+                beforeLabel = fmt.Sprintf("#%d (%s, spec %s)", b.ID, b.Timestamp.Local().Format(time.RFC3339), b.SpecHash)
+                afterLabel = fmt.Sprintf("#%d (%s, spec %s)", a.ID, a.Timestamp.Local().Format(time.RFC3339), a.SpecHash)
+            }
+
+            diff := analyzer.DiffRuns(before, after)
+            if rt.output == "json" {
+                return printJSON(os.Stdout, diff)
+            }
+            fmt.Println(ui.RenderDiff(beforeLabel, afterLabel, diff))
+            return nil
+        }
+    },
+}
+
+// storedPair picks the two records to compare from a workload's history.
+func storedPair(rt *runtime, key string, from, to uint64, since time.Duration) (*store.Record, *store.Record, error) {
+    s, err := store.Open(rt.historyPath())
+    if err != nil {
+        return nil, nil, err
+    }
+    defer s.Close()
+
+    records, err := s.History(key)
+    if err != nil {
+        return nil, nil, err
+    }
+    if len(records) == 0 {
+        return nil, nil, fmt.Errorf("no history for %s", key)
+    }
+
+    find := func(id uint64) (int, error) {
+        for i, rec := range records {
+            if rec.ID == id {
+                return i, nil
+            }
+        }
+        return 0, fmt.Errorf("no record %d for %s", id, key)
+    }
+
+    after := len(records) - 1
+    if to != 0 {
+        if after, err = find(to); err != nil {
+            return nil, nil, err
+        }
+    }
+
+    before := after - 1
+    switch {
+    case from != 0:
+        if before, err = find(from); err != nil {
+            return nil, nil, err
+        }
+        if before >= after {
+            return nil, nil, usagef("-from %d is not earlier than #%d", from, records[after].ID)
+        }
+    case since != 0:
+        cutoff := records[after].Timestamp.Add(-since)
+        before = -1
+        for i := after - 1; i >= 0; i-- {
+            if !records[i].Timestamp.After(cutoff) {
+                before = i
+                break
+            }
+        }
+        if before < 0 {
+            return nil, nil, fmt.Errorf("no analysis of %s older than %s", key, since)
+        }
+    }
+    if before < 0 {
+        return nil, nil, fmt.Errorf("only one analysis of %s; nothing to compare", key)
+    }
+    return &records[before], &records[after], nil
+}
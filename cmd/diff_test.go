@@ -0,0 +1,59 @@
+package main
+
+import (
+    "fmt"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/store"
+)
+
+func TestDiffUsage(t *testing.T) {
+    setupCLI(t, "http://127.0.0.1:1", "http://127.0.0.1:1")
+    path := filepath.Join(t.TempDir(), "history.db")
+    t.Setenv("KWA_HISTORY_PATH", path)
+
+    s, err := store.Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var ids []uint64
+    for i := 0; i < 3; i++ {
+        details := &analyzer.WorkloadDetails{Cluster: "test", Namespace: "default", Kind: "deployment", Deployment: "web"}
+        rec, err := s.Save(store.Record{Timestamp: time.Now().Add(time.Duration(i) * time.Hour), Workload: details})
+        if err != nil {
+            t.Fatal(err)
+        }
+        ids = append(ids, rec.ID)
+    }
+    s.Close()
+
+    stored := []string{"diff", "-cluster", "test", "-name", "web", "-output", "json"}
+    id := func(i int) string { return fmt.Sprint(ids[i]) }
+    tests := []struct {
+        name string
+        args []string
+        want int
+    }{
+        {"no files", []string{"diff", "a.json"}, exitUsage},
+        {"files with -name", []string{"diff", "-name", "web", "a.json", "b.json"}, exitUsage},
+        {"files with -from", []string{"diff", "-from", "1", "a.json", "b.json"}, exitUsage},
+        {"files with -to", []string{"diff", "-to", "2", "a.json", "b.json"}, exitUsage},
+        {"files with -since", []string{"diff", "-since", "1h", "a.json", "b.json"}, exitUsage},
+        {"-from with -since", append(stored, "-from", id(0), "-since", "1h"), exitUsage},
+        {"-from after -to", append(stored, "-from", id(2), "-to", id(1)), exitUsage},
+        {"-from equal to -to", append(stored, "-from", id(1), "-to", id(1)), exitUsage},
+        {"-from is the latest", append(stored, "-from", id(2)), exitUsage},
+        {"-from before -to", append(stored, "-from", id(0), "-to", id(2)), exitOK},
+        {"-from before the latest", append(stored, "-from", id(1)), exitOK},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if code, out := runCLI(t, tt.args...); code != tt.want {
+                t.Errorf("expected exit code %d, got %d:\n%s", tt.want, code, out)
+            }
+        })
+    }
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+
+    "k8s-workload-analyzer/pkg/ai"
+    "k8s-workload-analyzer/pkg/eval"
+    "k8s-workload-analyzer/pkg/ui"
+)
+
+var evalCommand = &command{
+    name:    "eval",
+    args:    "[-corpus dir] [-variant spec]... [-record dir | -replay dir] [flags]",
+    summary: "Score the AI analysis on a corpus of fixture workloads and compare prompt versions and models.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        fs.Lookup("timeout").Usage = "Timeout for each case (0 disables)"
+        corpus := fs.String("corpus", "eval", "Directory of fixture cases (*.yaml)")
+        apiKey := fs.String("api-key", "", "GPT API key (defaults to ai.api_key or $OPENAI_API_KEY)")
+        var variants []string
+        fs.Func("variant", "Variant to evaluate, e.g. name=v3,model=gpt-4o-mini,prompts=./prompts,focus=cost,base-url=http://localhost:11434/v1 (repeatable; defaults to the config)", func(spec string) error {
+            variants = append(variants, spec)
+            return nil
+        })
+        prices := make(map[string]eval.Price)
+        for model, price := range eval.DefaultPrices {
+            prices[model] = price
+        }
+        fs.Func("price", "USD per million input/output tokens of a model, e.g. llama3=0/0 (repeatable)", func(spec string) error {
+            model, price, err := parsePrice(spec)
+            if err != nil {
+                return err
+            }
+            prices[model] = price
+            return nil
+        })
+
+        return func(ctx context.Context) error {
+            rt, _, cancel, err := g.load(ctx)
+            if err != nil {
+                return err
+            }
+            defer cancel()
+
+            cases, err := eval.LoadCorpus(*corpus)
+            if err != nil {
+                return err
+            }
+
+            base := rt.aiConfig(*apiKey)
+            if base.APIKey == "" {
+                return usagef("eval needs an API key (use -api-key, ai.api_key or $OPENAI_API_KEY) or -replay")
+            }
+            if len(variants) == 0 {
+                variants = []string{""}
+            }
+
+            var summaries []*eval.Summary
+            for _, spec := range variants {
+                name, aiCfg, err := parseVariant(spec, base)
+                if err != nil {
+                    return err
+                }
+                provider, err := ai.NewProvider(aiCfg)
+                if err != nil {
+                    return fmt.Errorf("variant %s: failed to create AI client: %v", name, err)
+                }
+                cfg := eval.Config{
+                    Variant:  name,
+                    Provider: provider,
+                    Finish:   rt.settings.finish,
+                    Enrich:   aiEnricher,
+                    Timeout:  g.timeout,
+                }
+                if price, ok := prices[aiCfg.Model]; ok {
+                    cfg.Price = &price
+                }
+                fmt.Fprintf(os.Stderr, "Evaluating %s on %d cases\n", name, len(cases))
+                summaries = append(summaries, eval.Run(ctx, cfg, cases))
+                if ctx.Err() != nil {
+                    return ctx.Err()
+                }
+            }
+
+            if rt.output == "json" {
+                return printJSON(os.Stdout, summaries)
+            }
+            fmt.Print(ui.RenderEval(summaries))
+            return nil
+        }
+    },
+}
+
+// parseVariant applies a comma-separated list of key=value overrides to the
+// configured AI settings. The variant is named after its model and focus
+// unless it sets name.
+func parseVariant(spec string, base ai.Config) (string, ai.Config, error) {
+    cfg := base
+    var name string
+    for _, item := range splitList(spec) {
+        key, value, ok := strings.Cut(item, "=")
+        if !ok {
+            return "", cfg, usagef("invalid -variant %q: want key=value pairs", spec)
+        }
+        switch key {
+        case "name":
+            name = value
+        case "model":
+            cfg.Model = value
+        case "prompts":
+            cfg.PromptsDir = value
+        case "focus":
+            cfg.Focus = value
+        case "base-url":
+            cfg.BaseURL = value
+        case "temperature":
+            t, err := strconv.ParseFloat(value, 64)
+            if err != nil {
+                return "", cfg, usagef("invalid -variant temperature %q", value)
+            }
+            cfg.Temperature = t
+        default:
+            return "", cfg, usagef("invalid -variant key %q (want name, model, prompts, focus, base-url or temperature)", key)
+        }
+    }
+    if name == "" {
+        name = cfg.Model + "/" + cfg.Focus
+    }
+    return name, cfg, nil
+}
+
+func parsePrice(spec string) (string, eval.Price, error) {
+    model, prices, ok := strings.Cut(spec, "=")
+    in, out, ok2 := strings.Cut(prices, "/")
+    if !ok || !ok2 {
+        return "", eval.Price{}, fmt.Errorf("want model=input/output")
+    }
+    input, err := strconv.ParseFloat(in, 64)
+    if err != nil {
+        return "", eval.Price{}, fmt.Errorf("invalid input price %q", in)
+    }
+    output, err := strconv.ParseFloat(out, 64)
+    if err != nil {
+        return "", eval.Price{}, fmt.Errorf("invalid output price %q", out)
+    }
+    return model, eval.Price{Input: input, Output: output}, nil
+}
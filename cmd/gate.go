@@ -0,0 +1,84 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/gate"
+)
+
+// gateOptions are the CI gate flags shared by analyze and scan.
+type gateOptions struct {
+    failOn        string
+    baseline      string
+    writeBaseline string
+}
+
+func addGateFlags(fs *flag.FlagSet) *gateOptions {
+    o := &gateOptions{}
+    fs.StringVar(&o.failOn, "fail-on", "", "Exit with code 3 when a finding is at or above this severity (info, low, medium, high, critical)")
+    fs.StringVar(&o.baseline, "baseline", "", "Baseline file of accepted findings that never fail the gate")
+    fs.StringVar(&o.writeBaseline, "write-baseline", "", "Write the findings that would fail the gate to this baseline file and exit 0")
+    return o
+}
+
+// validate checks the flags before any cluster work is done.
+func (o *gateOptions) validate() error {
+    if o.failOn == "" {
+        if o.baseline != "" {
+            return usagef("-baseline requires -fail-on")
+        }
+        return nil
+    }
+    if _, err := analyzer.ParseSeverity(o.failOn); err != nil {
+        return usagef("invalid -fail-on: %v", err)
+    }
+    return nil
+}
+
+// check gates the workloads once the report has been written. The summary
+// goes to stderr so stdout stays parseable with -output=json.
+func (o *gateOptions) check(workloads []*analyzer.WorkloadDetails) error {
+    if o.failOn == "" && o.writeBaseline == "" {
+        return nil
+    }
+    threshold := analyzer.SeverityInfo
+    if o.failOn != "" {
+        threshold, _ = analyzer.ParseSeverity(o.failOn)
+    }
+    baseline, err := gate.LoadBaseline(o.baseline)
+    if err != nil {
+        return err
+    }
+    result := gate.Check(workloads, threshold, baseline)
+
+    if o.writeBaseline != "" {
+        return writeBaseline(o.writeBaseline, baseline, result)
+    }
+
+    result.WriteSummary(os.Stderr)
+    if result.Failed() {
+        return errFindings
+    }
+    return nil
+}
+
+// writeBaseline keeps the entries of the existing baseline and adds the
+// current violations to them.
+func writeBaseline(file string, existing *gate.Baseline, result *gate.Result) error {
+    b := result.NewBaseline("accepted when the baseline was created")
+    b.Accepted = append(existing.Accepted, b.Accepted...)
+
+    f, err := os.Create(file)
+    if err != nil {
+        return fmt.Errorf("failed to write baseline: %v", err)
+    }
+    defer f.Close()
+    if err := b.Write(f); err != nil {
+        return err
+    }
+    fmt.Fprintf(os.Stderr, "Wrote %d accepted finding(s) to %s\n", len(b.Accepted), file)
+    return nil
+}
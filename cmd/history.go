@@ -0,0 +1,181 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "log/slog"
+    "os"
+    "time"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/store"
+    "k8s-workload-analyzer/pkg/ui"
+)
+
+// defaultHeadroom is the headroom of recommendations saved with history,
+// matching the recommend command's default.
+const defaultHeadroom = 20
+
+// historyPath returns the configured store path.
+func (rt *runtime) historyPath() string {
+    if rt.cfg.History.Path != "" {
+        return rt.cfg.History.Path
+    }
+    return store.DefaultPath()
+}
+
+// record saves analyzed workloads to the history store. History is a side
+// effect of a run, so failures are logged as warnings only.
+func (rt *runtime) record(ctx context.Context, workloads []*analyzer.WorkloadDetails) {
+    if !rt.cfg.History.Enabled || rt.replaying() || len(workloads) == 0 {
+        return
+    }
+    path := rt.historyPath()
+    s, err := store.Open(path)
+    if err != nil {
+        slog.WarnContext(ctx, "not saving history", "path", path, "error", err)
+        return
+    }
+    defer s.Close()
+
+    now := time.Now().UTC()
+    for _, details := range workloads {
+        _, err := s.Save(store.Record{
+            Timestamp:       now,
+            SpecHash:        store.SpecHash(details),
+            Workload:        details,
+            Recommendations: analyzer.RecommendResources(details, defaultHeadroom, rt.settings.pricing),
+        })
+        if err != nil {
+            slog.WarnContext(ctx, "failed to save history", "path", path, "workload", store.KeyOf(details), "error", err)
+            return
+        }
+    }
+}
+
+// historyKey resolves the cluster and namespace of a workload like analyze
+// does, but without talking to the cluster.
+func historyKey(rt *runtime, cluster, namespace, workloadType, name string) (string, error) {
+    if cluster == "" || namespace == "" {
+        c, err := rt.loadCluster(rt.opts)
+        if err != nil {
+            return "", err
+        }
+        if cluster == "" {
+            cluster = c.Name
+        }
+        if namespace == "" {
+            namespace = c.Namespace
+        }
+    }
+    return store.Key(cluster, namespace, workloadType, name), nil
+}
+
+var historyActions = []string{"list", "trend", "findings", "workloads"}
+
+const historyArgs = "[list|trend|findings|workloads] -name <workload> [flags]"
+
+var historyCommand = &command{
+    name:    "history",
+    args:    historyArgs,
+    summary: "Show stored analyses of a workload, its efficiency trend, or when findings appeared and were resolved.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        workloadType := fs.String("type", "deployment", "Workload type (deployment, statefulset, daemonset)")
+        workloadName := fs.String("name", "", "Workload name")
+        cluster := fs.String("cluster", "", "Cluster the workload was analyzed in (defaults to the current context)")
+        limit := fs.Int("limit", 0, "Only show the most recent runs, or the findings seen in them (0 shows all)")
+
+        return func(ctx context.Context) error {
+            action := "list"
+            if fs.NArg() > 0 {
+                action = fs.Arg(0)
+                // Flags may also follow the action
+                if err := fs.Parse(fs.Args()[1:]); err != nil || fs.NArg() != 0 {
+                    return usagef("usage: kwa history %s", historyArgs)
+                }
+            }
+            if !contains(historyActions, action) {
+                return usagef("unknown history action %q (want list, trend, findings or workloads)", action)
+            }
+            if action != "workloads" && *workloadName == "" {
+                return usagef("-name is required")
+            }
+
+            rt, _, cancel, err := g.load(ctx)
+            if err != nil {
+                return err
+            }
+            defer cancel()
+
+            s, err := store.Open(rt.historyPath())
+            if err != nil {
+                return err
+            }
+            defer s.Close()
+
+            if action == "workloads" {
+                keys, err := s.Workloads()
+                if err != nil {
+                    return err
+                }
+                if rt.output == "json" {
+                    return printJSON(os.Stdout, keys)
+                }
+                for _, key := range keys {
+                    fmt.Println(key)
+                }
+                return nil
+            }
+
+            key, err := historyKey(rt, *cluster, g.namespace, *workloadType, *workloadName)
+            if err != nil {
+                return err
+            }
+
+            records, err := s.History(key)
+            if err != nil {
+                return err
+            }
+            // Findings are tracked over the whole history so first seen and
+            // resolved stay accurate; -limit only trims what is shown.
+            spans := store.FindingSpans(records)
+            if *limit > 0 && len(records) > *limit {
+                records = records[len(records)-*limit:]
+                spans = spansSince(spans, records[0].Timestamp)
+            }
+
+            switch action {
+            case "trend":
+                if rt.output == "json" {
+                    return printJSON(os.Stdout, records)
+                }
+                fmt.Println(ui.RenderTrend(key, records, rt.settings.bands))
+            case "findings":
+                if rt.output == "json" {
+                    return printJSON(os.Stdout, spans)
+                }
+                fmt.Println(ui.RenderFindingHistory(key, spans))
+            default:
+                if rt.output == "json" {
+                    return printJSON(os.Stdout, records)
+                }
+                fmt.Println(ui.RenderHistory(key, records))
+            }
+            return nil
+        }
+    },
+}
+
+// spansSince keeps the finding spans that are open or were resolved at or
+// after since.
+func spansSince(spans []store.FindingSpan, since time.Time) []store.FindingSpan {
+    var kept []store.FindingSpan
+    for _, span := range spans {
+        if span.Resolved == nil || !span.Resolved.Before(since) {
+            kept = append(kept, span)
+        }
+    }
+    return kept
+}
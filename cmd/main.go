@@ -1,78 +1,456 @@
 package main
 
 import (
+    "context"
+    "encoding/json"
+    "errors"
     "flag"
     "fmt"
-    "log"
+    "io"
+    "log/slog"
+    "net/http"
     "os"
+    "os/signal"
+    "strings"
+    "syscall"
+    "time"
 
     "k8s.io/client-go/kubernetes"
-    "k8s.io/client-go/tools/clientcmd"
-    "k8s-workload-analyzer/pkg/analyzer"
-    "k8s-workload-analyzer/pkg/ai"
-    "k8s-workload-analyzer/pkg/ui"
+    "k8s.io/client-go/rest"
+    metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+    "k8s-workload-analyzer/pkg/config"
+    "k8s-workload-analyzer/pkg/kube"
+    "k8s-workload-analyzer/pkg/logging"
+    "k8s-workload-analyzer/pkg/replay"
+    "k8s-workload-analyzer/pkg/tracing"
 )
 
+// Exit codes are part of the CLI contract for scripts.
+const (
+    exitOK          = 0
+    exitError       = 1
+    exitUsage       = 2
+    exitFindings    = 3
+    exitInterrupted = 130
+)
+
+// command is a kwa subcommand. flags registers the command's flags on a new
+// flag set and returns the function that runs it once they are parsed.
+type command struct {
+    name    string
+    args    string
+    summary string
+    flags   func(fs *flag.FlagSet) func(ctx context.Context) error
+}
+
+var commands []*command
+
+func init() {
+    commands = []*command{
+        analyzeCommand,
+        chatCommand,
+        scanCommand,
+        recommendCommand,
+        reportCommand,
+        historyCommand,
+        diffCommand,
+        serveCommand,
+        operatorCommand,
+        webhookCommand,
+        tuiCommand,
+        configCommand,
+        promptsCommand,
+        evalCommand,
+        completionCommand,
+        versionCommand,
+    }
+}
+
 func main() {
-    namespace := flag.String("namespace", "", "Kubernetes namespace")
-    workloadType := flag.String("type", "deployment", "Workload type (deployment, statefulset, daemonset)")
-    workloadName := flag.String("name", "", "Workload name")
-    apiKey := flag.String("api-key", "", "GPT API key")
-    flag.Parse()
+    os.Exit(run(os.Args[1:]))
+}
 
-    if *namespace == "" || *workloadName == "" || *apiKey == "" {
-        flag.Usage()
-        os.Exit(1)
+func run(args []string) int {
+    if len(args) == 0 {
+        printUsage(os.Stderr)
+        return exitUsage
     }
 
-    // Initialize Kubernetes client
-    config, err := clientcmd.BuildConfigFromFlags("", clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename())
-    if err != nil {
-        log.Fatalf("Failed to get kubeconfig: %v", err)
+    name := args[0]
+    switch name {
+    case "-h", "-help", "--help":
+        printUsage(os.Stdout)
+        return exitOK
+    case "help":
+        if len(args) < 2 {
+            printUsage(os.Stdout)
+            return exitOK
+        }
+        cmd := findCommand(args[1])
+        if cmd == nil {
+            fmt.Fprintf(os.Stderr, "Unknown command %q\n", args[1])
+            return exitUsage
+        }
+        fs, _ := newFlagSet(cmd)
+        fs.SetOutput(os.Stdout)
+        fs.Usage()
+        return exitOK
+    }
+
+    cmd := findCommand(name)
+    if cmd == nil {
+        fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+        printUsage(os.Stderr)
+        return exitUsage
+    }
+
+    fs, runCmd := newFlagSet(cmd)
+    if err := fs.Parse(args[1:]); err != nil {
+        if errors.Is(err, flag.ErrHelp) {
+            return exitOK
+        }
+        return exitUsage
+    }
+
+    // Cancel all in-flight calls on Ctrl-C; commands add the timeout
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    return exitCode(ctx, runCmd(ctx))
+}
+
+func findCommand(name string) *command {
+    for _, cmd := range commands {
+        if cmd.name == name {
+            return cmd
+        }
     }
+    return nil
+}
+
+func newFlagSet(cmd *command) (*flag.FlagSet, func(ctx context.Context) error) {
+    fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+    runCmd := cmd.flags(fs)
+    fs.Usage = func() {
+        fmt.Fprintf(fs.Output(), "Usage: kwa %s %s\n\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+        fs.PrintDefaults()
+    }
+    return fs, runCmd
+}
+
+func printUsage(w io.Writer) {
+    fmt.Fprintln(w, "Usage: kwa <command> [flags]")
+    fmt.Fprintln(w)
+    fmt.Fprintln(w, "Commands:")
+    for _, cmd := range commands {
+        fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.summary)
+    }
+    fmt.Fprintln(w)
+    fmt.Fprintln(w, `Run "kwa help <command>" for the flags of a command.`)
+}
+
+// usageError is returned by commands for invalid flag combinations.
+type usageError struct {
+    msg string
+}
+
+func (e *usageError) Error() string {
+    return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+    return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// exitCodeError lets a command pick its exit code, e.g. for CI gates. A nil
+// err exits quietly, for commands that already reported why.
+type exitCodeError struct {
+    code int
+    err  error
+}
+
+func (e *exitCodeError) Error() string {
+    if e.err == nil {
+        return fmt.Sprintf("exit code %d", e.code)
+    }
+    return e.err.Error()
+}
+
+// errFindings fails a CI gate once its summary has been written.
+var errFindings = &exitCodeError{code: exitFindings}
+
+// exitCode reports err and maps it to the process exit code. Cancellation by
+// Ctrl-C or the timeout is reported briefly rather than as a wrapped error.
+func exitCode(ctx context.Context, err error) int {
+    if err == nil {
+        return exitOK
+    }
+
+    var usageErr *usageError
+    var codeErr *exitCodeError
+    switch {
+    case errors.Is(ctx.Err(), context.Canceled):
+        fmt.Fprintln(os.Stderr, "Interrupted")
+        return exitInterrupted
+    case errors.Is(err, context.DeadlineExceeded):
+        fmt.Fprintln(os.Stderr, "Timed out")
+        return exitError
+    case errors.As(err, &usageErr):
+        fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+        return exitUsage
+    case errors.As(err, &codeErr):
+        if codeErr.err != nil {
+            fmt.Fprintln(os.Stderr, codeErr.err)
+        }
+        return codeErr.code
+    }
+    fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+    return exitError
+}
+
+// globalOptions are the flags shared by every command that talks to a
+// cluster or renders results.
+type globalOptions struct {
+    configPath        string
+    kubeconfig        string
+    context           string
+    impersonate       string
+    impersonateGroups []string
+    namespace         string
+    timeout           time.Duration
+    output            string
+    record            string
+    replay            string
+    verbose           bool
+    logLevel          string
+    logFormat         string
+    trace             string
 
-    k8sClient, err := kubernetes.NewForConfig(config)
+    fs *flag.FlagSet
+}
+
+func addGlobalFlags(fs *flag.FlagSet) *globalOptions {
+    g := &globalOptions{fs: fs}
+    fs.StringVar(&g.configPath, "config", "", "Path to a config file (defaults to ~/.config/kwa/config.yaml and ./.kwa.yaml)")
+    fs.StringVar(&g.kubeconfig, "kubeconfig", "", "Path to kubeconfig (defaults to KUBECONFIG, ~/.kube/config, then in-cluster config)")
+    fs.StringVar(&g.context, "context", "", "Kubeconfig context to use (defaults to the current context)")
+    fs.StringVar(&g.impersonate, "as", "", "Username to impersonate for Kubernetes calls")
+    fs.Func("as-group", "Group to impersonate for Kubernetes calls (repeatable)", func(group string) error {
+        g.impersonateGroups = append(g.impersonateGroups, group)
+        return nil
+    })
+    fs.StringVar(&g.namespace, "namespace", "", "Kubernetes namespace (defaults to the context's namespace)")
+    fs.DurationVar(&g.timeout, "timeout", 5*time.Minute, "Overall timeout for the command (0 disables)")
+    fs.StringVar(&g.output, "output", "text", "Output format (text, json)")
+    fs.StringVar(&g.record, "record", "", "Save every Kubernetes, metrics and AI exchange of the run to this bundle directory")
+    fs.StringVar(&g.replay, "replay", "", "Run from a bundle saved with -record, without a cluster or network")
+    fs.BoolVar(&g.verbose, "v", false, "Log debug details, e.g. each Kubernetes and AI call and phase timings (same as -log-level=debug)")
+    fs.StringVar(&g.logLevel, "log-level", "info", "Level of the logs written to stderr (debug, info, warn, error)")
+    fs.StringVar(&g.logFormat, "log-format", logging.FormatText, "Format of the logs written to stderr (text, json)")
+    fs.StringVar(&g.trace, "trace", tracing.ExporterNone, "Export OpenTelemetry spans: none, otlp (configured by OTEL_EXPORTER_OTLP_*), or stdout (JSON written to stderr)")
+    return g
+}
+
+// runtime is what a command needs after flags and config are resolved.
+type runtime struct {
+    cfg      *config.Config
+    settings *settings
+    opts     kube.ConfigOptions
+    output   string
+    bundle   *replay.Bundle // set with -record or -replay
+}
+
+// load reads the config and lets flags given on the command line win over it.
+// The returned context carries the timeout; call cancel when done.
+func (g *globalOptions) load(ctx context.Context, outputs ...string) (*runtime, context.Context, context.CancelFunc, error) {
+    if g.verbose {
+        g.logLevel = "debug"
+    }
+    logger, err := logging.New(os.Stderr, g.logLevel, g.logFormat)
     if err != nil {
-        log.Fatalf("Failed to create kubernetes client: %v", err)
+        return nil, nil, nil, usagef("%v", err)
     }
+    slog.SetDefault(logger)
 
-    // Get workload YAML and analyze
-    yaml, err := analyzer.GetWorkloadYAML(k8sClient, *namespace, *workloadType, *workloadName)
+    cfg, err := config.Load(g.configPath)
     if err != nil {
-        log.Fatalf("Failed to get workload: %v", err)
+        return nil, nil, nil, err
+    }
+    s, err := newSettings(cfg)
+    if err != nil {
+        return nil, nil, nil, err
+    }
+
+    setFlags := make(map[string]bool)
+    g.fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+    if !setFlags["timeout"] {
+        g.timeout = cfg.Timeout
+    }
+    if !setFlags["output"] {
+        g.output = cfg.Output.Format
+    }
+
+    if len(outputs) == 0 {
+        outputs = []string{"text", "json"}
+    }
+    if !contains(outputs, g.output) {
+        return nil, nil, nil, usagef("unsupported output format %q (want %s)", g.output, strings.Join(outputs, ", "))
     }
 
-    // Initialize AI client and analyze
-    aiClient := ai.NewGPTClient(*apiKey)
-    analysis, err := aiClient.AnalyzeWorkload(yaml)
+    var bundle *replay.Bundle
+    switch {
+    case g.record != "" && g.replay != "":
+        return nil, nil, nil, usagef("-record and -replay are exclusive")
+    case g.record != "":
+        if bundle, err = replay.Create(g.record, g.args()); err != nil {
+            return nil, nil, nil, err
+        }
+    case g.replay != "":
+        if bundle, err = replay.Open(g.replay); err != nil {
+            return nil, nil, nil, err
+        }
+    }
+
+    shutdown, err := tracing.Setup(ctx, g.trace, os.Stderr)
     if err != nil {
-        log.Fatalf("Failed to analyze workload: %v", err)
+        return nil, nil, nil, err
     }
 
-    // Get workload details with metrics
-    details, err := analyzer.AnalyzeWorkload(k8sClient, *namespace, *workloadType, *workloadName, config)
+    // Every Kubernetes and AI call of the run is logged with this ID
+    id := logging.NewRequestID()
+    ctx = logging.WithRequestID(ctx, id)
+    ctx, span := tracing.Start(ctx, "kwa "+g.fs.Name(), tracing.RequestID.String(id))
+    cancelTimeout := context.CancelFunc(func() {})
+    if g.timeout > 0 {
+        ctx, cancelTimeout = context.WithTimeout(ctx, g.timeout)
+    }
+    cancel := func() {
+        cancelTimeout()
+        span.End()
+        flushCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+        defer stop()
+        if err := shutdown(flushCtx); err != nil {
+            slog.Warn("failed to export traces", "error", err)
+        }
+    }
+
+    return &runtime{
+        cfg:      cfg,
+        settings: s,
+        opts: kube.ConfigOptions{
+            Kubeconfig:        g.kubeconfig,
+            Context:           g.context,
+            Impersonate:       g.impersonate,
+            ImpersonateGroups: g.impersonateGroups,
+        },
+        output: g.output,
+        bundle: bundle,
+    }, ctx, cancel, nil
+}
+
+// args returns the command line of the run for the bundle, without secrets.
+func (g *globalOptions) args() []string {
+    args := []string{g.fs.Name()}
+    g.fs.Visit(func(f *flag.Flag) {
+        switch f.Name {
+        case "record":
+        case "api-key":
+            args = append(args, "-api-key=REDACTED")
+        default:
+            args = append(args, fmt.Sprintf("-%s=%s", f.Name, f.Value))
+        }
+    })
+    return append(args, g.fs.Args()...)
+}
+
+// replaying reports whether the run is answered from a bundle.
+func (rt *runtime) replaying() bool {
+    return rt.bundle != nil && !rt.bundle.Recording()
+}
+
+// loadCluster loads the config of a cluster. With -record its calls are saved
+// to the bundle; with -replay the cluster is the one recorded in the bundle
+// and kubeconfig is not read.
+func (rt *runtime) loadCluster(opts kube.ConfigOptions) (*kube.Cluster, error) {
+    if rt.replaying() {
+        c, err := rt.bundle.Cluster(opts.Context)
+        if err != nil {
+            return nil, err
+        }
+        return &kube.Cluster{
+            Name:      c.Name,
+            Namespace: c.Namespace,
+            Config:    &rest.Config{Host: c.Host, Transport: logging.Transport("kube", rt.bundle.Transport(replay.Kube, nil))},
+        }, nil
+    }
+
+    cluster, err := kube.LoadConfig(opts)
     if err != nil {
-        log.Printf("Warning: Failed to get workload details: %v", err)
+        return nil, err
     }
+    if rt.bundle != nil {
+        cluster.Config.Wrap(func(next http.RoundTripper) http.RoundTripper {
+            return rt.bundle.Transport(replay.Kube, next)
+        })
+        err = rt.bundle.AddCluster(replay.ClusterMeta{
+            Name:      cluster.Name,
+            Namespace: cluster.Namespace,
+            Host:      cluster.Config.Host,
+        })
+        if err != nil {
+            return nil, err
+        }
+    }
+    cluster.Config.Wrap(func(next http.RoundTripper) http.RoundTripper {
+        return logging.Transport("kube", next)
+    })
+    return cluster, nil
+}
 
-    // Debug output
-    fmt.Printf("Debug - Before AI analysis - Efficiency Rate: %s\n", details.EfficiencyRate)
+// connect loads the cluster config and clients. The metrics client is nil
+// when usage collection is disabled in the config.
+func (rt *runtime) connect(namespace string) (*kube.Cluster, kubernetes.Interface, metricsv.Interface, string, error) {
+    cluster, err := rt.loadCluster(rt.opts)
+    if err != nil {
+        return nil, nil, nil, "", err
+    }
+    k8sClient, metricsClient, err := cluster.Clients()
+    if err != nil {
+        return nil, nil, nil, "", err
+    }
+    if !rt.settings.metricsEnabled {
+        metricsClient = nil
+    }
+    if namespace == "" {
+        namespace = cluster.Namespace
+    }
+    return cluster, k8sClient, metricsClient, namespace, nil
+}
 
-    // Update details with AI analysis
-    if details != nil {
-        // Don't overwrite efficiency rate from metrics
-        // details.EfficiencyRate = analysis.EfficiencyRate
-        
-        details.ReliabilityRisk = analysis.ReliabilityRisk
-        details.Analysis = analysis.Analysis
-        details.Opportunities = analysis.Opportunities
-        details.Cautions = analysis.Cautions
-        details.Blockers = analysis.Blockers
-        details.Recommendations = analysis.Recommendations
+func printJSON(w io.Writer, v interface{}) error {
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    if err := encoder.Encode(v); err != nil {
+        return fmt.Errorf("failed to encode output: %v", err)
     }
+    return nil
+}
 
-    fmt.Printf("Debug - After AI analysis - Efficiency Rate: %s\n", details.EfficiencyRate)
+func splitList(value string) []string {
+    var items []string
+    for _, item := range strings.Split(value, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
 
-    // Render and display results
-    fmt.Println(ui.RenderAnalysis(details))
-}
\ No newline at end of file
+func contains(items []string, item string) bool {
+    for _, i := range items {
+        if i == item {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "syscall"
+    "testing"
+    "time"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/resource"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+    "k8s-workload-analyzer/pkg/report"
+)
+
+// newAPIServer fakes the Kubernetes and metrics API for a deployment web
+// with two pods. Anything else is not found.
+func newAPIServer(t *testing.T) *httptest.Server {
+    t.Helper()
+    labels := map[string]string{"app": "web"}
+    replicas := int32(2)
+    container := corev1.Container{
+        Name:  "app",
+        Image: "web:1.0",
+        Resources: corev1.ResourceRequirements{
+            Requests: corev1.ResourceList{
+                corev1.ResourceCPU:    resource.MustParse("500m"),
+                corev1.ResourceMemory: resource.MustParse("512Mi"),
+            },
+        },
+    }
+
+    objects := map[string]interface{}{
+        "/apis/apps/v1/namespaces/default/deployments/web": &appsv1.Deployment{
+            TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+            ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+            Spec: appsv1.DeploymentSpec{
+                Replicas: &replicas,
+                Selector: &metav1.LabelSelector{MatchLabels: labels},
+                Template: corev1.PodTemplateSpec{
+                    ObjectMeta: metav1.ObjectMeta{Labels: labels},
+                    Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+                },
+            },
+        },
+    }
+    pods := &corev1.PodList{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PodList"}}
+    for i := 0; i < 2; i++ {
+        name := fmt.Sprintf("web-%d", i)
+        pods.Items = append(pods.Items, corev1.Pod{
+            ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+            Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+            Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+        })
+        objects["/apis/metrics.k8s.io/v1beta1/namespaces/default/pods/"+name] = &metricsv1beta1.PodMetrics{
+            TypeMeta:   metav1.TypeMeta{APIVersion: "metrics.k8s.io/v1beta1", Kind: "PodMetrics"},
+            ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+            Containers: []metricsv1beta1.ContainerMetrics{{
+                Name: "app",
+                Usage: corev1.ResourceList{
+                    corev1.ResourceCPU:    resource.MustParse("100m"),
+                    corev1.ResourceMemory: resource.MustParse("128Mi"),
+                },
+            }},
+        }
+    }
+    objects["/api/v1/namespaces/default/pods"] = pods
+
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        obj, ok := objects[r.URL.Path]
+        if !ok {
+            w.WriteHeader(http.StatusNotFound)
+            json.NewEncoder(w).Encode(&metav1.Status{
+                TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"},
+                Status:   metav1.StatusFailure,
+                Reason:   metav1.StatusReasonNotFound,
+                Code:     http.StatusNotFound,
+            })
+            return
+        }
+        json.NewEncoder(w).Encode(obj)
+    }))
+    t.Cleanup(srv.Close)
+    return srv
+}
+
+func newAIServer(t *testing.T) *httptest.Server {
+    t.Helper()
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        content, _ := json.Marshal(`{"analysis": "Requests are well above usage.", "opportunities": [{"text": "Lower the CPU request", "findings": [], "metrics": {}}]}`)
+        fmt.Fprintf(w, `{"choices": [{"message": {"content": %s}}], "usage": {"prompt_tokens": 10, "completion_tokens": 5}}`, content)
+    }))
+    t.Cleanup(srv.Close)
+    return srv
+}
+
+// setupCLI points kwa at the fake servers through a kubeconfig and the
+// environment, isolated from the user's config and history.
+func setupCLI(t *testing.T, apiServer, aiServer string) {
+    t.Helper()
+    dir := t.TempDir()
+    kubeconfig := filepath.Join(dir, "kubeconfig")
+    err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: `+apiServer+`
+users:
+- name: test
+  user:
+    token: secret-token
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: default
+current-context: test
+`), 0o600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("HOME", dir)
+    t.Setenv("KUBECONFIG", kubeconfig)
+    t.Setenv("KWA_HISTORY_ENABLED", "false")
+    t.Setenv("KWA_AI_BASE_URL", aiServer)
+    t.Setenv("OPENAI_API_KEY", "secret-key")
+}
+
+// runCLI runs kwa with args and returns its exit code and stdout.
+func runCLI(t *testing.T, args ...string) (int, string) {
+    t.Helper()
+    r, w, err := os.Pipe()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stdout := os.Stdout
+    os.Stdout = w
+    out := make(chan []byte)
+    go func() {
+        data, _ := io.ReadAll(r)
+        out <- data
+    }()
+
+    code := run(args)
+    w.Close()
+    os.Stdout = stdout
+    return code, string(<-out)
+}
+
+func workloadsJSON(t *testing.T, out string) string {
+    t.Helper()
+    rep, err := report.Decode(strings.NewReader(out))
+    if err != nil {
+        t.Fatalf("failed to decode report: %v\n%s", err, out)
+    }
+    data, err := json.Marshal(rep.Workloads())
+    if err != nil {
+        t.Fatal(err)
+    }
+    return string(data)
+}
+
+func TestAnalyzeRecordAndReplay(t *testing.T) {
+    apiServer := newAPIServer(t)
+    aiServer := newAIServer(t)
+    setupCLI(t, apiServer.URL, aiServer.URL)
+    bundle := filepath.Join(t.TempDir(), "bundle")
+
+    code, recorded := runCLI(t, "analyze", "-name", "web", "-output", "json", "-record", bundle)
+    if code != exitOK {
+        t.Fatalf("record exited with %d:\n%s", code, recorded)
+    }
+    want := workloadsJSON(t, recorded)
+    for _, part := range []string{`"cpu_usage_millicores":100`, `"Lower the CPU request"`} {
+        if !strings.Contains(want, part) {
+            t.Errorf("expected the recorded analysis to contain %s:\n%s", part, want)
+        }
+    }
+
+    filepath.Walk(bundle, func(path string, info os.FileInfo, err error) error {
+        if err != nil || info.IsDir() {
+            return err
+        }
+        data, err := os.ReadFile(path)
+        if err == nil && (strings.Contains(string(data), "secret-token") || strings.Contains(string(data), "secret-key")) {
+            t.Errorf("%s contains credentials", path)
+        }
+        return err
+    })
+
+    // Without the servers, kubeconfig or an API key, the bundle is all there is
+    apiServer.Close()
+    aiServer.Close()
+    t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+    t.Setenv("OPENAI_API_KEY", "")
+
+    code, replayed := runCLI(t, "analyze", "-name", "web", "-output", "json", "-replay", bundle, "-v", "-log-format=json")
+    if code != exitOK {
+        t.Fatalf("replay exited with %d:\n%s", code, replayed)
+    }
+    if got := workloadsJSON(t, replayed); got != want {
+        t.Errorf("replay differs from the recording:\n got %s\nwant %s", got, want)
+    }
+
+    if code, _ := runCLI(t, "analyze", "-name", "api", "-output", "json", "-replay", bundle); code != exitError {
+        t.Errorf("expected a run that wasn't recorded to fail, got exit code %d", code)
+    }
+}
+
+func TestRecordReplayUsage(t *testing.T) {
+    setupCLI(t, "http://127.0.0.1:1", "http://127.0.0.1:1")
+    dir := t.TempDir()
+
+    if code, _ := runCLI(t, "analyze", "-name", "web", "-record", dir, "-replay", dir); code != exitUsage {
+        t.Errorf("expected -record with -replay to be a usage error, got %d", code)
+    }
+    if code, _ := runCLI(t, "analyze", "-name", "web", "-replay", dir); code != exitError {
+        t.Errorf("expected replaying a directory without a bundle to fail, got %d", code)
+    }
+    if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    if code, _ := runCLI(t, "analyze", "-name", "web", "-record", dir); code != exitError {
+        t.Errorf("expected recording into a non-empty directory to fail, got %d", code)
+    }
+}
+
+func TestServeDefaultFlags(t *testing.T) {
+    apiServer := newAPIServer(t)
+    setupCLI(t, apiServer.URL, "http://127.0.0.1:1")
+
+    l, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    addr := l.Addr().String()
+    l.Close()
+
+    done := make(chan int, 1)
+    go func() {
+        done <- run([]string{"serve", "-addr", addr})
+    }()
+
+    deadline := time.Now().Add(10 * time.Second)
+    for {
+        resp, err := http.Get("http://" + addr + "/healthz")
+        if err == nil {
+            resp.Body.Close()
+            break
+        }
+        select {
+        case code := <-done:
+            t.Fatalf("serve exited with %d before serving", code)
+        default:
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("serve didn't start: %v", err)
+        }
+        time.Sleep(20 * time.Millisecond)
+    }
+
+    // An API key alone doesn't let callers spend credits
+    tests := []struct {
+        body   string
+        status int
+    }{
+        {`{"name": "web", "ai": true}`, http.StatusBadRequest},
+        {`{"name": "web", "kind": "Deployment"}`, http.StatusOK},
+    }
+    for _, tt := range tests {
+        resp, err := http.Post("http://"+addr+"/analyze", "application/json", strings.NewReader(tt.body))
+        if err != nil {
+            t.Fatal(err)
+        }
+        resp.Body.Close()
+        if resp.StatusCode != tt.status {
+            t.Errorf("POST /analyze %s: expected %d, got %d", tt.body, tt.status, resp.StatusCode)
+        }
+    }
+
+    // serve is listening, so its signal handler is in place
+    if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+        t.Fatal(err)
+    }
+    select {
+    case code := <-done:
+        if code != exitOK {
+            t.Errorf("expected serve to stop cleanly, got exit code %d", code)
+        }
+    case <-time.After(10 * time.Second):
+        t.Fatal("serve didn't stop on SIGINT")
+    }
+}
+
+func TestServeAllowAIWithoutKey(t *testing.T) {
+    setupCLI(t, "http://127.0.0.1:1", "http://127.0.0.1:1")
+    t.Setenv("OPENAI_API_KEY", "")
+
+    if code, _ := runCLI(t, "serve", "-allow-ai"); code != exitUsage {
+        t.Errorf("expected -allow-ai without an API key to be a usage error, got %d", code)
+    }
+}
+
+func TestAnalyzeTimeout(t *testing.T) {
+    block := make(chan struct{})
+    apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        select {
+        case <-block:
+        case <-r.Context().Done():
+        }
+    }))
+    t.Cleanup(apiServer.Close)
+    t.Cleanup(func() { close(block) })
+    setupCLI(t, apiServer.URL, "http://127.0.0.1:1")
+
+    r, w, err := os.Pipe()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stderr := os.Stderr
+    os.Stderr = w
+    code, _ := runCLI(t, "analyze", "-name", "web", "-no-ai", "-timeout", "200ms")
+    w.Close()
+    os.Stderr = stderr
+    logs, _ := io.ReadAll(r)
+
+    if code != exitError {
+        t.Errorf("expected exit code %d, got %d", exitError, code)
+    }
+    if !strings.Contains(string(logs), "Timed out") {
+        t.Errorf("expected the timeout to be reported, got:\n%s", logs)
+    }
+}
+
+func TestWarningsAreStructured(t *testing.T) {
+    apiServer := newAPIServer(t)
+    setupCLI(t, apiServer.URL, "http://127.0.0.1:1")
+    t.Setenv("OPENAI_API_KEY", "")
+    // A history path under a regular file can't be opened
+    notDir := filepath.Join(t.TempDir(), "file")
+    if err := os.WriteFile(notDir, nil, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    t.Setenv("KWA_HISTORY_ENABLED", "true")
+    t.Setenv("KWA_HISTORY_PATH", filepath.Join(notDir, "history.db"))
+
+    r, w, err := os.Pipe()
+    if err != nil {
+        t.Fatal(err)
+    }
+    stderr := os.Stderr
+    os.Stderr = w
+    code, out := runCLI(t, "analyze", "-name", "web", "-log-format", "json")
+    w.Close()
+    os.Stderr = stderr
+    logs, _ := io.ReadAll(r)
+
+    if code != exitOK {
+        t.Fatalf("analyze exited with %d:\n%s\n%s", code, out, logs)
+    }
+    var msgs []string
+    for _, line := range strings.Split(strings.TrimSpace(string(logs)), "\n") {
+        var record struct {
+            Level     string `json:"level"`
+            Msg       string `json:"msg"`
+            RequestID string `json:"request_id"`
+        }
+        if err := json.Unmarshal([]byte(line), &record); err != nil {
+            t.Fatalf("expected only JSON log lines on stderr, got %q", line)
+        }
+        if record.Level != "WARN" || record.RequestID == "" {
+            t.Errorf("expected a warning with a request ID, got %s", line)
+        }
+        msgs = append(msgs, record.Msg)
+    }
+    want := "skipping AI analysis: no API key,not saving history"
+    if strings.Join(msgs, ",") != want {
+        t.Errorf("expected warnings %q, got %q", want, msgs)
+    }
+}
+
+func TestTraceStdoutKeepsOutputClean(t *testing.T) {
+    apiServer := newAPIServer(t)
+    setupCLI(t, apiServer.URL, "http://127.0.0.1:1")
+
+    code, out := runCLI(t, "analyze", "-name", "web", "-no-ai", "-output", "json", "-trace", "stdout")
+    if code != exitOK {
+        t.Fatalf("analyze exited with %d:\n%s", code, out)
+    }
+    var v interface{}
+    if err := json.Unmarshal([]byte(out), &v); err != nil {
+        t.Errorf("expected stdout to be the JSON report only: %v\n%s", err, out)
+    }
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+    "context"
+    "sync"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// analyzeClusters analyzes the same workload, or the whole namespace when name
+// is empty, in every kubeconfig context concurrently. Results keep the order
+// of contexts; a cluster that fails is reported in its result, not returned.
+func analyzeClusters(ctx context.Context, rt *runtime, contexts []string, namespace, workloadType, name string) []analyzer.ClusterResult {
+    results := make([]analyzer.ClusterResult, len(contexts))
+
+    var wg sync.WaitGroup
+    for i, kubeContext := range contexts {
+        wg.Add(1)
+        go func(i int, kubeContext string) {
+            defer wg.Done()
+            results[i] = analyzeCluster(ctx, rt, kubeContext, namespace, workloadType, name)
+        }(i, kubeContext)
+    }
+    wg.Wait()
+    return results
+}
+
+func analyzeCluster(ctx context.Context, rt *runtime, kubeContext, namespace, workloadType, name string) analyzer.ClusterResult {
+    result := analyzer.ClusterResult{Cluster: kubeContext}
+    s := rt.settings
+
+    opts := rt.opts
+    opts.Context = kubeContext
+    cluster, err := rt.loadCluster(opts)
+    if err != nil {
+        result.Error = err.Error()
+        return result
+    }
+    k8sClient, metricsClient, err := cluster.Clients()
+    if err != nil {
+        result.Error = err.Error()
+        return result
+    }
+    if !s.metricsEnabled {
+        metricsClient = nil
+    }
+    if namespace == "" {
+        namespace = cluster.Namespace
+    }
+
+    if name != "" {
+        details, err := analyzer.AnalyzeWorkload(ctx, k8sClient, metricsClient, namespace, workloadType, name)
+        if err != nil {
+            result.Error = err.Error()
+            return result
+        }
+        result.Workloads = []*analyzer.WorkloadDetails{details}
+    } else {
+        result.Workloads, err = analyzer.AnalyzeNamespace(ctx, k8sClient, metricsClient, namespace)
+        if err != nil {
+            result.Error = err.Error()
+            return result
+        }
+    }
+
+    for _, details := range result.Workloads {
+        s.finish(ctx, details, cluster.Name)
+    }
+    return result
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "os"
+    "time"
+
+    "k8s-workload-analyzer/pkg/operator"
+)
+
+var operatorCommand = &command{
+    name:    "operator",
+    args:    "[-namespace a,b] [-selector key=value] [flags]",
+    summary: "Run as a controller keeping WorkloadAnalysis resources up to date.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        fs.Lookup("namespace").Usage = "Comma-separated namespaces to watch (defaults to all namespaces)"
+        fs.Lookup("timeout").Usage = "Timeout for each analysis (0 disables)"
+        selector := fs.String("selector", "", "Only analyze workloads matching this label selector")
+        interval := fs.Duration("interval", 10*time.Minute, "How often every workload is re-analyzed")
+        workers := fs.Int("workers", 2, "Number of workloads analyzed concurrently")
+
+        return func(ctx context.Context) error {
+            if *interval <= 0 {
+                return usagef("-interval must be positive")
+            }
+
+            // The timeout applies per analysis, not to the controller
+            rt, _, cancel, err := g.load(ctx)
+            if err != nil {
+                return err
+            }
+            defer cancel()
+
+            cluster, k8sClient, metricsClient, _, err := rt.connect("")
+            if err != nil {
+                return err
+            }
+            dynamicClient, err := cluster.DynamicClient()
+            if err != nil {
+                return err
+            }
+
+            controller, err := operator.New(operator.Config{
+                Cluster:    cluster.Name,
+                Namespaces: splitList(g.namespace),
+                Selector:   *selector,
+                Client:     k8sClient,
+                Metrics:    metricsClient,
+                Dynamic:    dynamicClient,
+                Finish:     rt.settings.finish,
+                Interval:   *interval,
+                Timeout:    g.timeout,
+                Workers:    *workers,
+            })
+            if err != nil {
+                return usagef("%v", err)
+            }
+
+            fmt.Fprintf(os.Stderr, "Watching workloads in cluster %s\n", cluster.Name)
+            if err := controller.Run(ctx); err != nil {
+                return err
+            }
+            fmt.Fprintln(os.Stderr, "Operator stopped")
+            return nil
+        }
+    },
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+
+    "k8s-workload-analyzer/pkg/ai/prompts"
+    "k8s-workload-analyzer/pkg/config"
+)
+
+const promptsUsage = "usage: kwa prompts list|show <name> [-config path]"
+
+var promptsCommand = &command{
+    name:    "prompts",
+    args:    "list|show <name> [-config path]",
+    summary: "List the AI prompt templates in effect, or show one, including overrides from ai.prompts_dir.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        configPath := fs.String("config", "", "Path to a config file (skips discovery)")
+
+        return func(ctx context.Context) error {
+            if fs.NArg() == 0 {
+                return usagef(promptsUsage)
+            }
+            action, args := fs.Arg(0), fs.Args()[1:]
+            var name string
+            if action == "show" && len(args) > 0 {
+                name, args = args[0], args[1:]
+            }
+            // Flags may also follow the action
+            if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+                return usagef(promptsUsage)
+            }
+
+            cfg, err := config.Load(*configPath)
+            if err != nil {
+                return err
+            }
+            set, err := prompts.Load(cfg.AI.PromptsDir)
+            if err != nil {
+                return err
+            }
+
+            switch action {
+            case "list":
+                fmt.Printf("# version: %s\n", set.Version)
+                for _, p := range set.List() {
+                    fmt.Printf("%-20s %s\n", p.Name, p.Source)
+                }
+            case "show":
+                if name == "" {
+                    return usagef(promptsUsage)
+                }
+                p, ok := set.Get(name)
+                if !ok {
+                    return usagef("no prompt named %s; see kwa prompts list", name)
+                }
+                fmt.Printf("# %s (%s, version %s)\n", p.Name, p.Source, set.Version)
+                fmt.Print(p.Text)
+            default:
+                return usagef(promptsUsage)
+            }
+            return nil
+        }
+    },
+}
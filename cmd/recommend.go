@@ -0,0 +1,68 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "os"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/ui"
+)
+
+var recommendCommand = &command{
+    name:    "recommend",
+    args:    "[-name <workload>] [flags]",
+    summary: "Recommend right-sized requests from observed usage for one workload or a whole namespace.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        workloadType := fs.String("type", "deployment", "Workload type (deployment, statefulset, daemonset)")
+        workloadName := fs.String("name", "", "Workload name (defaults to every workload in the namespace)")
+        headroom := fs.Float64("headroom", defaultHeadroom, "Percentage added on top of observed usage")
+
+        return func(ctx context.Context) error {
+            if *headroom < 0 {
+                return usagef("-headroom must not be negative")
+            }
+            rt, ctx, cancel, err := g.load(ctx)
+            if err != nil {
+                return err
+            }
+            defer cancel()
+            if !rt.settings.metricsEnabled {
+                return usagef("recommendations need usage data, but metrics.source is none")
+            }
+
+            cluster, k8sClient, metricsClient, namespace, err := rt.connect(g.namespace)
+            if err != nil {
+                return err
+            }
+
+            var workloads []*analyzer.WorkloadDetails
+            if *workloadName != "" {
+                details, err := analyzer.AnalyzeWorkload(ctx, k8sClient, metricsClient, namespace, *workloadType, *workloadName)
+                if err != nil {
+                    return fmt.Errorf("[%s] failed to analyze workload: %w", cluster.Name, err)
+                }
+                workloads = []*analyzer.WorkloadDetails{details}
+            } else {
+                workloads, err = analyzer.AnalyzeNamespace(ctx, k8sClient, metricsClient, namespace)
+                if err != nil {
+                    return fmt.Errorf("[%s] %w", cluster.Name, err)
+                }
+            }
+
+            recommendations := []analyzer.ResourceRecommendation{}
+            for _, details := range workloads {
+                rt.settings.finish(ctx, details, cluster.Name)
+                recommendations = append(recommendations, analyzer.RecommendResources(details, *headroom, rt.settings.pricing)...)
+            }
+
+            if rt.output == "json" {
+                return printJSON(os.Stdout, recommendations)
+            }
+            fmt.Println(ui.RenderRecommendations(cluster.Name, recommendations))
+            return nil
+        }
+    },
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+
+    "k8s-workload-analyzer/pkg/report"
+    "k8s-workload-analyzer/pkg/ui"
+)
+
+var reportCommand = &command{
+    name:    "report",
+    args:    "-input <file.json> [-output format]",
+    summary: "Render a saved JSON result (from -output=json) in another format, without a cluster.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        input := fs.String("input", "-", "JSON result file to render (- for stdin)")
+        output := fs.String("output", "text", "Output format ("+reportFormatList+")")
+
+        return func(ctx context.Context) error {
+            if !contains(reportFormats, *output) {
+                return usagef("unsupported output format %q (want %s)", *output, reportFormatList)
+            }
+            rep, err := report.ReadFile(*input)
+            if err != nil {
+                return err
+            }
+            return renderReport(os.Stdout, rep, *output)
+        }
+    },
+}
+
+// reportFormats are the formats any saved report can be rendered in.
+var reportFormats = []string{"text", "json", "sarif", "junit", "html", "prometheus"}
+
+const reportFormatList = "text, json, sarif, junit, html, prometheus"
+
+// renderReport writes a report in the given format. Text output picks the
+// most specific view: a comparison, a single workload, or a scan table.
+func renderReport(w io.Writer, rep *report.Report, format string) error {
+    switch format {
+    case "json":
+        if err := rep.WriteJSON(w); err != nil {
+            return fmt.Errorf("failed to encode output: %v", err)
+        }
+        return nil
+    case "sarif":
+        if err := rep.WriteSARIF(w, version); err != nil {
+            return fmt.Errorf("failed to encode output: %v", err)
+        }
+        return nil
+    case "junit":
+        if err := rep.WriteJUnit(w); err != nil {
+            return fmt.Errorf("failed to encode output: %v", err)
+        }
+        return nil
+    case "html":
+        if err := rep.WriteHTML(w); err != nil {
+            return fmt.Errorf("failed to render HTML: %v", err)
+        }
+        return nil
+    case "prometheus":
+        if err := rep.WritePrometheus(w); err != nil {
+            return fmt.Errorf("failed to encode output: %v", err)
+        }
+        return nil
+    case "text":
+        if rep.Comparison != nil {
+            fmt.Fprintln(w, ui.RenderComparison(rep.Comparison))
+            return nil
+        }
+        if workloads := rep.Workloads(); len(rep.Clusters) == 1 && len(workloads) == 1 {
+            fmt.Fprintln(w, ui.RenderAnalysis(workloads[0]))
+            return nil
+        }
+        for _, cluster := range rep.Clusters {
+            namespace := ""
+            if len(cluster.Workloads) > 0 {
+                namespace = cluster.Workloads[0].Namespace
+            }
+            fmt.Fprintln(w, ui.RenderScan(cluster.Cluster, namespace, cluster.Workloads))
+        }
+        return nil
+    }
+    return usagef("unsupported output format %q", format)
+}
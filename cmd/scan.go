@@ -0,0 +1,111 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "os"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/manifest"
+    "k8s-workload-analyzer/pkg/report"
+)
+
+var scanCommand = &command{
+    name:    "scan",
+    args:    "[-contexts a,b,c | -f path] [flags]",
+    summary: "Analyze every workload in a namespace, compare workloads across clusters with -contexts, or check manifests with -f.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        fs.Lookup("output").Usage = "Output format (" + reportFormatList + ")"
+        contexts := fs.String("contexts", "", "Comma-separated kubeconfig contexts to compare")
+        workloadType := fs.String("type", "deployment", "Workload type when comparing a single workload with -name")
+        workloadName := fs.String("name", "", "Compare only this workload across -contexts")
+        var files []string
+        fs.Func("f", "Manifest file or directory to check without a cluster (repeatable)", func(path string) error {
+            files = append(files, path)
+            return nil
+        })
+        gateOpts := addGateFlags(fs)
+
+        return func(ctx context.Context) error {
+            if *workloadName != "" && *contexts == "" {
+                return usagef("-name requires -contexts; use analyze for a single workload")
+            }
+            if len(files) > 0 && *contexts != "" {
+                return usagef("-f and -contexts cannot be combined")
+            }
+            if err := gateOpts.validate(); err != nil {
+                return err
+            }
+            rt, ctx, cancel, err := g.load(ctx, reportFormats...)
+            if err != nil {
+                return err
+            }
+            defer cancel()
+
+            var rep *report.Report
+            if len(files) > 0 {
+                rep, err = scanManifests(ctx, rt, files, g.namespace)
+            } else {
+                rep, err = scan(ctx, rt, splitList(*contexts), g.namespace, *workloadType, *workloadName)
+                if err == nil {
+                    rt.record(ctx, rep.Workloads())
+                }
+            }
+            if err != nil {
+                return err
+            }
+            if err := renderReport(os.Stdout, rep, rt.output); err != nil {
+                return err
+            }
+            return gateOpts.check(rep.Workloads())
+        }
+    },
+}
+
+// scan analyzes a namespace in the current cluster, or compares it (or one
+// workload) across contexts.
+func scan(ctx context.Context, rt *runtime, contexts []string, namespace, workloadType, name string) (*report.Report, error) {
+    if len(contexts) > 0 {
+        results := analyzeClusters(ctx, rt, contexts, namespace, workloadType, name)
+        if ctx.Err() != nil {
+            return nil, ctx.Err()
+        }
+        rep := rt.settings.newReport(results...)
+        rep.Comparison = analyzer.Compare(results)
+        return rep, nil
+    }
+
+    cluster, k8sClient, metricsClient, namespace, err := rt.connect(namespace)
+    if err != nil {
+        return nil, err
+    }
+    workloads, err := analyzer.AnalyzeNamespace(ctx, k8sClient, metricsClient, namespace)
+    if err != nil {
+        return nil, fmt.Errorf("[%s] %w", cluster.Name, err)
+    }
+    for _, details := range workloads {
+        rt.settings.finish(ctx, details, cluster.Name)
+    }
+    return rt.settings.newReport(analyzer.ClusterResult{Cluster: cluster.Name, Workloads: workloads}), nil
+}
+
+// offlineCluster names the result of a manifest scan, which has no cluster.
+const offlineCluster = "offline"
+
+// scanManifests checks the workloads in manifest files. Without a cluster
+// there is no usage, so only configuration rules can produce findings.
+func scanManifests(ctx context.Context, rt *runtime, files []string, namespace string) (*report.Report, error) {
+    if namespace == "" {
+        namespace = "default"
+    }
+    workloads, err := manifest.Load(files, namespace)
+    if err != nil {
+        return nil, err
+    }
+    for _, details := range workloads {
+        rt.settings.finish(ctx, details, offlineCluster)
+    }
+    return rt.settings.newReport(analyzer.ClusterResult{Cluster: offlineCluster, Workloads: workloads}), nil
+}
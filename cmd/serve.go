@@ -0,0 +1,73 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "os"
+    "time"
+
+    "k8s-workload-analyzer/pkg/ai"
+    "k8s-workload-analyzer/pkg/server"
+)
+
+var serveCommand = &command{
+    name:    "serve",
+    args:    "[-addr :8080] [flags]",
+    summary: "Serve the analyzer over HTTP for dashboards and developer portals.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        fs.Lookup("timeout").Usage = "Timeout for each request (0 disables)"
+        fs.Lookup("output").Usage = "Ignored; responses are always JSON"
+        addr := fs.String("addr", ":8080", "Address to listen on")
+        shutdownTimeout := fs.Duration("shutdown-timeout", 15*time.Second, "How long to wait for in-flight requests on shutdown")
+        refresh := fs.Duration("refresh", 0, "Rescan -namespace on this interval to keep /metrics current (0 disables)")
+        allowAI := fs.Bool("allow-ai", false, "Accept \"ai\": true requests, which spend API credits on behalf of any caller")
+        apiKey := fs.String("api-key", "", "GPT API key for -allow-ai (defaults to ai.api_key or $OPENAI_API_KEY)")
+
+        return func(ctx context.Context) error {
+            // The timeout applies per request, not to the server. Responses are
+            // always JSON, so -output and output.format don't apply.
+            g.fs.Set("output", "json")
+            rt, _, cancel, err := g.load(ctx, "json")
+            if err != nil {
+                return err
+            }
+            defer cancel()
+
+            cluster, k8sClient, metricsClient, namespace, err := rt.connect(g.namespace)
+            if err != nil {
+                return err
+            }
+
+            cfg := server.Config{
+                Cluster:   cluster.Name,
+                Namespace: namespace,
+                Client:    k8sClient,
+                Metrics:   metricsClient,
+                Finish:    rt.settings.finish,
+                NewReport: rt.settings.newReport,
+                Timeout:   g.timeout,
+                Refresh:   *refresh,
+            }
+            if *allowAI {
+                aiCfg := rt.aiConfig(*apiKey)
+                if aiCfg.APIKey == "" {
+                    return usagef("-allow-ai needs an API key (use -api-key, ai.api_key or $OPENAI_API_KEY)")
+                }
+                provider, err := ai.NewProvider(aiCfg)
+                if err != nil {
+                    return fmt.Errorf("failed to create AI client: %v", err)
+                }
+                cfg.Enrich = aiEnricher(provider, k8sClient)
+            }
+
+            fmt.Fprintf(os.Stderr, "Serving cluster %s on %s\n", cluster.Name, *addr)
+            if err := server.New(cfg).ListenAndServe(ctx, *addr, *shutdownTimeout); err != nil {
+                return err
+            }
+            fmt.Fprintln(os.Stderr, "Server stopped")
+            return nil
+        }
+    },
+}
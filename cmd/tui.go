@@ -0,0 +1,128 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "time"
+
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s-workload-analyzer/pkg/ai"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/ui"
+)
+
+// exportExtensions maps export formats to file extensions.
+var exportExtensions = map[string]string{
+    "text":       ".txt",
+    "json":       ".json",
+    "sarif":      ".sarif",
+    "junit":      ".xml",
+    "html":       ".html",
+    "prometheus": ".prom",
+}
+
+var tuiCommand = &command{
+    name:    "tui",
+    args:    "[-namespace <ns>] [flags]",
+    summary: "Browse namespaces and workloads interactively.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        fs.Lookup("namespace").Usage = "Namespace to open on start (defaults to the namespace list)"
+        fs.Lookup("timeout").Usage = "Timeout for each scan or AI analysis (0 disables)"
+        apiKey := fs.String("api-key", "", "GPT API key for on-demand AI analysis (defaults to ai.api_key or $OPENAI_API_KEY)")
+        exportFormat := fs.String("export-format", "json", "Format of exported workloads ("+reportFormatList+")")
+        exportDir := fs.String("export-dir", ".", "Directory exported workloads are written to")
+
+        return func(ctx context.Context) error {
+            if _, ok := exportExtensions[*exportFormat]; !ok {
+                return usagef("unsupported -export-format %q (want %s)", *exportFormat, reportFormatList)
+            }
+
+            // The timeout applies per action, not to the session
+            rt, _, cancel, err := g.load(ctx)
+            if err != nil {
+                return err
+            }
+            defer cancel()
+
+            cluster, k8sClient, metricsClient, _, err := rt.connect("")
+            if err != nil {
+                return err
+            }
+            withTimeout := func(ctx context.Context) (context.Context, context.CancelFunc) {
+                if g.timeout > 0 {
+                    return context.WithTimeout(ctx, g.timeout)
+                }
+                return ctx, func() {}
+            }
+
+            cfg := ui.BrowserConfig{
+                Cluster:   cluster.Name,
+                Namespace: g.namespace,
+                Namespaces: func(ctx context.Context) ([]string, error) {
+                    ctx, cancel := withTimeout(ctx)
+                    defer cancel()
+                    list, err := k8sClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+                    if err != nil {
+                        return nil, fmt.Errorf("failed to list namespaces: %v", err)
+                    }
+                    var namespaces []string
+                    for _, ns := range list.Items {
+                        namespaces = append(namespaces, ns.Name)
+                    }
+                    sort.Strings(namespaces)
+                    return namespaces, nil
+                },
+                Scan: func(ctx context.Context, namespace string) ([]*analyzer.WorkloadDetails, error) {
+                    ctx, cancel := withTimeout(ctx)
+                    defer cancel()
+                    workloads, err := analyzer.AnalyzeNamespace(ctx, k8sClient, metricsClient, namespace)
+                    if err != nil {
+                        return nil, err
+                    }
+                    for _, details := range workloads {
+                        rt.settings.finish(ctx, details, cluster.Name)
+                    }
+                    rt.record(ctx, workloads)
+                    return workloads, nil
+                },
+                Export: func(namespace string, workloads []*analyzer.WorkloadDetails) (string, error) {
+                    name := fmt.Sprintf("kwa-%s-%s%s", namespace, time.Now().Format("20060102-150405"), exportExtensions[*exportFormat])
+                    path := filepath.Join(*exportDir, name)
+                    f, err := os.Create(path)
+                    if err != nil {
+                        return "", fmt.Errorf("failed to export: %v", err)
+                    }
+                    defer f.Close()
+                    rep := rt.settings.newReport(analyzer.ClusterResult{Cluster: cluster.Name, Workloads: workloads})
+                    if err := renderReport(f, rep, *exportFormat); err != nil {
+                        return "", err
+                    }
+                    return path, nil
+                },
+            }
+            if aiCfg := rt.aiConfig(*apiKey); aiCfg.APIKey != "" {
+                provider, err := ai.NewProvider(aiCfg)
+                if err != nil {
+                    return fmt.Errorf("failed to create AI client: %v", err)
+                }
+                enrich := aiEnricher(provider, k8sClient)
+                cfg.Enrich = func(ctx context.Context, details *analyzer.WorkloadDetails) error {
+                    ctx, cancel := withTimeout(ctx)
+                    defer cancel()
+                    return enrich(ctx, details)
+                }
+            }
+
+            if err := ui.Browse(ctx, cfg); err != nil && !errors.Is(err, context.Canceled) {
+                return err
+            }
+            return nil
+        }
+    },
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    goruntime "runtime"
+    "runtime/debug"
+
+    "k8s-workload-analyzer/pkg/report"
+)
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+var versionCommand = &command{
+    name:    "version",
+    args:    "",
+    summary: "Print the kwa version.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        return func(ctx context.Context) error {
+            revision := "unknown"
+            if info, ok := debug.ReadBuildInfo(); ok {
+                for _, setting := range info.Settings {
+                    if setting.Key == "vcs.revision" {
+                        revision = setting.Value
+                    }
+                }
+            }
+            fmt.Printf("kwa %s (revision %s, %s, report schema %s)\n", version, revision, goruntime.Version(), report.SchemaVersion)
+            return nil
+        }
+    },
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "os"
+    "time"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/gate"
+    "k8s-workload-analyzer/pkg/store"
+    "k8s-workload-analyzer/pkg/webhook"
+)
+
+var webhookCommand = &command{
+    name:    "webhook",
+    args:    "-tls-cert <file> -tls-key <file> [-deny high] [-mutate] [flags]",
+    summary: "Serve admission webhooks enforcing the rules on Deployments, StatefulSets and DaemonSets.",
+    flags: func(fs *flag.FlagSet) func(ctx context.Context) error {
+        g := addGlobalFlags(fs)
+        fs.Lookup("timeout").Usage = "Timeout for each review (0 disables)"
+        addr := fs.String("addr", ":8443", "Address to listen on")
+        certFile := fs.String("tls-cert", "", "TLS certificate file")
+        keyFile := fs.String("tls-key", "", "TLS key file")
+        deny := fs.String("deny", "", "Reject workloads with a finding at or above this severity (info, low, medium, high, critical); others only warn")
+        baseline := fs.String("baseline", "", "Baseline file of accepted findings that are never rejected")
+        mutate := fs.Bool("mutate", false, "Fill in missing requests on /mutate from the workload's stored recommendations")
+        cluster := fs.String("cluster", "", "Cluster name of stored history used by -mutate (defaults to the current context)")
+        shutdownTimeout := fs.Duration("shutdown-timeout", 15*time.Second, "How long to wait for in-flight reviews on shutdown")
+
+        return func(ctx context.Context) error {
+            if *certFile == "" || *keyFile == "" {
+                return usagef("-tls-cert and -tls-key are required")
+            }
+            var threshold analyzer.Severity
+            if *deny != "" {
+                var err error
+                if threshold, err = analyzer.ParseSeverity(*deny); err != nil {
+                    return usagef("invalid -deny: %v", err)
+                }
+            }
+
+            // The timeout applies per review, not to the server
+            rt, _, cancel, err := g.load(ctx)
+            if err != nil {
+                return err
+            }
+            defer cancel()
+
+            b, err := gate.LoadBaseline(*baseline)
+            if err != nil {
+                return err
+            }
+            if *cluster == "" {
+                c, err := rt.loadCluster(rt.opts)
+                if err != nil {
+                    return err
+                }
+                *cluster = c.Name
+            }
+
+            cfg := webhook.Config{
+                Cluster:  *cluster,
+                Finish:   rt.settings.finish,
+                Deny:     threshold,
+                Baseline: b,
+                Timeout:  g.timeout,
+            }
+            if *mutate {
+                cfg.Recommendations = latestRecommendations(rt.historyPath())
+            }
+
+            fmt.Fprintf(os.Stderr, "Serving admission webhooks on %s\n", *addr)
+            if err := webhook.New(cfg).ListenAndServeTLS(ctx, *addr, *certFile, *keyFile, *shutdownTimeout); err != nil {
+                return err
+            }
+            fmt.Fprintln(os.Stderr, "Webhook stopped")
+            return nil
+        }
+    },
+}
+
+// latestRecommendations reads the recommendations of a workload's most
+// recent stored analysis. The store is opened per review so the CLI can
+// keep recording history in between.
+func latestRecommendations(path string) func(key string) ([]analyzer.ResourceRecommendation, error) {
+    return func(key string) ([]analyzer.ResourceRecommendation, error) {
+        s, err := store.Open(path)
+        if err != nil {
+            return nil, err
+        }
+        defer s.Close()
+
+        records, err := s.History(key)
+        if err != nil || len(records) == 0 {
+            return nil, err
+        }
+        return records[len(records)-1].Recommendations, nil
+    }
+}
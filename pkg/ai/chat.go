@@ -0,0 +1,121 @@
+package ai
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+)
+
+// maxToolRounds bounds how many times one question can go back and forth
+// between the model and local tools.
+const maxToolRounds = 5
+
+// maxToolOutput bounds what a tool sends back to the model, in bytes.
+const maxToolOutput = 8000
+
+// Message is one turn of a conversation. Assistant messages may ask for
+// tool calls instead of answering; each is answered by a "tool" message
+// carrying the call's ID.
+type Message struct {
+    Role       string     `json:"role"` // system, user, assistant or tool
+    Content    string     `json:"content"`
+    ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+    ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+type ToolCall struct {
+    ID        string `json:"id"`
+    Name      string `json:"name"`
+    Arguments string `json:"arguments"` // JSON object
+}
+
+// Tool is a local function the model may call. Parameters is the JSON
+// schema of its arguments.
+type Tool struct {
+    Name        string
+    Description string
+    Parameters  map[string]interface{}
+    Run         func(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// Conversation is a multi-turn chat that keeps its history and runs the
+// tool calls the model asks for.
+type Conversation struct {
+    provider Provider
+    tools    []Tool
+    messages []Message
+
+    // OnToolCall, when set, is told about each tool call before it runs.
+    OnToolCall func(call ToolCall)
+}
+
+func NewConversation(provider Provider, system string, tools []Tool) *Conversation {
+    return &Conversation{
+        provider: provider,
+        tools:    tools,
+        messages: []Message{{Role: "system", Content: system}},
+    }
+}
+
+// Ask sends a question and returns the model's answer, running tools in
+// between as needed.
+func (c *Conversation) Ask(ctx context.Context, question string) (string, error) {
+    start := len(c.messages)
+    c.messages = append(c.messages, Message{Role: "user", Content: question})
+
+    for round := 0; round <= maxToolRounds; round++ {
+        tools := c.tools
+        if round == maxToolRounds {
+            // Out of rounds; make the model answer with what it has
+            tools = nil
+        }
+        reply, err := c.provider.Chat(ctx, c.messages, tools)
+        if err != nil {
+            // Drop the unanswered turn so the question can be asked again
+            c.messages = c.messages[:start]
+            return "", err
+        }
+        c.messages = append(c.messages, *reply)
+        if len(reply.ToolCalls) == 0 {
+            return reply.Content, nil
+        }
+
+        for _, call := range reply.ToolCalls {
+            if c.OnToolCall != nil {
+                c.OnToolCall(call)
+            }
+            c.messages = append(c.messages, Message{
+                Role:       "tool",
+                ToolCallID: call.ID,
+                Content:    c.runTool(ctx, call),
+            })
+        }
+    }
+    c.messages = c.messages[:start]
+    return "", fmt.Errorf("no answer after %d rounds of tool calls", maxToolRounds)
+}
+
+// runTool returns the tool's redacted output, or its error for the model
+// to see.
+func (c *Conversation) runTool(ctx context.Context, call ToolCall) string {
+    for _, tool := range c.tools {
+        if tool.Name != call.Name {
+            continue
+        }
+        args := json.RawMessage(call.Arguments)
+        if len(args) == 0 {
+            args = json.RawMessage("{}")
+        }
+        out, err := tool.Run(ctx, args)
+        if err != nil {
+            return fmt.Sprintf("error: %v", err)
+        }
+        // Logs and events may carry credentials, like manifests do
+        out = Redact(out)
+        if len(out) > maxToolOutput {
+            out = out[:maxToolOutput] + "\n[truncated]"
+        }
+        return out
+    }
+    return fmt.Sprintf("error: unknown tool %q", call.Name)
+}
@@ -0,0 +1,176 @@
+package ai
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "strings"
+    "testing"
+)
+
+// scriptedProvider answers Chat with its replies in order and keeps what
+// it was sent.
+type scriptedProvider struct {
+    replies []*Message
+    err     error
+    sent    [][]Message
+    tools   [][]Tool
+}
+
+func (p *scriptedProvider) AnalyzeWorkload(ctx context.Context, kind, yaml string, evidence Evidence) (*WorkloadAnalysis, error) {
+    return nil, errors.New("not implemented")
+}
+
+func (p *scriptedProvider) Chat(ctx context.Context, messages []Message, tools []Tool) (*Message, error) {
+    p.sent = append(p.sent, append([]Message{}, messages...))
+    p.tools = append(p.tools, tools)
+    if p.err != nil {
+        return nil, p.err
+    }
+    if len(p.replies) == 0 {
+        return &Message{Role: "assistant", Content: "done"}, nil
+    }
+    reply := p.replies[0]
+    p.replies = p.replies[1:]
+    return reply, nil
+}
+
+func toolCall(id, name, args string) *Message {
+    return &Message{Role: "assistant", ToolCalls: []ToolCall{{ID: id, Name: name, Arguments: args}}}
+}
+
+func TestConversationRunsTools(t *testing.T) {
+    var gotArgs string
+    tools := []Tool{{
+        Name: "get_logs",
+        Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+            gotArgs = string(args)
+            return "connecting with password=hunter2\nAuthorization: Bearer abc.def", nil
+        },
+    }, {
+        Name: "broken",
+        Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+            return "", errors.New("no pods")
+        },
+    }}
+    provider := &scriptedProvider{replies: []*Message{
+        toolCall("1", "get_logs", `{"lines": 5}`),
+        {Role: "assistant", ToolCalls: []ToolCall{{ID: "2", Name: "broken"}, {ID: "3", Name: "missing"}}},
+        {Role: "assistant", Content: "It ran out of memory."},
+    }}
+    conv := NewConversation(provider, "system prompt", tools)
+    var called []string
+    conv.OnToolCall = func(call ToolCall) { called = append(called, call.Name) }
+
+    answer, err := conv.Ask(context.Background(), "why did it restart?")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if answer != "It ran out of memory." {
+        t.Errorf("unexpected answer %q", answer)
+    }
+    if gotArgs != `{"lines": 5}` {
+        t.Errorf("unexpected tool arguments %q", gotArgs)
+    }
+    if strings.Join(called, ",") != "get_logs,broken,missing" {
+        t.Errorf("unexpected tool calls %v", called)
+    }
+
+    // system, user, call, result, calls, 2 results
+    last := provider.sent[len(provider.sent)-1]
+    if len(last) != 7 || last[0].Content != "system prompt" || last[1].Content != "why did it restart?" {
+        t.Fatalf("unexpected history: %+v", last)
+    }
+    logs := last[3]
+    if logs.Role != "tool" || logs.ToolCallID != "1" {
+        t.Errorf("expected the tool result to answer call 1, got %+v", logs)
+    }
+    if strings.Contains(logs.Content, "hunter2") || strings.Contains(logs.Content, "abc.def") {
+        t.Errorf("expected tool output to be redacted, got %q", logs.Content)
+    }
+    if last[5].Content != "error: no pods" || last[6].Content != `error: unknown tool "missing"` {
+        t.Errorf("expected tool errors to go back to the model, got %q and %q", last[5].Content, last[6].Content)
+    }
+
+    // The next question carries the whole conversation
+    if _, err := conv.Ask(context.Background(), "and now?"); err != nil {
+        t.Fatal(err)
+    }
+    if n := len(provider.sent[len(provider.sent)-1]); n != 9 {
+        t.Errorf("expected the follow-up to carry 9 messages, got %d", n)
+    }
+}
+
+func TestConversationTruncatesToolOutput(t *testing.T) {
+    tools := []Tool{{
+        Name: "get_logs",
+        Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+            return strings.Repeat("x", maxToolOutput+100), nil
+        },
+    }}
+    provider := &scriptedProvider{replies: []*Message{toolCall("1", "get_logs", "")}}
+    conv := NewConversation(provider, "system", tools)
+    if _, err := conv.Ask(context.Background(), "logs?"); err != nil {
+        t.Fatal(err)
+    }
+    out := provider.sent[1][3].Content
+    if len(out) != maxToolOutput+len("\n[truncated]") || !strings.HasSuffix(out, "[truncated]") {
+        t.Errorf("expected output truncated to %d bytes, got %d", maxToolOutput, len(out))
+    }
+}
+
+func TestConversationToolRounds(t *testing.T) {
+    provider := &scriptedProvider{}
+    for i := 0; i <= maxToolRounds; i++ {
+        provider.replies = append(provider.replies, toolCall("1", "get_logs", "{}"))
+    }
+    tools := []Tool{{Name: "get_logs", Run: func(ctx context.Context, args json.RawMessage) (string, error) { return "", nil }}}
+    conv := NewConversation(provider, "system", tools)
+
+    if _, err := conv.Ask(context.Background(), "loop"); err == nil {
+        t.Fatal("expected a model that never answers to be an error")
+    }
+    if n := len(provider.tools); n != maxToolRounds+1 || provider.tools[n-1] != nil {
+        t.Errorf("expected the last of %d rounds to offer no tools, got %d rounds", maxToolRounds+1, n)
+    }
+    if len(conv.messages) != 1 {
+        t.Errorf("expected the unanswered question to be dropped, got %d messages", len(conv.messages))
+    }
+}
+
+func TestConversationProviderError(t *testing.T) {
+    provider := &scriptedProvider{err: errors.New("rate limited")}
+    conv := NewConversation(provider, "system", nil)
+
+    if _, err := conv.Ask(context.Background(), "hello"); err == nil || err.Error() != "rate limited" {
+        t.Fatalf("expected the provider error, got %v", err)
+    }
+    provider.err = nil
+    if _, err := conv.Ask(context.Background(), "hello again"); err != nil {
+        t.Fatal(err)
+    }
+    if sent := provider.sent[1]; len(sent) != 2 || sent[1].Content != "hello again" {
+        t.Errorf("expected the failed question to be dropped from history, got %+v", sent)
+    }
+}
+
+func TestRedact(t *testing.T) {
+    tests := []struct {
+        in   string
+        want string
+    }{
+        {"password=hunter2 user=bob", "password=REDACTED user=bob"},
+        {`{"api_key": "sk-123", "model": "gpt"}`, `{"api_key": "REDACTED", "model": "gpt"}`},
+        {"DB_PASSWORD: s3cret", "DB_PASSWORD: REDACTED"},
+        {"Authorization: Bearer abc.def-ghi", "Authorization: Bearer REDACTED"},
+        {"dial postgres://app:pa55@db:5432/app", "dial postgres://REDACTED@db:5432/app"},
+        {"token eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl", "token REDACTED"},
+        {"GET /healthz 200 in 3ms", "GET /healthz 200 in 3ms"},
+        {"image: nginx:1.27", "image: nginx:1.27"},
+    }
+    for _, tt := range tests {
+        if got := Redact(tt.in); got != tt.want {
+            t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,126 @@
+package ai
+
+import (
+    "encoding/json"
+    "math"
+    "strconv"
+    "strings"
+
+    "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Evidence is what an analysis may cite: rule findings by ID, and metric
+// values by name.
+type Evidence struct {
+    Findings map[string]string `json:"findings"`
+    Metrics  map[string]string `json:"metrics"`
+}
+
+// Claim is an opportunity or caution with the evidence it rests on.
+// Unverified is set by Ground when the claim cites nothing that checks out.
+type Claim struct {
+    Text       string            `json:"text"`
+    Findings   []string          `json:"findings,omitempty"`
+    Metrics    map[string]string `json:"metrics,omitempty"`
+    Unverified bool              `json:"-"`
+}
+
+// UnmarshalJSON also accepts a bare string, which models sometimes return
+// despite the prompt; such a claim cites nothing.
+func (c *Claim) UnmarshalJSON(data []byte) error {
+    var text string
+    if err := json.Unmarshal(data, &text); err == nil {
+        *c = Claim{Text: text}
+        return nil
+    }
+    type plain Claim
+    return json.Unmarshal(data, (*plain)(c))
+}
+
+// Ground checks every claim's citations against the evidence. Citations of
+// unknown findings or metrics, or of values that don't match, are removed.
+// Claims left with no valid citation are marked unverified, and claims whose
+// citations were all invalid are dropped as hallucinated. It returns the
+// number of claims dropped.
+func (a *WorkloadAnalysis) Ground(ev Evidence) int {
+    var dropped int
+    a.Opportunities, dropped = groundClaims(a.Opportunities, ev)
+    cautions, n := groundClaims(a.Cautions, ev)
+    a.Cautions = cautions
+    return dropped + n
+}
+
+func groundClaims(claims []Claim, ev Evidence) ([]Claim, int) {
+    var kept []Claim
+    dropped := 0
+    for _, c := range claims {
+        cited := len(c.Findings) + len(c.Metrics)
+
+        var findings []string
+        for _, id := range c.Findings {
+            if _, ok := ev.Findings[id]; ok {
+                findings = append(findings, id)
+            }
+        }
+        metrics := map[string]string{}
+        for name, value := range c.Metrics {
+            if actual, ok := ev.Metrics[name]; ok && sameValue(value, actual) {
+                metrics[name] = actual
+            }
+        }
+        valid := len(findings) + len(metrics)
+
+        if cited > 0 && valid == 0 {
+            dropped++
+            continue
+        }
+        c.Findings = findings
+        c.Metrics = metrics
+        if len(metrics) == 0 {
+            c.Metrics = nil
+        }
+        c.Unverified = valid == 0 || valid < cited
+        kept = append(kept, c)
+    }
+    return kept, dropped
+}
+
+// sameValue reports whether a cited value matches the actual one, either
+// exactly or as numbers within 1% in the same unit, so "12%" matches "12.0%"
+// and "1Gi" matches "1024Mi", but "512Mi" doesn't match "512Gi".
+func sameValue(cited, actual string) bool {
+    cited, actual = strings.TrimSpace(cited), strings.TrimSpace(actual)
+    if strings.EqualFold(cited, actual) {
+        return true
+    }
+    // Kubernetes quantities, e.g. "250m", "0.25" or "1.07Gi"
+    if a, err := resource.ParseQuantity(cited); err == nil {
+        if b, err := resource.ParseQuantity(actual); err == nil {
+            return within(a.AsApproximateFloat64(), b.AsApproximateFloat64())
+        }
+    }
+    a, unitA, ok := splitNumber(cited)
+    if !ok {
+        return false
+    }
+    b, unitB, ok := splitNumber(actual)
+    if !ok || !strings.EqualFold(unitA, unitB) {
+        return false
+    }
+    return within(a, b)
+}
+
+func within(a, b float64) bool {
+    return math.Abs(a-b) <= 0.01*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// splitNumber splits a value like "12.5%" into its leading number and the
+// unit that follows it.
+func splitNumber(s string) (float64, string, bool) {
+    end := 0
+    for end < len(s) && strings.ContainsRune("+-.0123456789", rune(s[end])) {
+        end++
+    }
+    f, err := strconv.ParseFloat(s[:end], 64)
+    return f, strings.TrimSpace(s[end:]), err == nil
+}
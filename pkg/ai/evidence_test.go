@@ -0,0 +1,79 @@
+package ai
+
+import (
+    "encoding/json"
+    "testing"
+)
+
+func TestGround(t *testing.T) {
+    ev := Evidence{
+        Findings: map[string]string{"KWA001/app": "high: no memory limit"},
+        Metrics:  map[string]string{"cpu_utilization": "12.0%", "app.memory_usage_bytes": "104857600"},
+    }
+    var analysis WorkloadAnalysis
+    err := json.Unmarshal([]byte(`{
+        "opportunities": [
+            {"text": "cut CPU requests", "metrics": {"cpu_utilization": "12%"}},
+            {"text": "made up", "findings": ["KWA999"], "metrics": {"disk_usage": "90%"}},
+            "plain string"
+        ],
+        "cautions": [
+            {"text": "set a memory limit", "findings": ["KWA001/app", "KWA002"]},
+            {"text": "wrong number", "metrics": {"app.memory_usage_bytes": "209715200"}}
+        ]
+    }`), &analysis)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if dropped := analysis.Ground(ev); dropped != 2 {
+        t.Errorf("expected 2 dropped claims, got %d", dropped)
+    }
+
+    if len(analysis.Opportunities) != 2 {
+        t.Fatalf("unexpected opportunities: %+v", analysis.Opportunities)
+    }
+    if c := analysis.Opportunities[0]; c.Unverified || c.Metrics["cpu_utilization"] != "12.0%" {
+        t.Errorf("expected a verified claim citing the actual value, got %+v", c)
+    }
+    if c := analysis.Opportunities[1]; c.Text != "plain string" || !c.Unverified {
+        t.Errorf("expected an unverified claim without citations, got %+v", c)
+    }
+
+    if len(analysis.Cautions) != 1 {
+        t.Fatalf("unexpected cautions: %+v", analysis.Cautions)
+    }
+    if c := analysis.Cautions[0]; !c.Unverified || len(c.Findings) != 1 || c.Findings[0] != "KWA001/app" {
+        t.Errorf("expected the unknown finding removed and the claim marked, got %+v", c)
+    }
+}
+
+func TestSameValue(t *testing.T) {
+    tests := []struct {
+        cited  string
+        actual string
+        want   bool
+    }{
+        {"12%", "12.0%", true},
+        {"12.1%", "12.0%", true},
+        {"15%", "12.0%", false},
+        {"High (90.0%)", "high (90.0%)", true},
+        {"512Mi", "512Mi", true},
+        {"512Gi", "512Mi", false},
+        {"1Gi", "1024Mi", true},
+        {"1.07Gi", "1100Mi", true},
+        {"250m", "250", false},
+        {"0.25", "250m", true},
+        {"250m", "255m", false},
+        {"104857600", "100Mi", true},
+        {"12", "12%", false},
+        {"12 pods", "12 nodes", false},
+        {"$12.30", "$12.30", true},
+        {"none", "12", false},
+    }
+    for _, tt := range tests {
+        if got := sameValue(tt.cited, tt.actual); got != tt.want {
+            t.Errorf("sameValue(%q, %q) = %v, want %v", tt.cited, tt.actual, got, tt.want)
+        }
+    }
+}
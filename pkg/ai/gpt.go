@@ -2,20 +2,83 @@ package ai
 
 import (
     "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
     "encoding/json"
+    "errors"
     "fmt"
     "io"
     "net/http"
     "strings"
+    "sync"
+    "time"
+    "go.opentelemetry.io/otel/trace"
     "k8s-workload-analyzer/pkg/ai/prompts"
+    "k8s-workload-analyzer/pkg/retry"
+    "k8s-workload-analyzer/pkg/tracing"
 )
 
+// requestTimeout bounds a single HTTP round trip; the caller's context bounds
+// the whole call including retries.
+const requestTimeout = 60 * time.Second
+
+const (
+    defaultModel   = "gpt-3.5-turbo"
+    defaultBaseURL = "https://api.openai.com/v1"
+)
+
+// maxCached bounds the analyses a client remembers.
+const maxCached = 128
+
 type GPTClient struct {
-    apiKey string
+    apiKey      string
+    model       string
+    temperature float64
+    baseURL     string
+    httpClient  *http.Client
+    retry       retry.Policy
+    prompts     *prompts.Set
+    focus       string
+
+    // cache holds analyses by prompt version, model, focus, manifest and
+    // evidence, so long-running modes don't pay twice for the same question.
+    mu    sync.Mutex
+    cache map[string]*WorkloadAnalysis
 }
 
 func NewGPTClient(apiKey string) *GPTClient {
-    return &GPTClient{apiKey: apiKey}
+    return newGPTClient(Config{APIKey: apiKey, Temperature: 0.1}, prompts.Must(prompts.Load("")))
+}
+
+func newGPTClient(cfg Config, set *prompts.Set) *GPTClient {
+    client := &GPTClient{
+        apiKey:      cfg.APIKey,
+        model:       cfg.Model,
+        temperature: cfg.Temperature,
+        baseURL:     strings.TrimSuffix(cfg.BaseURL, "/"),
+        httpClient:  &http.Client{Timeout: cfg.Timeout, Transport: cfg.Transport},
+        retry:       retry.DefaultPolicy,
+        prompts:     set,
+        focus:       cfg.Focus,
+        cache:       make(map[string]*WorkloadAnalysis),
+    }
+    if client.focus == "" {
+        client.focus = prompts.DefaultFocus
+    }
+    if client.model == "" {
+        client.model = defaultModel
+    }
+    if client.baseURL == "" {
+        client.baseURL = defaultBaseURL
+    }
+    if cfg.Timeout <= 0 {
+        client.httpClient.Timeout = requestTimeout
+    }
+    if cfg.MaxRetries > 0 {
+        client.retry.MaxAttempts = cfg.MaxRetries
+    }
+    return client
 }
 
 func summarizeYAML(yaml string) string {
@@ -52,26 +115,75 @@ func summarizeYAML(yaml string) string {
         }
     }
     
-    return strings.Join(summary, "\n")
+    return Redact(strings.Join(summary, "\n"))
 }
 
-func (c *GPTClient) AnalyzeWorkload(yaml string) (*WorkloadAnalysis, error) {
+// AnalyzeWorkload is traced as an llm.analyze span carrying the model, the
+// prompt and the tokens spent, also on invalid responses.
+func (c *GPTClient) AnalyzeWorkload(ctx context.Context, kind, yaml string, evidence Evidence) (analysis *WorkloadAnalysis, err error) {
+    ctx, span := tracing.Start(ctx, "llm.analyze",
+        tracing.Kind.String(kind),
+        tracing.Model.String(c.model),
+        tracing.PromptVersion.String(c.prompts.Version),
+        tracing.Focus.String(c.focus))
+    defer func() {
+        var usage Usage
+        var invalid *InvalidResponseError
+        switch {
+        case analysis != nil:
+            usage = analysis.Usage
+        case errors.As(err, &invalid):
+            usage = invalid.Usage
+        }
+        span.SetAttributes(tracing.PromptTokens.Int(usage.PromptTokens), tracing.CompletionTokens.Int(usage.CompletionTokens))
+        tracing.End(span, err)
+    }()
+    return c.analyzeWorkload(ctx, kind, yaml, evidence)
+}
+
+func (c *GPTClient) analyzeWorkload(ctx context.Context, kind, yaml string, evidence Evidence) (*WorkloadAnalysis, error) {
     // Summarize YAML before sending to GPT
     summarizedYAML := summarizeYAML(yaml)
+    evidenceJSON, err := json.MarshalIndent(evidence, "", "  ")
+    if err != nil {
+        return nil, fmt.Errorf("failed to marshal evidence: %v", err)
+    }
+
+    key := c.cacheKey(kind, summarizedYAML, evidenceJSON)
+    c.mu.Lock()
+    cached, ok := c.cache[key]
+    c.mu.Unlock()
+    trace.SpanFromContext(ctx).SetAttributes(tracing.CacheHit.Bool(ok))
+    if ok {
+        // A copy, so callers grounding or editing it leave the cache intact.
+        // Nothing was spent on it this time.
+        analysis := *cached
+        analysis.Usage = Usage{}
+        return &analysis, nil
+    }
+
+    system, err := c.prompts.Render(prompts.System, nil)
+    if err != nil {
+        return nil, err
+    }
+    prompt, err := c.prompts.RenderAnalysis(kind, c.focus, string(evidenceJSON), summarizedYAML)
+    if err != nil {
+        return nil, err
+    }
     
     payload := map[string]interface{}{
-        "model": "gpt-3.5-turbo",
+        "model": c.model,
         "messages": []map[string]string{
             {
                 "role":    "system",
-                "content": "You are a Kubernetes container expert. Focus on analyzing container configuration, resources, and best practices.",
+                "content": system,
             },
             {
                 "role":    "user",
-                "content": fmt.Sprintf(prompts.WorkloadAnalysisTemplate, summarizedYAML),
+                "content": prompt,
             },
         },
-        "temperature": 0.1,
+        "temperature": c.temperature,
         "response_format": map[string]string{
             "type": "json_object",
         },
@@ -82,23 +194,14 @@ func (c *GPTClient) AnalyzeWorkload(yaml string) (*WorkloadAnalysis, error) {
         return nil, fmt.Errorf("failed to marshal request: %v", err)
     }
 
-    req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+    var body []byte
+    err = retry.Do(ctx, c.retry, func(ctx context.Context) error {
+        var err error
+        body, err = c.post(ctx, jsonData)
+        return err
+    })
     if err != nil {
-        return nil, fmt.Errorf("failed to create request: %v", err)
-    }
-
-    req.Header.Set("Authorization", "Bearer "+c.apiKey)
-    req.Header.Set("Content-Type", "application/json")
-
-    resp, err := http.DefaultClient.Do(req)
-    if err != nil {
-        return nil, fmt.Errorf("failed to make request: %v", err)
-    }
-    defer resp.Body.Close()
-
-    if resp.StatusCode != http.StatusOK {
-        body, _ := io.ReadAll(resp.Body)
-        return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+        return nil, err
     }
 
     var result struct {
@@ -107,12 +210,13 @@ func (c *GPTClient) AnalyzeWorkload(yaml string) (*WorkloadAnalysis, error) {
                 Content string `json:"content"`
             } `json:"message"`
         } `json:"choices"`
+        Usage Usage `json:"usage"`
         Error *struct {
             Message string `json:"message"`
         } `json:"error"`
     }
 
-    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+    if err := json.Unmarshal(body, &result); err != nil {
         return nil, fmt.Errorf("failed to decode response: %v", err)
     }
 
@@ -138,13 +242,160 @@ func (c *GPTClient) AnalyzeWorkload(yaml string) (*WorkloadAnalysis, error) {
 
     // Verify JSON structure
     if !strings.HasPrefix(content, "{") || !strings.HasSuffix(content, "}") {
-        return nil, fmt.Errorf("invalid JSON response format: %s", content)
+        return nil, &InvalidResponseError{Content: content, Usage: result.Usage}
     }
 
     var analysis WorkloadAnalysis
     if err := json.Unmarshal([]byte(content), &analysis); err != nil {
-        return nil, fmt.Errorf("failed to parse analysis (content: %s): %v", content, err)
+        return nil, &InvalidResponseError{Content: content, Usage: result.Usage, Err: err}
+    }
+    analysis.PromptVersion = c.prompts.Version
+    analysis.Focus = c.focus
+    analysis.Usage = result.Usage
+
+    c.mu.Lock()
+    if len(c.cache) >= maxCached {
+        clear(c.cache)
+    }
+    c.cache[key] = &analysis
+    c.mu.Unlock()
+
+    copied := analysis
+    return &copied, nil
+}
+
+func (c *GPTClient) cacheKey(kind, yaml string, evidence []byte) string {
+    hash := sha256.New()
+    for _, part := range []string{c.prompts.Version, c.model, fmt.Sprint(c.temperature), c.focus, kind, yaml, string(evidence)} {
+        hash.Write([]byte(part))
+        hash.Write([]byte{0})
+    }
+    return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *GPTClient) post(ctx context.Context, jsonData []byte) ([]byte, error) {
+    req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(jsonData))
+    if err != nil {
+        return nil, fmt.Errorf("failed to create request: %v", err)
+    }
+
+    req.Header.Set("Authorization", "Bearer "+c.apiKey)
+    req.Header.Set("Content-Type", "application/json")
+
+    resp, err := c.httpClient.Do(req)
+    if err != nil {
+        if ctx.Err() != nil {
+            return nil, ctx.Err()
+        }
+        return nil, retry.Retryable(fmt.Errorf("failed to make request: %v", err), 0)
+    }
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, retry.Retryable(fmt.Errorf("failed to read response: %v", err), 0)
+    }
+
+    if resp.StatusCode != http.StatusOK {
+        err := fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+        if retry.StatusRetryable(resp.StatusCode) {
+            return nil, retry.Retryable(err, retry.ParseRetryAfter(resp.Header.Get("Retry-After")))
+        }
+        return nil, err
+    }
+
+    return body, nil
+}
+
+// gptToolCall and gptMessage are the chat completions wire format of
+// tool calls and messages.
+type gptToolCall struct {
+    ID       string `json:"id"`
+    Type     string `json:"type"`
+    Function struct {
+        Name      string `json:"name"`
+        Arguments string `json:"arguments"`
+    } `json:"function"`
+}
+
+type gptMessage struct {
+    Role       string        `json:"role"`
+    Content    string        `json:"content"`
+    ToolCalls  []gptToolCall `json:"tool_calls,omitempty"`
+    ToolCallID string        `json:"tool_call_id,omitempty"`
+}
+
+// Chat sends the conversation, offering the tools, and returns the reply.
+func (c *GPTClient) Chat(ctx context.Context, messages []Message, tools []Tool) (*Message, error) {
+    var wire []gptMessage
+    for _, m := range messages {
+        msg := gptMessage{Role: m.Role, Content: m.Content, ToolCallID: m.ToolCallID}
+        for _, call := range m.ToolCalls {
+            tc := gptToolCall{ID: call.ID, Type: "function"}
+            tc.Function.Name = call.Name
+            tc.Function.Arguments = call.Arguments
+            msg.ToolCalls = append(msg.ToolCalls, tc)
+        }
+        wire = append(wire, msg)
     }
 
-    return &analysis, nil
-}
\ No newline at end of file
+    payload := map[string]interface{}{
+        "model":       c.model,
+        "messages":    wire,
+        "temperature": c.temperature,
+    }
+    if len(tools) > 0 {
+        var defs []map[string]interface{}
+        for _, tool := range tools {
+            defs = append(defs, map[string]interface{}{
+                "type": "function",
+                "function": map[string]interface{}{
+                    "name":        tool.Name,
+                    "description": tool.Description,
+                    "parameters":  tool.Parameters,
+                },
+            })
+        }
+        payload["tools"] = defs
+    }
+
+    jsonData, err := json.Marshal(payload)
+    if err != nil {
+        return nil, fmt.Errorf("failed to marshal request: %v", err)
+    }
+
+    var body []byte
+    err = retry.Do(ctx, c.retry, func(ctx context.Context) error {
+        var err error
+        body, err = c.post(ctx, jsonData)
+        return err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    var result struct {
+        Choices []struct {
+            Message gptMessage `json:"message"`
+        } `json:"choices"`
+        Error *struct {
+            Message string `json:"message"`
+        } `json:"error"`
+    }
+    if err := json.Unmarshal(body, &result); err != nil {
+        return nil, fmt.Errorf("failed to decode response: %v", err)
+    }
+    if result.Error != nil {
+        return nil, fmt.Errorf("API error: %s", result.Error.Message)
+    }
+    if len(result.Choices) == 0 {
+        return nil, fmt.Errorf("no response from GPT")
+    }
+
+    reply := result.Choices[0].Message
+    msg := &Message{Role: "assistant", Content: reply.Content}
+    for _, call := range reply.ToolCalls {
+        msg.ToolCalls = append(msg.ToolCalls, ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+    }
+    return msg, nil
+}
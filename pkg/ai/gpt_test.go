@@ -0,0 +1,132 @@
+package ai
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync/atomic"
+    "testing"
+
+    "go.opentelemetry.io/otel"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    "go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAnalyzeWorkloadCache(t *testing.T) {
+    var calls atomic.Int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        calls.Add(1)
+        var req struct {
+            Messages []struct {
+                Content string `json:"content"`
+            } `json:"messages"`
+        }
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) != 2 {
+            http.Error(w, "bad request", http.StatusBadRequest)
+            return
+        }
+        content, _ := json.Marshal(`{"analysis": "ok", "opportunities": [{"text": "x", "metrics": {"cpu_utilization": "10%"}}]}`)
+        fmt.Fprintf(w, `{"choices": [{"message": {"content": %s}}]}`, content)
+    }))
+    defer srv.Close()
+
+    dir := t.TempDir()
+    if err := os.MkdirAll(filepath.Join(dir, "focus"), 0o755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(filepath.Join(dir, "focus", "cost.tmpl"), []byte("Costs only."), 0o644); err != nil {
+        t.Fatal(err)
+    }
+
+    newClient := func(promptsDir, focus string) Provider {
+        t.Helper()
+        provider, err := NewProvider(Config{APIKey: "test", BaseURL: srv.URL, PromptsDir: promptsDir, Focus: focus, MaxRetries: 1})
+        if err != nil {
+            t.Fatal(err)
+        }
+        return provider
+    }
+    ev := Evidence{Metrics: map[string]string{"cpu_utilization": "10%"}}
+    ctx := context.Background()
+
+    client := newClient("", "")
+    first, err := client.AnalyzeWorkload(ctx, "deployment", "containers:", ev)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if first.PromptVersion == "" || first.Focus != "general" {
+        t.Errorf("expected the prompt version and focus to be recorded, got %q %q", first.PromptVersion, first.Focus)
+    }
+    first.Ground(Evidence{})
+    second, err := client.AnalyzeWorkload(ctx, "deployment", "containers:", ev)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if calls.Load() != 1 || len(second.Opportunities) != 1 {
+        t.Errorf("expected an intact cached analysis without a second call, got %d calls and %+v", calls.Load(), second.Opportunities)
+    }
+
+    if _, err := client.AnalyzeWorkload(ctx, "statefulset", "containers:", ev); err != nil {
+        t.Fatal(err)
+    }
+    overridden, err := newClient(dir, "cost").AnalyzeWorkload(ctx, "deployment", "containers:", ev)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if calls.Load() != 3 || !strings.Contains(overridden.PromptVersion, "+local.") {
+        t.Errorf("expected new kinds and prompts to miss the cache, got %d calls and version %q", calls.Load(), overridden.PromptVersion)
+    }
+
+    if _, err := NewProvider(Config{APIKey: "test", Focus: "latency"}); err == nil {
+        t.Error("expected an error for an unknown focus")
+    }
+}
+
+func TestAnalyzeWorkloadSpan(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        fmt.Fprint(w, `{"choices": [{"message": {"content": "{\"analysis\": \"ok\"}"}}], "usage": {"prompt_tokens": 120, "completion_tokens": 30}}`)
+    }))
+    defer srv.Close()
+
+    exporter := tracetest.NewInMemoryExporter()
+    previous := otel.GetTracerProvider()
+    otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+    defer otel.SetTracerProvider(previous)
+
+    provider, err := NewProvider(Config{APIKey: "test", BaseURL: srv.URL, Model: "gpt-4o-mini", MaxRetries: 1})
+    if err != nil {
+        t.Fatal(err)
+    }
+    for i := 0; i < 2; i++ {
+        if _, err := provider.AnalyzeWorkload(context.Background(), "deployment", "containers:", Evidence{}); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    spans := exporter.GetSpans()
+    if len(spans) != 2 {
+        t.Fatalf("expected 2 spans, got %d", len(spans))
+    }
+    for i, want := range []map[string]string{
+        {"gen_ai.request.model": "gpt-4o-mini", "kwa.workload.kind": "deployment", "gen_ai.usage.input_tokens": "120", "gen_ai.usage.output_tokens": "30", "kwa.ai.cache_hit": "false"},
+        {"gen_ai.usage.input_tokens": "0", "kwa.ai.cache_hit": "true"},
+    } {
+        attrs := make(map[string]string)
+        for _, kv := range spans[i].Attributes {
+            attrs[string(kv.Key)] = kv.Value.Emit()
+        }
+        if spans[i].Name != "llm.analyze" {
+            t.Errorf("unexpected span %s", spans[i].Name)
+        }
+        for key, value := range want {
+            if attrs[key] != value {
+                t.Errorf("call %d: expected %s=%s, got %v", i+1, key, value, attrs)
+            }
+        }
+    }
+}
@@ -14,8 +14,8 @@ type gptAnalysis struct {
     EfficiencyRate   string   `json:"efficiency_rate"`
     ReliabilityRisk  string   `json:"reliability_risk"`
     Analysis         string   `json:"analysis"`
-    Opportunities    []string `json:"opportunities"`
-    Cautions        []string `json:"cautions"`
+    Opportunities    []Claim  `json:"opportunities"`
+    Cautions        []Claim   `json:"cautions"`
     Blockers        []string `json:"blockers"`
     Recommendations []string `json:"recommendations"`
 }
@@ -0,0 +1,200 @@
+// Package prompts holds the AI prompt templates. They are text/template
+// files embedded in the binary; any of them can be overridden, and new focus
+// templates added, by files of the same name in a prompts directory.
+package prompts
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "embed"
+    "encoding/hex"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "text/template"
+)
+
+// Version identifies the embedded templates. Bump it whenever one of them
+// changes, so outputs and cached analyses tell prompt revisions apart.
+const Version = "2"
+
+// Template names. Kind templates are kinds/<type> and focus templates
+// focus/<focus>.
+const (
+    System   = "system"
+    Analysis = "analysis"
+    Chat     = "chat"
+)
+
+// DefaultFocus is the focus of an analysis when none is chosen.
+const DefaultFocus = "general"
+
+//go:embed templates
+var embedded embed.FS
+
+// Prompt is one template and where it was loaded from.
+type Prompt struct {
+    Name   string
+    Source string
+    Text   string
+
+    tmpl *template.Template
+}
+
+// Set is the templates in effect: the embedded ones with any overrides.
+// Version is Version, suffixed with a hash of the overrides when there are
+// any, e.g. "2+local.1a2b3c4d".
+type Set struct {
+    Version string
+    prompts map[string]*Prompt
+}
+
+// Load parses the embedded templates and, when dir is set, the *.tmpl files
+// under it.
+func Load(dir string) (*Set, error) {
+    set := &Set{Version: Version, prompts: make(map[string]*Prompt)}
+    sub, _ := fs.Sub(embedded, "templates")
+    if err := set.add(sub, "embedded"); err != nil {
+        return nil, err
+    }
+    if dir == "" {
+        return set, nil
+    }
+
+    if _, err := os.Stat(dir); err != nil {
+        return nil, fmt.Errorf("failed to read prompts directory: %v", err)
+    }
+    overrides := &Set{prompts: make(map[string]*Prompt)}
+    if err := overrides.add(os.DirFS(dir), dir); err != nil {
+        return nil, err
+    }
+    if len(overrides.prompts) == 0 {
+        return set, nil
+    }
+    hash := sha256.New()
+    for _, p := range overrides.List() {
+        set.prompts[p.Name] = p
+        fmt.Fprintf(hash, "%s\x00%s\x00", p.Name, p.Text)
+    }
+    set.Version += "+local." + hex.EncodeToString(hash.Sum(nil))[:8]
+    return set, nil
+}
+
+// Must returns the set, panicking on error. It is for the embedded
+// templates, which are covered by tests.
+func Must(set *Set, err error) *Set {
+    if err != nil {
+        panic(err)
+    }
+    return set
+}
+
+func (s *Set) add(fsys fs.FS, source string) error {
+    return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if d.IsDir() || filepath.Ext(path) != ".tmpl" {
+            return nil
+        }
+        data, err := fs.ReadFile(fsys, path)
+        if err != nil {
+            return fmt.Errorf("failed to read prompt %s: %v", path, err)
+        }
+        name := strings.TrimSuffix(filepath.ToSlash(path), ".tmpl")
+        tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+        if err != nil {
+            return fmt.Errorf("failed to parse prompt %s: %v", name, err)
+        }
+        if source != "embedded" {
+            source = filepath.Join(source, path)
+        }
+        s.prompts[name] = &Prompt{Name: name, Source: source, Text: string(data), tmpl: tmpl}
+        return nil
+    })
+}
+
+// List returns the templates sorted by name.
+func (s *Set) List() []*Prompt {
+    var list []*Prompt
+    for _, p := range s.prompts {
+        list = append(list, p)
+    }
+    sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+    return list
+}
+
+// Get returns the named template.
+func (s *Set) Get(name string) (*Prompt, bool) {
+    p, ok := s.prompts[name]
+    return p, ok
+}
+
+// Focuses lists the analysis focuses there are templates for.
+func (s *Set) Focuses() []string {
+    var focuses []string
+    for _, p := range s.List() {
+        if focus, ok := strings.CutPrefix(p.Name, "focus/"); ok {
+            focuses = append(focuses, focus)
+        }
+    }
+    return focuses
+}
+
+// HasFocus reports whether there is a template for focus.
+func (s *Set) HasFocus(focus string) bool {
+    _, ok := s.prompts["focus/"+focus]
+    return ok
+}
+
+// Render executes the named template.
+func (s *Set) Render(name string, data interface{}) (string, error) {
+    p, ok := s.prompts[name]
+    if !ok {
+        return "", fmt.Errorf("no prompt named %s", name)
+    }
+    var buf bytes.Buffer
+    if err := p.tmpl.Execute(&buf, data); err != nil {
+        return "", fmt.Errorf("failed to render prompt %s: %v", name, err)
+    }
+    return strings.TrimSpace(buf.String()), nil
+}
+
+// AnalysisData is what the analysis template renders.
+type AnalysisData struct {
+    Kind          string
+    Focus         string
+    KindGuidance  string
+    FocusGuidance string
+    Evidence      string
+    Manifest      string
+}
+
+// RenderAnalysis renders the analysis prompt for a workload type and focus,
+// with the kind and focus templates rendered into it. Kinds without a
+// template get no kind guidance.
+func (s *Set) RenderAnalysis(kind, focus, evidence, manifest string) (string, error) {
+    data := AnalysisData{Kind: kind, Focus: focus, Evidence: evidence, Manifest: manifest}
+    var err error
+    if _, ok := s.prompts["kinds/"+kind]; ok {
+        if data.KindGuidance, err = s.Render("kinds/"+kind, data); err != nil {
+            return "", err
+        }
+    }
+    if !s.HasFocus(focus) {
+        return "", fmt.Errorf("unknown analysis focus %q (want %s)", focus, strings.Join(s.Focuses(), ", "))
+    }
+    if data.FocusGuidance, err = s.Render("focus/"+focus, data); err != nil {
+        return "", err
+    }
+    return s.Render(Analysis, data)
+}
+
+// ChatData is what the chat template renders.
+type ChatData struct {
+    Analysis string
+    Manifest string
+}
@@ -0,0 +1,86 @@
+package prompts
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestRenderEmbedded(t *testing.T) {
+    set, err := Load("")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if set.Version != Version {
+        t.Errorf("expected version %s, got %s", Version, set.Version)
+    }
+    for _, kind := range []string{"deployment", "statefulset", "daemonset", "cronjob"} {
+        for _, focus := range set.Focuses() {
+            prompt, err := set.RenderAnalysis(kind, focus, `{"findings": {}}`, "containers:")
+            if err != nil {
+                t.Fatalf("%s/%s: %v", kind, focus, err)
+            }
+            if !strings.Contains(prompt, "this "+kind+" configuration") || !strings.HasSuffix(prompt, "containers:") {
+                t.Errorf("%s/%s: unexpected prompt:\n%s", kind, focus, prompt)
+            }
+        }
+    }
+    if _, err := set.RenderAnalysis("deployment", "latency", "{}", ""); err == nil {
+        t.Error("expected an error for an unknown focus")
+    }
+    if _, err := set.Render(Chat, ChatData{Analysis: "{}", Manifest: "kind: Deployment"}); err != nil {
+        t.Error(err)
+    }
+    if _, err := set.Render(Chat, nil); err == nil {
+        t.Error("expected an error rendering chat without data")
+    }
+}
+
+func TestLoadOverrides(t *testing.T) {
+    dir := t.TempDir()
+    write := func(name, text string) {
+        path := filepath.Join(dir, name)
+        if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    write("focus/cost.tmpl", "Only cost.")
+    write("focus/latency.tmpl", "Focus on {{.Kind}} latency.")
+    write("README.md", "ignored")
+
+    set, err := Load(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.HasPrefix(set.Version, Version+"+local.") {
+        t.Errorf("expected a local version, got %s", set.Version)
+    }
+    if p, _ := set.Get("focus/cost"); p.Source != filepath.Join(dir, "focus/cost.tmpl") || p.Text != "Only cost." {
+        t.Errorf("override not applied: %+v", p)
+    }
+    prompt, err := set.RenderAnalysis("statefulset", "latency", "{}", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(prompt, "Focus on statefulset latency.") {
+        t.Errorf("custom focus missing from prompt:\n%s", prompt)
+    }
+
+    write("focus/cost.tmpl", "Only cost, again.")
+    changed, err := Load(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if changed.Version == set.Version {
+        t.Error("expected editing an override to change the version")
+    }
+
+    write("bad.tmpl", "{{.Unclosed")
+    if _, err := Load(dir); err == nil {
+        t.Error("expected a parse error")
+    }
+}
@@ -0,0 +1,56 @@
+package ai
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "k8s-workload-analyzer/pkg/ai/prompts"
+)
+
+// Provider turns a workload manifest into an AI analysis citing the
+// evidence, and answers follow-up questions in a conversation (see
+// Conversation). Callers should Ground the analysis against the evidence.
+type Provider interface {
+    AnalyzeWorkload(ctx context.Context, kind, yaml string, evidence Evidence) (*WorkloadAnalysis, error)
+    Chat(ctx context.Context, messages []Message, tools []Tool) (*Message, error)
+}
+
+// Config selects and tunes a provider.
+type Config struct {
+    Provider    string
+    Model       string
+    Temperature float64
+    BaseURL     string
+    APIKey      string
+    Timeout     time.Duration
+    MaxRetries  int
+    // PromptsDir overrides the embedded prompt templates; Focus picks the
+    // analysis focus template (general when empty).
+    PromptsDir string
+    Focus      string
+    // Transport, when set, carries the provider's HTTP requests, e.g. to
+    // record or replay them.
+    Transport http.RoundTripper
+}
+
+func NewProvider(cfg Config) (Provider, error) {
+    switch cfg.Provider {
+    case "openai", "":
+        if cfg.APIKey == "" {
+            return nil, fmt.Errorf("an API key is required for the %s provider", "openai")
+        }
+        set, err := prompts.Load(cfg.PromptsDir)
+        if err != nil {
+            return nil, err
+        }
+        if cfg.Focus != "" && !set.HasFocus(cfg.Focus) {
+            return nil, fmt.Errorf("unknown analysis focus %q (want %s)", cfg.Focus, strings.Join(set.Focuses(), ", "))
+        }
+        return newGPTClient(cfg, set), nil
+    default:
+        return nil, fmt.Errorf("unsupported AI provider: %s", cfg.Provider)
+    }
+}
@@ -0,0 +1,25 @@
+package ai
+
+import "regexp"
+
+// redactions mask credentials in text sent to a provider: bearer and basic
+// auth values, credentials in URLs, JWTs, and values of keys that look
+// secret, such as password=..., "token": "..." or API_KEY: ...
+var redactions = []struct {
+    pattern *regexp.Regexp
+    replace string
+}{
+    {regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`), "$1 REDACTED"},
+    {regexp.MustCompile(`://[^/\s:@]+:[^/\s@]+@`), "://REDACTED@"},
+    {regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`), "REDACTED"},
+    {regexp.MustCompile(`(?i)([\w.-]*(?:passw(?:or)?d|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)[\w.-]*["']?\s*[:=]\s*["']?)[^\s"',;&]+`), "${1}REDACTED"},
+}
+
+// Redact masks credentials in text before it leaves the machine, e.g.
+// manifests and logs sent for analysis.
+func Redact(text string) string {
+    for _, r := range redactions {
+        text = r.pattern.ReplaceAllString(text, r.replace)
+    }
+    return text
+}
@@ -1,5 +1,7 @@
 package ai
 
+import "fmt"
+
 type WorkloadAnalysis struct {
     MainContainer     string   `json:"main_container"`
     PodQoSClass      string   `json:"pod_qos_class"`
@@ -9,8 +11,35 @@ type WorkloadAnalysis struct {
     EfficiencyRate   string   `json:"efficiency_rate"`
     ReliabilityRisk  string   `json:"reliability_risk"`
     Analysis         string   `json:"analysis"`
-    Opportunities    []string `json:"opportunities"`
-    Cautions        []string `json:"cautions"`
+    Opportunities    []Claim  `json:"opportunities"`
+    Cautions        []Claim   `json:"cautions"`
     Blockers        []string `json:"blockers"`
     Recommendations []string `json:"recommendations"`
+
+    // PromptVersion and Focus record the prompts the analysis came from;
+    // Usage is the tokens it cost, zero when it came from the cache.
+    PromptVersion string `json:"-"`
+    Focus         string `json:"-"`
+    Usage         Usage  `json:"-"`
+}
+
+// Usage counts the tokens of a completion.
+type Usage struct {
+    PromptTokens     int `json:"prompt_tokens"`
+    CompletionTokens int `json:"completion_tokens"`
+}
+
+// InvalidResponseError is returned when the model's reply isn't the JSON
+// analysis asked for. The tokens were spent all the same.
+type InvalidResponseError struct {
+    Content string
+    Usage   Usage
+    Err     error
+}
+
+func (e *InvalidResponseError) Error() string {
+    if e.Err == nil {
+        return fmt.Sprintf("invalid JSON response format: %s", e.Content)
+    }
+    return fmt.Sprintf("failed to parse analysis (content: %s): %v", e.Content, e.Err)
 }
\ No newline at end of file
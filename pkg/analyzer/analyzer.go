@@ -3,35 +3,43 @@ package analyzer
 import (
     "context"
     "fmt"
+    "log/slog"
+    appsv1 "k8s.io/api/apps/v1"
+    autoscalingv2 "k8s.io/api/autoscaling/v2"
     corev1 "k8s.io/api/core/v1"
     metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
     "k8s.io/client-go/kubernetes"
-    "k8s.io/client-go/rest"
+    metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+    "k8s-workload-analyzer/pkg/logging"
+    "k8s-workload-analyzer/pkg/tracing"
 )
 
-func GetWorkloadYAML(client *kubernetes.Clientset, namespace, workloadType, name string) (string, error) {
-    ctx := context.Background()
-    
-    deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+func GetWorkloadYAML(ctx context.Context, client kubernetes.Interface, namespace, workloadType, name string) (string, error) {
+    w, err := getWorkload(ctx, client, namespace, workloadType, name)
     if err != nil {
-        return "", fmt.Errorf("failed to get deployment: %v", err)
+        return "", fmt.Errorf("failed to get %s: %w", workloadType, err)
     }
 
-    // Extract important deployment details
+    // Extract important workload details
     yamlInfo := fmt.Sprintf(`
 apiVersion: apps/v1
-kind: Deployment
+kind: %s
 metadata:
   name: %s
   namespace: %s
-spec:
-  replicas: %d
+spec:`, w.Kind, w.Name, namespace)
+    if w.Replicas != nil {
+        yamlInfo += fmt.Sprintf(`
+  replicas: %d`, *w.Replicas)
+    }
+    yamlInfo += `
   template:
     spec:
-      containers:`, deployment.Name, deployment.Namespace, *deployment.Spec.Replicas)
+      containers:`
 
     // Add container details
-    for _, container := range deployment.Spec.Template.Spec.Containers {
+    for _, container := range w.PodSpec.Containers {
         yamlInfo += fmt.Sprintf(`
       - name: %s
         image: %s
@@ -54,40 +62,82 @@ spec:
     return yamlInfo, nil
 }
 
-func AnalyzeWorkload(client *kubernetes.Clientset, namespace, workloadType, name string, config *rest.Config) (*WorkloadDetails, error) {
-    var podSpec *corev1.PodSpec
-
+func AnalyzeWorkload(ctx context.Context, client kubernetes.Interface, metricsClient metricsv.Interface, namespace, workloadType, name string) (*WorkloadDetails, error) {
     // Get workload based on type
-    switch workloadType {
-    case "deployment":
-        deployment, err := client.AppsV1().Deployments(namespace).Get(context.Background(), name, metav1.GetOptions{})
-        if err != nil {
-            return nil, err
-        }
-        podSpec = &deployment.Spec.Template.Spec
-    case "statefulset":
-        sts, err := client.AppsV1().StatefulSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
-        if err != nil {
-            return nil, err
-        }
-        podSpec = &sts.Spec.Template.Spec
-    case "daemonset":
-        ds, err := client.AppsV1().DaemonSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+    done := logging.Phase(ctx, "lookup", "namespace", namespace, "kind", workloadType, "name", name)
+    spanCtx, span := tracing.Start(ctx, "workload.lookup",
+        tracing.Namespace.String(namespace), tracing.Kind.String(workloadType), tracing.Workload.String(name))
+    w, err := getWorkload(spanCtx, client, namespace, workloadType, name)
+    tracing.End(span, err)
+    done()
+    if err != nil {
+        return nil, err
+    }
+    return analyzeWorkload(ctx, client, metricsClient, namespace, w)
+}
+
+// AnalyzeNamespace analyzes every Deployment, StatefulSet and DaemonSet in
+// the namespace. A workload whose metrics can't be read is reported with the
+// error in its efficiency rate rather than failing the whole scan.
+func AnalyzeNamespace(ctx context.Context, client kubernetes.Interface, metricsClient metricsv.Interface, namespace string) ([]*WorkloadDetails, error) {
+    workloads, err := listWorkloads(ctx, client, namespace)
+    if err != nil {
+        return nil, err
+    }
+
+    var results []*WorkloadDetails
+    for _, w := range workloads {
+        details, err := analyzeWorkload(ctx, client, metricsClient, namespace, w)
         if err != nil {
-            return nil, err
+            if ctx.Err() != nil {
+                return nil, ctx.Err()
+            }
+            details = newWorkloadDetails(namespace, w, map[string]string{"efficiency_rate": fmt.Sprintf("N/A (%v)", err)}, nil)
         }
-        podSpec = &ds.Spec.Template.Spec
+        results = append(results, details)
+    }
+    return results, nil
+}
+
+// DetailsFromObject builds details for a Deployment, StatefulSet or DaemonSet
+// read from a manifest, without usage data. It returns false for other kinds.
+func DetailsFromObject(obj runtime.Object, namespace string) (*WorkloadDetails, bool) {
+    var w *workload
+    switch o := obj.(type) {
+    case *appsv1.Deployment:
+        w = fromDeployment(o)
+    case *appsv1.StatefulSet:
+        w = fromStatefulSet(o)
+    case *appsv1.DaemonSet:
+        w = fromDaemonSet(o)
     default:
-        return nil, fmt.Errorf("unsupported workload type: %s", workloadType)
+        return nil, false
     }
 
+    if ns := obj.(metav1.Object).GetNamespace(); ns != "" {
+        namespace = ns
+    }
+    return newWorkloadDetails(namespace, w, map[string]string{
+        "cpu_utilization":    "N/A",
+        "memory_utilization": "N/A",
+        "replica_count":      fmt.Sprintf("%d", w.Desired),
+        "efficiency_rate":    "N/A (Manifest)",
+    }, nil), true
+}
+
+func analyzeWorkload(ctx context.Context, client kubernetes.Interface, metricsClient metricsv.Interface, namespace string, w *workload) (*WorkloadDetails, error) {
     // Get metrics
-    metrics, err := GetMetrics(client, namespace, name, config)
+    metrics, usage, err := workloadMetrics(ctx, client, metricsClient, namespace, w)
     if err != nil {
-        return nil, fmt.Errorf("failed to get metrics: %v", err)
+        return nil, fmt.Errorf("failed to get metrics: %w", err)
     }
 
-    fmt.Printf("Debug - Metrics map: %+v\n", metrics) // Add this debug line
+    slog.DebugContext(ctx, "workload metrics", "namespace", namespace, "kind", w.Kind, "name", w.Name, "metrics", metrics)
+    return newWorkloadDetails(namespace, w, metrics, usage), nil
+}
+
+func newWorkloadDetails(namespace string, w *workload, metrics map[string]string, usage map[string]containerUsage) *WorkloadDetails {
+    podSpec := w.PodSpec
 
     // Get main container and QoS class
     var mainContainer string
@@ -95,29 +145,54 @@ func AnalyzeWorkload(client *kubernetes.Clientset, namespace, workloadType, name
         mainContainer = podSpec.Containers[0].Name
     }
 
-    details := &WorkloadDetails{
+    return &WorkloadDetails{
         Namespace:         namespace,
-        Deployment:       name,
-        Kind:            workloadType,
+        Deployment:       w.Name,
+        Kind:            w.Type(),
         MainContainer:    mainContainer,
         PodQoSClass:     string(podSpec.PriorityClassName),
         ReplicaCount:    metrics["replica_count"],
+        DesiredReplicas: w.Desired,
         CPUUtilization:  metrics["cpu_utilization"],
         MemoryUtilization: metrics["memory_utilization"],
         EfficiencyRate:   metrics["efficiency_rate"],  // This line is important
         ContainerCount:   fmt.Sprintf("%d", len(podSpec.Containers)),
         NetworkTraffic:   metrics["network_traffic"],
         OpsaniFlags:      "N/A",
+        Containers:      containerDetails(podSpec, usage),
+        PodSpec:         podSpec,
     }
+}
 
-    fmt.Printf("Debug - WorkloadDetails: %+v\n", details) // Add this debug line
-
-    return details, nil
+func containerDetails(podSpec *corev1.PodSpec, usage map[string]containerUsage) []ContainerDetails {
+    containers := make([]ContainerDetails, 0, len(podSpec.Containers))
+    for _, container := range podSpec.Containers {
+        c := ContainerDetails{
+            Name:          container.Name,
+            Image:         container.Image,
+            CPURequest:    container.Resources.Requests.Cpu().MilliValue(),
+            CPULimit:      container.Resources.Limits.Cpu().MilliValue(),
+            MemoryRequest: container.Resources.Requests.Memory().Value(),
+            MemoryLimit:   container.Resources.Limits.Memory().Value(),
+        }
+        if u, ok := usage[container.Name]; ok {
+            c.HasUsage = true
+            c.CPUUsage = u.CPU
+            c.MemoryUsage = u.Memory
+        }
+        containers = append(containers, c)
+    }
+    return containers
 }
 
-func GetWorkloadMetrics(client *kubernetes.Clientset, namespace, name string) (map[string]string, error) {
+func GetWorkloadMetrics(ctx context.Context, client kubernetes.Interface, namespace, name string) (map[string]string, error) {
     // Get HPA metrics
-    hpa, err := client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(context.Background(), name, metav1.GetOptions{})
+    var hpa *autoscalingv2.HorizontalPodAutoscaler
+    err := withRetry(ctx, func(ctx context.Context) error {
+        var err error
+        hpa, err = client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, name, metav1.GetOptions{})
+        return err
+    })
     if err != nil {
         return map[string]string{
             "cpu_utilization": "N/A",
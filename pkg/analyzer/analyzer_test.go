@@ -0,0 +1,305 @@
+package analyzer
+
+import (
+    "context"
+    "strings"
+    "testing"
+
+    appsv1 "k8s.io/api/apps/v1"
+    autoscalingv2 "k8s.io/api/autoscaling/v2"
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/resource"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/client-go/kubernetes/fake"
+    metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+    metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+const testNamespace = "default"
+
+var testLabels = map[string]string{"app": "web"}
+
+func podTemplate(cpu, memory string) corev1.PodTemplateSpec {
+    requests := corev1.ResourceList{}
+    if cpu != "" {
+        requests[corev1.ResourceCPU] = resource.MustParse(cpu)
+    }
+    if memory != "" {
+        requests[corev1.ResourceMemory] = resource.MustParse(memory)
+    }
+    return corev1.PodTemplateSpec{
+        ObjectMeta: metav1.ObjectMeta{Labels: testLabels},
+        Spec: corev1.PodSpec{
+            Containers: []corev1.Container{{
+                Name:      "app",
+                Image:     "nginx:1.27",
+                Resources: corev1.ResourceRequirements{Requests: requests},
+            }},
+        },
+    }
+}
+
+func deployment(name, cpu, memory string) *appsv1.Deployment {
+    replicas := int32(2)
+    return &appsv1.Deployment{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+        Spec: appsv1.DeploymentSpec{
+            Replicas: &replicas,
+            Selector: &metav1.LabelSelector{MatchLabels: testLabels},
+            Template: podTemplate(cpu, memory),
+        },
+    }
+}
+
+func statefulSet(name, cpu, memory string) *appsv1.StatefulSet {
+    replicas := int32(2)
+    return &appsv1.StatefulSet{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+        Spec: appsv1.StatefulSetSpec{
+            Replicas: &replicas,
+            Selector: &metav1.LabelSelector{MatchLabels: testLabels},
+            Template: podTemplate(cpu, memory),
+        },
+    }
+}
+
+func daemonSet(name, cpu, memory string) *appsv1.DaemonSet {
+    return &appsv1.DaemonSet{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+        Spec: appsv1.DaemonSetSpec{
+            Selector: &metav1.LabelSelector{MatchLabels: testLabels},
+            Template: podTemplate(cpu, memory),
+        },
+    }
+}
+
+func pod(name string) *corev1.Pod {
+    return &corev1.Pod{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: testLabels},
+    }
+}
+
+func podMetrics(name, cpu, memory string) *metricsv1beta1.PodMetrics {
+    return &metricsv1beta1.PodMetrics{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+        Containers: []metricsv1beta1.ContainerMetrics{{
+            Name: "app",
+            Usage: corev1.ResourceList{
+                corev1.ResourceCPU:    resource.MustParse(cpu),
+                corev1.ResourceMemory: resource.MustParse(memory),
+            },
+        }},
+    }
+}
+
+func hpa(name string, current, desired int32) *autoscalingv2.HorizontalPodAutoscaler {
+    return &autoscalingv2.HorizontalPodAutoscaler{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+        Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+            CurrentReplicas: current,
+            DesiredReplicas: desired,
+        },
+    }
+}
+
+// newMetricsClient builds a fake metrics clientset. The generated fake looks
+// pod metrics up under the "pods" resource, so objects are added to the
+// tracker directly rather than through NewSimpleClientset.
+func newMetricsClient(t *testing.T, objects ...*metricsv1beta1.PodMetrics) *metricsfake.Clientset {
+    t.Helper()
+    client := metricsfake.NewSimpleClientset()
+    gvr := metricsv1beta1.SchemeGroupVersion.WithResource("pods")
+    for _, obj := range objects {
+        if err := client.Tracker().Create(gvr, obj, obj.Namespace); err != nil {
+            t.Fatalf("failed to add pod metrics %s: %v", obj.Name, err)
+        }
+    }
+    return client
+}
+
+func TestAnalyzeWorkload(t *testing.T) {
+    tests := []struct {
+        name           string
+        kind           string
+        objects        []runtime.Object
+        metrics        []*metricsv1beta1.PodMetrics
+        wantReplicas   string
+        wantCPU        string
+        wantMemory     string
+        wantEfficiency string
+    }{
+        {
+            name:           "deployment with high efficiency",
+            kind:           "deployment",
+            objects:        []runtime.Object{deployment("web", "100m", "100Mi"), pod("web-1"), pod("web-2")},
+            metrics:        []*metricsv1beta1.PodMetrics{podMetrics("web-1", "90m", "90Mi"), podMetrics("web-2", "90m", "90Mi")},
+            wantReplicas:   "2",
+            wantCPU:        "90m",
+            wantMemory:     "90Mi",
+            wantEfficiency: "High (90.0%)",
+        },
+        {
+            name:           "statefulset with medium efficiency",
+            kind:           "statefulset",
+            objects:        []runtime.Object{statefulSet("web", "100m", "100Mi"), pod("web-0")},
+            metrics:        []*metricsv1beta1.PodMetrics{podMetrics("web-0", "60m", "60Mi")},
+            wantReplicas:   "1",
+            wantCPU:        "60m",
+            wantMemory:     "60Mi",
+            wantEfficiency: "Medium (60.0%)",
+        },
+        {
+            name:           "daemonset with low efficiency",
+            kind:           "daemonset",
+            objects:        []runtime.Object{daemonSet("web", "1", "2Gi"), pod("web-abcde")},
+            metrics:        []*metricsv1beta1.PodMetrics{podMetrics("web-abcde", "100m", "1100Mi")},
+            wantReplicas:   "1",
+            wantCPU:        "100m",
+            wantMemory:     "1.07Gi",
+            wantEfficiency: "Low (31.9%)",
+        },
+        {
+            name:           "missing pod metrics",
+            kind:           "deployment",
+            objects:        []runtime.Object{deployment("web", "100m", "100Mi"), pod("web-1")},
+            wantReplicas:   "1",
+            wantEfficiency: "N/A (No metrics)",
+        },
+        {
+            name:           "no pods",
+            kind:           "deployment",
+            objects:        []runtime.Object{deployment("web", "100m", "100Mi")},
+            wantReplicas:   "0",
+            wantCPU:        "N/A",
+            wantMemory:     "N/A",
+            wantEfficiency: "N/A (No running pods)",
+        },
+        {
+            name:           "zero requests",
+            kind:           "deployment",
+            objects:        []runtime.Object{deployment("web", "", ""), pod("web-1")},
+            metrics:        []*metricsv1beta1.PodMetrics{podMetrics("web-1", "50m", "64Mi")},
+            wantReplicas:   "1",
+            wantCPU:        "50m",
+            wantMemory:     "64Mi",
+            wantEfficiency: "N/A",
+        },
+        {
+            name:           "hpa replica count",
+            kind:           "deployment",
+            objects:        []runtime.Object{deployment("web", "100m", "100Mi"), pod("web-1"), hpa("web", 2, 4)},
+            metrics:        []*metricsv1beta1.PodMetrics{podMetrics("web-1", "90m", "90Mi")},
+            wantReplicas:   "2/4",
+            wantCPU:        "90m",
+            wantMemory:     "90Mi",
+            wantEfficiency: "High (90.0%)",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            client := fake.NewSimpleClientset(tt.objects...)
+            metricsClient := newMetricsClient(t, tt.metrics...)
+
+            details, err := AnalyzeWorkload(context.Background(), client, metricsClient, testNamespace, tt.kind, "web")
+            if err != nil {
+                t.Fatalf("AnalyzeWorkload() error = %v", err)
+            }
+
+            if details.Kind != tt.kind || details.Deployment != "web" || details.MainContainer != "app" {
+                t.Errorf("unexpected identity: kind=%q name=%q container=%q", details.Kind, details.Deployment, details.MainContainer)
+            }
+            if details.ContainerCount != "1" {
+                t.Errorf("ContainerCount = %q, want %q", details.ContainerCount, "1")
+            }
+            if details.ReplicaCount != tt.wantReplicas {
+                t.Errorf("ReplicaCount = %q, want %q", details.ReplicaCount, tt.wantReplicas)
+            }
+            if details.CPUUtilization != tt.wantCPU {
+                t.Errorf("CPUUtilization = %q, want %q", details.CPUUtilization, tt.wantCPU)
+            }
+            if details.MemoryUtilization != tt.wantMemory {
+                t.Errorf("MemoryUtilization = %q, want %q", details.MemoryUtilization, tt.wantMemory)
+            }
+            if details.EfficiencyRate != tt.wantEfficiency {
+                t.Errorf("EfficiencyRate = %q, want %q", details.EfficiencyRate, tt.wantEfficiency)
+            }
+        })
+    }
+}
+
+func TestAnalyzeWorkloadErrors(t *testing.T) {
+    client := fake.NewSimpleClientset(deployment("web", "100m", "100Mi"))
+    metricsClient := newMetricsClient(t)
+
+    if _, err := AnalyzeWorkload(context.Background(), client, metricsClient, testNamespace, "cronjob", "web"); err == nil || !strings.Contains(err.Error(), "unsupported workload type") {
+        t.Errorf("expected unsupported workload type error, got %v", err)
+    }
+    if _, err := AnalyzeWorkload(context.Background(), client, metricsClient, testNamespace, "statefulset", "web"); err == nil {
+        t.Error("expected error for missing statefulset")
+    }
+}
+
+func TestGetWorkloadYAML(t *testing.T) {
+    client := fake.NewSimpleClientset(statefulSet("db", "250m", "512Mi"))
+
+    yaml, err := GetWorkloadYAML(context.Background(), client, testNamespace, "statefulset", "db")
+    if err != nil {
+        t.Fatalf("GetWorkloadYAML() error = %v", err)
+    }
+    for _, want := range []string{"kind: StatefulSet", "name: db", "replicas: 2", "image: nginx:1.27", "cpu: 250m", "memory: 512Mi"} {
+        if !strings.Contains(yaml, want) {
+            t.Errorf("GetWorkloadYAML() missing %q in:\n%s", want, yaml)
+        }
+    }
+}
+
+func TestGetWorkloadMetrics(t *testing.T) {
+    client := fake.NewSimpleClientset()
+    metrics, err := GetWorkloadMetrics(context.Background(), client, testNamespace, "web")
+    if err != nil {
+        t.Fatalf("GetWorkloadMetrics() error = %v", err)
+    }
+    if metrics["replica_count"] != "N/A" {
+        t.Errorf("replica_count without HPA = %q, want N/A", metrics["replica_count"])
+    }
+
+    client = fake.NewSimpleClientset(hpa("web", 3, 5))
+    metrics, err = GetWorkloadMetrics(context.Background(), client, testNamespace, "web")
+    if err != nil {
+        t.Fatalf("GetWorkloadMetrics() error = %v", err)
+    }
+    if metrics["replica_count"] != "3/5" {
+        t.Errorf("replica_count with HPA = %q, want 3/5", metrics["replica_count"])
+    }
+}
+
+func TestAnalyzeNamespace(t *testing.T) {
+    client := fake.NewSimpleClientset(
+        deployment("web", "100m", "100Mi"),
+        statefulSet("db", "500m", "1Gi"),
+        daemonSet("agent", "50m", "64Mi"),
+        pod("web-1"),
+    )
+    metricsClient := newMetricsClient(t, podMetrics("web-1", "90m", "90Mi"))
+
+    results, err := AnalyzeNamespace(context.Background(), client, metricsClient, testNamespace)
+    if err != nil {
+        t.Fatalf("AnalyzeNamespace() error = %v", err)
+    }
+
+    var got []string
+    for _, details := range results {
+        got = append(got, details.Kind+"/"+details.Deployment)
+    }
+    want := []string{"deployment/web", "statefulset/db", "daemonset/agent"}
+    if strings.Join(got, ",") != strings.Join(want, ",") {
+        t.Fatalf("AnalyzeNamespace() workloads = %v, want %v", got, want)
+    }
+
+    web := results[0].Containers[0]
+    if !web.HasUsage || web.CPURequest != 100 || web.CPUUsage != 90 || web.MemoryUsage != 90*1024*1024 {
+        t.Errorf("unexpected container details: %+v", web)
+    }
+}
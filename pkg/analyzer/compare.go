@@ -0,0 +1,194 @@
+package analyzer
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// ClusterResult is the analysis of one cluster in a multi-cluster run. Error
+// is set when the cluster couldn't be analyzed at all.
+type ClusterResult struct {
+    Cluster   string             `json:"cluster"`
+    Workloads []*WorkloadDetails `json:"workloads"`
+    Error     string             `json:"error,omitempty"`
+}
+
+// Comparison lines up the same workloads across clusters.
+type Comparison struct {
+    Clusters  []string             `json:"clusters"`
+    Errors    map[string]string    `json:"errors,omitempty"`
+    Workloads []WorkloadComparison `json:"workloads"`
+}
+
+type WorkloadComparison struct {
+    Namespace string                      `json:"namespace"`
+    Kind      string                      `json:"kind"`
+    Name      string                      `json:"name"`
+    Clusters  map[string]*WorkloadDetails `json:"clusters"`
+    Drift     []Drift                     `json:"drift"`
+}
+
+// Drift is a configuration value that differs between clusters. Values maps
+// cluster name to the value seen there; "-" means absent.
+type Drift struct {
+    Field  string            `json:"field"`
+    Values map[string]string `json:"values"`
+}
+
+// Compare groups workloads by namespace, kind and name and reports where
+// their configuration or findings differ. Usage is expected to differ and is
+// not treated as drift.
+func Compare(results []ClusterResult) *Comparison {
+    comparison := &Comparison{}
+    byKey := make(map[string]*WorkloadComparison)
+    var keys []string
+
+    for _, result := range results {
+        comparison.Clusters = append(comparison.Clusters, result.Cluster)
+        if result.Error != "" {
+            if comparison.Errors == nil {
+                comparison.Errors = make(map[string]string)
+            }
+            comparison.Errors[result.Cluster] = result.Error
+            continue
+        }
+        for _, details := range result.Workloads {
+            key := strings.Join([]string{details.Namespace, details.Kind, details.Deployment}, "/")
+            wc, ok := byKey[key]
+            if !ok {
+                wc = &WorkloadComparison{
+                    Namespace: details.Namespace,
+                    Kind:      details.Kind,
+                    Name:      details.Deployment,
+                    Clusters:  make(map[string]*WorkloadDetails),
+                }
+                byKey[key] = wc
+                keys = append(keys, key)
+            }
+            wc.Clusters[result.Cluster] = details
+        }
+    }
+
+    sort.Strings(keys)
+    var analyzed []string
+    for _, result := range results {
+        if result.Error == "" {
+            analyzed = append(analyzed, result.Cluster)
+        }
+    }
+    for _, key := range keys {
+        wc := byKey[key]
+        wc.Drift = detectDrift(analyzed, wc.Clusters)
+        comparison.Workloads = append(comparison.Workloads, *wc)
+    }
+    return comparison
+}
+
+func detectDrift(clusters []string, byCluster map[string]*WorkloadDetails) []Drift {
+    var drift []Drift
+    add := func(field string, value func(d *WorkloadDetails) string) {
+        values := make(map[string]string, len(clusters))
+        distinct := make(map[string]bool)
+        for _, cluster := range clusters {
+            v := "-"
+            if d, ok := byCluster[cluster]; ok {
+                v = value(d)
+            }
+            values[cluster] = v
+            distinct[v] = true
+        }
+        if len(distinct) > 1 {
+            drift = append(drift, Drift{Field: field, Values: values})
+        }
+    }
+
+    add("present", func(d *WorkloadDetails) string { return "yes" })
+    add("replicas", func(d *WorkloadDetails) string { return fmt.Sprintf("%d", d.DesiredReplicas) })
+
+    for _, name := range containerNames(byCluster) {
+        name := name
+        container := func(d *WorkloadDetails) *ContainerDetails {
+            for i := range d.Containers {
+                if d.Containers[i].Name == name {
+                    return &d.Containers[i]
+                }
+            }
+            return nil
+        }
+        field := func(label string, value func(c *ContainerDetails) string) {
+            add(fmt.Sprintf("container %s %s", name, label), func(d *WorkloadDetails) string {
+                c := container(d)
+                if c == nil {
+                    return "-"
+                }
+                return value(c)
+            })
+        }
+        field("image", func(c *ContainerDetails) string { return c.Image })
+        field("cpu request", func(c *ContainerDetails) string { return FormatCPU(c.CPURequest) })
+        field("cpu limit", func(c *ContainerDetails) string { return FormatCPU(c.CPULimit) })
+        field("memory request", func(c *ContainerDetails) string { return FormatMemory(c.MemoryRequest) })
+        field("memory limit", func(c *ContainerDetails) string { return FormatMemory(c.MemoryLimit) })
+    }
+
+    for _, id := range findingIDs(byCluster) {
+        id := id
+        add("finding "+id, func(d *WorkloadDetails) string {
+            for _, f := range d.Findings {
+                if f.ID == id {
+                    return string(f.Severity)
+                }
+            }
+            return "-"
+        })
+    }
+    return drift
+}
+
+func containerNames(byCluster map[string]*WorkloadDetails) []string {
+    seen := make(map[string]bool)
+    var names []string
+    for _, d := range byCluster {
+        for _, c := range d.Containers {
+            if !seen[c.Name] {
+                seen[c.Name] = true
+                names = append(names, c.Name)
+            }
+        }
+    }
+    sort.Strings(names)
+    return names
+}
+
+func findingIDs(byCluster map[string]*WorkloadDetails) []string {
+    seen := make(map[string]bool)
+    var ids []string
+    for _, d := range byCluster {
+        for _, f := range d.Findings {
+            if !seen[f.ID] {
+                seen[f.ID] = true
+                ids = append(ids, f.ID)
+            }
+        }
+    }
+    sort.Strings(ids)
+    return ids
+}
+
+// FormatCPU renders millicores the way the metrics output does; zero is
+// shown as "-" since it means the value is unset.
+func FormatCPU(millicores int64) string {
+    if millicores == 0 {
+        return "-"
+    }
+    return fmt.Sprintf("%dm", millicores)
+}
+
+// FormatMemory renders bytes in Mi or Gi; zero is shown as "-".
+func FormatMemory(bytes int64) string {
+    if bytes == 0 {
+        return "-"
+    }
+    return formatMemory(bytes)
+}
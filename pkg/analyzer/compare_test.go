@@ -0,0 +1,64 @@
+package analyzer
+
+import "testing"
+
+func workloadIn(cluster string, replicas int32, image string, findings ...string) *WorkloadDetails {
+    details := &WorkloadDetails{
+        Cluster:         cluster,
+        Namespace:       testNamespace,
+        Deployment:      "web",
+        Kind:            "deployment",
+        DesiredReplicas: replicas,
+        Containers: []ContainerDetails{{
+            Name:          "app",
+            Image:         image,
+            CPURequest:    100,
+            MemoryRequest: 128 * 1024 * 1024,
+        }},
+    }
+    for _, id := range findings {
+        details.Findings = append(details.Findings, Finding{ID: id, RuleID: id, Severity: SeverityMedium})
+    }
+    return details
+}
+
+func TestCompare(t *testing.T) {
+    comparison := Compare([]ClusterResult{
+        {Cluster: "eu", Workloads: []*WorkloadDetails{workloadIn("eu", 3, "web:1.2", "KWA004/app")}},
+        {Cluster: "us", Workloads: []*WorkloadDetails{workloadIn("us", 2, "web:1.2", "KWA004/app", "KWA006")}},
+        {Cluster: "ap", Error: "connection refused"},
+    })
+
+    if len(comparison.Clusters) != 3 || comparison.Errors["ap"] != "connection refused" {
+        t.Fatalf("unexpected clusters/errors: %v %v", comparison.Clusters, comparison.Errors)
+    }
+    if len(comparison.Workloads) != 1 {
+        t.Fatalf("expected 1 workload, got %d", len(comparison.Workloads))
+    }
+
+    drift := make(map[string]Drift)
+    for _, d := range comparison.Workloads[0].Drift {
+        drift[d.Field] = d
+    }
+    if len(drift) != 2 {
+        t.Errorf("expected replicas and KWA006 drift, got %+v", comparison.Workloads[0].Drift)
+    }
+    if d, ok := drift["replicas"]; !ok || d.Values["eu"] != "3" || d.Values["us"] != "2" {
+        t.Errorf("unexpected replicas drift: %+v", d)
+    }
+    if d, ok := drift["finding KWA006"]; !ok || d.Values["eu"] != "-" || d.Values["us"] != "medium" {
+        t.Errorf("unexpected finding drift: %+v", d)
+    }
+}
+
+func TestCompareMissingWorkload(t *testing.T) {
+    comparison := Compare([]ClusterResult{
+        {Cluster: "eu", Workloads: []*WorkloadDetails{workloadIn("eu", 2, "web:1.2")}},
+        {Cluster: "us"},
+    })
+
+    drift := comparison.Workloads[0].Drift
+    if len(drift) == 0 || drift[0].Field != "present" || drift[0].Values["us"] != "-" {
+        t.Errorf("expected presence drift first, got %+v", drift)
+    }
+}
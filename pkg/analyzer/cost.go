@@ -0,0 +1,44 @@
+package analyzer
+
+// hoursPerMonth is the average number of hours in a month (365 * 24 / 12).
+const hoursPerMonth = 730
+
+// Pricing is the on-demand price of requested resources.
+type Pricing struct {
+    Currency      string
+    CPUCoreHour   float64
+    MemoryGiBHour float64
+}
+
+// Cost estimates what a workload's requests cost per month and how much of
+// that pays for capacity it doesn't use. Waste only counts containers with
+// usage data.
+type Cost struct {
+    Currency         string  `json:"currency"`
+    MonthlyRequested float64 `json:"monthly_requested"`
+    MonthlyUsed      float64 `json:"monthly_used"`
+    MonthlyWaste     float64 `json:"monthly_waste"`
+}
+
+func EstimateCost(details *WorkloadDetails, pricing Pricing) *Cost {
+    cost := &Cost{Currency: pricing.Currency}
+    replicas := float64(details.DesiredReplicas)
+
+    for _, c := range details.Containers {
+        requested := monthlyPrice(c.CPURequest, c.MemoryRequest, pricing) * replicas
+        cost.MonthlyRequested += requested
+        if !c.HasUsage {
+            continue
+        }
+        used := monthlyPrice(min(c.CPUUsage, c.CPURequest), min(c.MemoryUsage, c.MemoryRequest), pricing) * replicas
+        cost.MonthlyUsed += used
+        cost.MonthlyWaste += requested - used
+    }
+    return cost
+}
+
+func monthlyPrice(millicores, bytes int64, pricing Pricing) float64 {
+    cores := float64(millicores) / 1000
+    gib := float64(bytes) / (1024 * 1024 * 1024)
+    return (cores*pricing.CPUCoreHour + gib*pricing.MemoryGiBHour) * hoursPerMonth
+}
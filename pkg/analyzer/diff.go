@@ -0,0 +1,269 @@
+package analyzer
+
+import (
+    "sort"
+    "strings"
+)
+
+// Diff statuses of a workload between two runs.
+const (
+    DiffAdded     = "added"
+    DiffRemoved   = "removed"
+    DiffChanged   = "changed"
+    DiffUnchanged = "unchanged"
+)
+
+// RunDiff compares the workloads of two runs, e.g. before and after a
+// right-sizing change.
+type RunDiff struct {
+    Workloads []WorkloadDiff `json:"workloads"`
+}
+
+// WorkloadDiff is what changed in one workload between two runs. Before or
+// After is nil when the workload is only in one run.
+type WorkloadDiff struct {
+    Cluster          string           `json:"cluster,omitempty"`
+    Namespace        string           `json:"namespace"`
+    Kind             string           `json:"kind"`
+    Name             string           `json:"name"`
+    Status           string           `json:"status"`
+    Before           *WorkloadDetails `json:"-"`
+    After            *WorkloadDetails `json:"-"`
+    Replicas         *Change          `json:"replicas,omitempty"`
+    Containers       []ContainerDiff  `json:"containers,omitempty"`
+    Efficiency       *EfficiencyDiff  `json:"efficiency,omitempty"`
+    NewFindings      []Finding        `json:"new_findings,omitempty"`
+    ResolvedFindings []Finding        `json:"resolved_findings,omitempty"`
+    Cost             *CostDiff        `json:"cost,omitempty"`
+}
+
+// Change is a numeric value before and after.
+type Change struct {
+    Before int64 `json:"before"`
+    After  int64 `json:"after"`
+}
+
+func (c *Change) Delta() int64 {
+    return c.After - c.Before
+}
+
+// ContainerDiff lists the resources of a container that changed. CPU is in
+// millicores and memory in bytes; unchanged values are nil.
+type ContainerDiff struct {
+    Name          string       `json:"name"`
+    Status        string       `json:"status"`
+    Image         *ImageChange `json:"image,omitempty"`
+    CPURequest    *Change      `json:"cpu_request,omitempty"`
+    CPULimit      *Change      `json:"cpu_limit,omitempty"`
+    MemoryRequest *Change      `json:"memory_request,omitempty"`
+    MemoryLimit   *Change      `json:"memory_limit,omitempty"`
+    CPUUsage      *Change      `json:"cpu_usage,omitempty"`
+    MemoryUsage   *Change      `json:"memory_usage,omitempty"`
+}
+
+type ImageChange struct {
+    Before string `json:"before"`
+    After  string `json:"after"`
+}
+
+// EfficiencyDiff holds both rates; Delta is in percentage points and only
+// set when both rates have a percentage.
+type EfficiencyDiff struct {
+    Before string   `json:"before"`
+    After  string   `json:"after"`
+    Delta  *float64 `json:"delta,omitempty"`
+}
+
+type CostDiff struct {
+    Currency        string  `json:"currency"`
+    RequestedBefore float64 `json:"monthly_requested_before"`
+    RequestedAfter  float64 `json:"monthly_requested_after"`
+    WasteBefore     float64 `json:"monthly_waste_before"`
+    WasteAfter      float64 `json:"monthly_waste_after"`
+}
+
+func (c *CostDiff) RequestedDelta() float64 {
+    return c.RequestedAfter - c.RequestedBefore
+}
+
+func (c *CostDiff) WasteDelta() float64 {
+    return c.WasteAfter - c.WasteBefore
+}
+
+// DiffRuns matches workloads by namespace, kind and name, and also by
+// cluster when either run covers more than one cluster.
+func DiffRuns(before, after []*WorkloadDetails) *RunDiff {
+    byCluster := len(clusters(before)) > 1 || len(clusters(after)) > 1
+    key := func(d *WorkloadDetails) string {
+        parts := []string{d.Namespace, d.Kind, d.Deployment}
+        if byCluster {
+            parts = append([]string{d.Cluster}, parts...)
+        }
+        return strings.Join(parts, "/")
+    }
+
+    pairs := make(map[string]*[2]*WorkloadDetails)
+    var keys []string
+    add := func(details *WorkloadDetails, i int) {
+        k := key(details)
+        pair, ok := pairs[k]
+        if !ok {
+            pair = &[2]*WorkloadDetails{}
+            pairs[k] = pair
+            keys = append(keys, k)
+        }
+        pair[i] = details
+    }
+    for _, d := range before {
+        add(d, 0)
+    }
+    for _, d := range after {
+        add(d, 1)
+    }
+
+    sort.Strings(keys)
+    diff := &RunDiff{Workloads: []WorkloadDiff{}}
+    for _, k := range keys {
+        diff.Workloads = append(diff.Workloads, DiffWorkload(pairs[k][0], pairs[k][1]))
+    }
+    return diff
+}
+
+// DiffWorkload compares two analyses of the same workload; either may be nil.
+func DiffWorkload(before, after *WorkloadDetails) WorkloadDiff {
+    ref := after
+    if ref == nil {
+        ref = before
+    }
+    d := WorkloadDiff{
+        Cluster:   ref.Cluster,
+        Namespace: ref.Namespace,
+        Kind:      ref.Kind,
+        Name:      ref.Deployment,
+        Before:    before,
+        After:     after,
+    }
+    switch {
+    case before == nil:
+        d.Status = DiffAdded
+        return d
+    case after == nil:
+        d.Status = DiffRemoved
+        return d
+    }
+
+    if before.DesiredReplicas != after.DesiredReplicas {
+        d.Replicas = &Change{Before: int64(before.DesiredReplicas), After: int64(after.DesiredReplicas)}
+    }
+    d.Containers = diffContainers(before.Containers, after.Containers)
+
+    if before.EfficiencyRate != after.EfficiencyRate {
+        d.Efficiency = &EfficiencyDiff{Before: before.EfficiencyRate, After: after.EfficiencyRate}
+        b, okBefore := EfficiencyPercent(before.EfficiencyRate)
+        a, okAfter := EfficiencyPercent(after.EfficiencyRate)
+        if okBefore && okAfter {
+            delta := a - b
+            d.Efficiency.Delta = &delta
+        }
+    }
+
+    d.NewFindings = findingsOnlyIn(after.Findings, before.Findings)
+    d.ResolvedFindings = findingsOnlyIn(before.Findings, after.Findings)
+
+    if before.Cost != nil && after.Cost != nil {
+        cost := &CostDiff{
+            Currency:        after.Cost.Currency,
+            RequestedBefore: before.Cost.MonthlyRequested,
+            RequestedAfter:  after.Cost.MonthlyRequested,
+            WasteBefore:     before.Cost.MonthlyWaste,
+            WasteAfter:      after.Cost.MonthlyWaste,
+        }
+        if cost.RequestedDelta() != 0 || cost.WasteDelta() != 0 {
+            d.Cost = cost
+        }
+    }
+
+    d.Status = DiffUnchanged
+    if d.Replicas != nil || len(d.Containers) > 0 || d.Efficiency != nil ||
+        len(d.NewFindings) > 0 || len(d.ResolvedFindings) > 0 || d.Cost != nil {
+        d.Status = DiffChanged
+    }
+    return d
+}
+
+func diffContainers(before, after []ContainerDetails) []ContainerDiff {
+    var diffs []ContainerDiff
+    seen := make(map[string]bool)
+    for _, b := range before {
+        seen[b.Name] = true
+        a, ok := findContainer(after, b.Name)
+        if !ok {
+            diffs = append(diffs, ContainerDiff{Name: b.Name, Status: DiffRemoved})
+            continue
+        }
+        cd := ContainerDiff{
+            Name:          b.Name,
+            Status:        DiffChanged,
+            CPURequest:    change(b.CPURequest, a.CPURequest),
+            CPULimit:      change(b.CPULimit, a.CPULimit),
+            MemoryRequest: change(b.MemoryRequest, a.MemoryRequest),
+            MemoryLimit:   change(b.MemoryLimit, a.MemoryLimit),
+        }
+        if b.Image != a.Image {
+            cd.Image = &ImageChange{Before: b.Image, After: a.Image}
+        }
+        if b.HasUsage && a.HasUsage {
+            cd.CPUUsage = change(b.CPUUsage, a.CPUUsage)
+            cd.MemoryUsage = change(b.MemoryUsage, a.MemoryUsage)
+        }
+        if cd.Image != nil || cd.CPURequest != nil || cd.CPULimit != nil || cd.MemoryRequest != nil ||
+            cd.MemoryLimit != nil || cd.CPUUsage != nil || cd.MemoryUsage != nil {
+            diffs = append(diffs, cd)
+        }
+    }
+    for _, a := range after {
+        if !seen[a.Name] {
+            diffs = append(diffs, ContainerDiff{Name: a.Name, Status: DiffAdded})
+        }
+    }
+    return diffs
+}
+
+func findContainer(containers []ContainerDetails, name string) (ContainerDetails, bool) {
+    for _, c := range containers {
+        if c.Name == name {
+            return c, true
+        }
+    }
+    return ContainerDetails{}, false
+}
+
+func change(before, after int64) *Change {
+    if before == after {
+        return nil
+    }
+    return &Change{Before: before, After: after}
+}
+
+// findingsOnlyIn returns the findings of a whose ID is not in b.
+func findingsOnlyIn(a, b []Finding) []Finding {
+    ids := make(map[string]bool)
+    for _, f := range b {
+        ids[f.ID] = true
+    }
+    var only []Finding
+    for _, f := range a {
+        if !ids[f.ID] {
+            only = append(only, f)
+        }
+    }
+    return only
+}
+
+func clusters(workloads []*WorkloadDetails) map[string]bool {
+    names := make(map[string]bool)
+    for _, d := range workloads {
+        names[d.Cluster] = true
+    }
+    return names
+}
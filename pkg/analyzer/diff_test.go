@@ -0,0 +1,56 @@
+package analyzer
+
+import "testing"
+
+func TestDiffRuns(t *testing.T) {
+    before := workloadIn("eu", 2, "web:1.2", "KWA007/app")
+    before.EfficiencyRate = "Low (30.0%)"
+    before.Cost = &Cost{Currency: "USD", MonthlyRequested: 20, MonthlyWaste: 14}
+
+    after := workloadIn("eu", 2, "web:1.2", "KWA008/app")
+    after.EfficiencyRate = "High (85.0%)"
+    after.Containers[0].CPURequest = 50
+    after.Cost = &Cost{Currency: "USD", MonthlyRequested: 12, MonthlyWaste: 2}
+
+    other := workloadIn("eu", 1, "web:1.2")
+    other.Deployment = "worker"
+
+    diff := DiffRuns([]*WorkloadDetails{before, other}, []*WorkloadDetails{after})
+    if len(diff.Workloads) != 2 {
+        t.Fatalf("expected 2 workloads, got %+v", diff.Workloads)
+    }
+
+    wd := diff.Workloads[0]
+    if wd.Name != "web" || wd.Status != DiffChanged {
+        t.Fatalf("unexpected diff: %+v", wd)
+    }
+    if wd.Replicas != nil {
+        t.Errorf("replicas didn't change, got %+v", wd.Replicas)
+    }
+    if len(wd.Containers) != 1 || wd.Containers[0].CPURequest == nil || wd.Containers[0].CPURequest.Delta() != -50 || wd.Containers[0].MemoryRequest != nil {
+        t.Errorf("unexpected container diff: %+v", wd.Containers)
+    }
+    if wd.Efficiency == nil || wd.Efficiency.Delta == nil || *wd.Efficiency.Delta != 55 {
+        t.Errorf("unexpected efficiency diff: %+v", wd.Efficiency)
+    }
+    if len(wd.NewFindings) != 1 || wd.NewFindings[0].ID != "KWA008/app" {
+        t.Errorf("unexpected new findings: %+v", wd.NewFindings)
+    }
+    if len(wd.ResolvedFindings) != 1 || wd.ResolvedFindings[0].ID != "KWA007/app" {
+        t.Errorf("unexpected resolved findings: %+v", wd.ResolvedFindings)
+    }
+    if wd.Cost == nil || wd.Cost.RequestedDelta() != -8 || wd.Cost.WasteDelta() != -12 {
+        t.Errorf("unexpected cost diff: %+v", wd.Cost)
+    }
+
+    if removed := diff.Workloads[1]; removed.Name != "worker" || removed.Status != DiffRemoved {
+        t.Errorf("expected worker to be removed, got %+v", removed)
+    }
+}
+
+func TestDiffRunsUnchanged(t *testing.T) {
+    diff := DiffRuns([]*WorkloadDetails{workloadIn("eu", 2, "web:1.2")}, []*WorkloadDetails{workloadIn("us", 2, "web:1.2")})
+    if len(diff.Workloads) != 1 || diff.Workloads[0].Status != DiffUnchanged {
+        t.Errorf("expected one unchanged workload matched across clusters, got %+v", diff.Workloads)
+    }
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Severity ranks a rule finding. The zero value is not a valid severity.
+type Severity string
+
+const (
+    SeverityInfo     Severity = "info"
+    SeverityLow      Severity = "low"
+    SeverityMedium   Severity = "medium"
+    SeverityHigh     Severity = "high"
+    SeverityCritical Severity = "critical"
+)
+
+var severityRank = map[Severity]int{
+    SeverityInfo:     1,
+    SeverityLow:      2,
+    SeverityMedium:   3,
+    SeverityHigh:     4,
+    SeverityCritical: 5,
+}
+
+// Rank orders severities from info (1) to critical (5); unknown values are 0.
+func (s Severity) Rank() int {
+    return severityRank[s]
+}
+
+// AtLeast reports whether s is as severe as threshold or more.
+func (s Severity) AtLeast(threshold Severity) bool {
+    return s.Rank() >= threshold.Rank()
+}
+
+func ParseSeverity(value string) (Severity, error) {
+    s := Severity(strings.ToLower(strings.TrimSpace(value)))
+    if s.Rank() == 0 {
+        return "", fmt.Errorf("unknown severity %q (want info, low, medium, high or critical)", value)
+    }
+    return s, nil
+}
+
+// Finding is a deterministic issue found by a rule. ID is stable across runs
+// and clusters so findings can be compared, baselined and tracked.
+type Finding struct {
+    ID        string   `json:"id"`
+    RuleID    string   `json:"rule_id"`
+    Severity  Severity `json:"severity"`
+    Container string   `json:"container,omitempty"`
+    Message   string   `json:"message"`
+}
@@ -0,0 +1,33 @@
+package analyzer
+
+import "testing"
+
+func TestSeverityAtLeast(t *testing.T) {
+    tests := []struct {
+        severity  Severity
+        threshold Severity
+        want      bool
+    }{
+        {SeverityCritical, SeverityHigh, true},
+        {SeverityHigh, SeverityHigh, true},
+        {SeverityMedium, SeverityHigh, false},
+        {SeverityInfo, SeverityInfo, true},
+        {SeverityInfo, SeverityLow, false},
+        {SeverityLow, SeverityInfo, true},
+        {Severity("bogus"), SeverityInfo, false},
+    }
+    for _, tt := range tests {
+        if got := tt.severity.AtLeast(tt.threshold); got != tt.want {
+            t.Errorf("%q.AtLeast(%q) = %v, want %v", tt.severity, tt.threshold, got, tt.want)
+        }
+    }
+}
+
+func TestParseSeverity(t *testing.T) {
+    if s, err := ParseSeverity(" High "); err != nil || s != SeverityHigh {
+        t.Errorf("expected high, got %q, %v", s, err)
+    }
+    if _, err := ParseSeverity("urgent"); err == nil {
+        t.Error("expected an unknown severity to be an error")
+    }
+}
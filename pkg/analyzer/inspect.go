@@ -0,0 +1,221 @@
+package analyzer
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/types"
+    "k8s.io/client-go/kubernetes"
+    metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// Event is a Kubernetes event about a workload or one of its pods.
+type Event struct {
+    Time    time.Time `json:"time"`
+    Type    string    `json:"type"`
+    Reason  string    `json:"reason"`
+    Object  string    `json:"object"`
+    Message string    `json:"message"`
+    Count   int32     `json:"count"`
+}
+
+// WorkloadEvents returns the most recent events about a workload and the
+// objects it owns (ReplicaSets, pods), oldest first. Ownership follows owner
+// references, so workloads whose names share a prefix don't mix.
+func WorkloadEvents(ctx context.Context, client kubernetes.Interface, namespace, workloadType, name string, limit int) ([]Event, error) {
+    w, err := getWorkload(ctx, client, namespace, workloadType, name)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get %s: %w", workloadType, err)
+    }
+    owned, err := ownedObjects(ctx, client, namespace, w)
+    if err != nil {
+        return nil, err
+    }
+    var list *corev1.EventList
+    err = withRetry(ctx, func(ctx context.Context) error {
+        var err error
+        list, err = client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+        return err
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to list events: %w", err)
+    }
+
+    var events []Event
+    for _, e := range list.Items {
+        if !owned[e.InvolvedObject.UID] {
+            continue
+        }
+        t := e.LastTimestamp.Time
+        if t.IsZero() {
+            t = e.EventTime.Time
+        }
+        events = append(events, Event{
+            Time:    t,
+            Type:    e.Type,
+            Reason:  e.Reason,
+            Object:  strings.ToLower(e.InvolvedObject.Kind) + "/" + e.InvolvedObject.Name,
+            Message: e.Message,
+            Count:   e.Count,
+        })
+    }
+    sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+    if limit > 0 && len(events) > limit {
+        events = events[len(events)-limit:]
+    }
+    return events, nil
+}
+
+// ownedObjects returns the UIDs of a workload, the ReplicaSets it owns when
+// it is a Deployment, and the pods they own.
+func ownedObjects(ctx context.Context, client kubernetes.Interface, namespace string, w *workload) (map[types.UID]bool, error) {
+    owned := make(map[types.UID]bool)
+    if w.UID != "" {
+        owned[w.UID] = true
+    }
+    selector := metav1.FormatLabelSelector(w.Selector)
+
+    if w.Kind == "Deployment" {
+        var replicaSets *appsv1.ReplicaSetList
+        err := withRetry(ctx, func(ctx context.Context) error {
+            var err error
+            replicaSets, err = client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+            return err
+        })
+        if err != nil {
+            return nil, fmt.Errorf("failed to list replicasets: %w", err)
+        }
+        for _, rs := range replicaSets.Items {
+            if ownedBy(rs.OwnerReferences, owned) {
+                owned[rs.UID] = true
+            }
+        }
+    }
+
+    var pods *corev1.PodList
+    err := withRetry(ctx, func(ctx context.Context) error {
+        var err error
+        pods, err = client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+        return err
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to list pods: %w", err)
+    }
+    for _, pod := range pods.Items {
+        if ownedBy(pod.OwnerReferences, owned) {
+            owned[pod.UID] = true
+        }
+    }
+    return owned, nil
+}
+
+func ownedBy(refs []metav1.OwnerReference, owners map[types.UID]bool) bool {
+    for _, ref := range refs {
+        if owners[ref.UID] {
+            return true
+        }
+    }
+    return false
+}
+
+// LogsTail returns the last lines of a container's logs in one of the
+// workload's pods: the named pod, or the first running one.
+func LogsTail(ctx context.Context, client kubernetes.Interface, namespace, workloadType, name, pod, container string, lines int64) (string, error) {
+    pods, err := workloadPods(ctx, client, namespace, workloadType, name)
+    if err != nil {
+        return "", err
+    }
+    var target *corev1.Pod
+    for i := range pods {
+        p := &pods[i]
+        if (pod != "" && p.Name == pod) || (pod == "" && p.Status.Phase == corev1.PodRunning) {
+            target = p
+            break
+        }
+    }
+    if target == nil && pod == "" && len(pods) > 0 {
+        target = &pods[0]
+    }
+    if target == nil {
+        return "", fmt.Errorf("no pod %q of %s %s", pod, workloadType, name)
+    }
+
+    data, err := client.CoreV1().Pods(namespace).GetLogs(target.Name, &corev1.PodLogOptions{
+        Container: container,
+        TailLines: &lines,
+    }).DoRaw(ctx)
+    if err != nil {
+        return "", fmt.Errorf("failed to get logs of %s: %w", target.Name, err)
+    }
+    return fmt.Sprintf("pod/%s:\n%s", target.Name, data), nil
+}
+
+// PodUsage is the live state and usage of one pod. Usage is empty when
+// metrics are disabled or unavailable.
+type PodUsage struct {
+    Pod       string            `json:"pod"`
+    Phase     string            `json:"phase"`
+    Node      string            `json:"node"`
+    Restarts  map[string]int32  `json:"restarts"`
+    LastState map[string]string `json:"last_termination,omitempty"`
+    Usage     map[string]Usage  `json:"usage,omitempty"`
+}
+
+// WorkloadPodUsage breaks a workload's usage down per pod, with restarts and
+// the reason each container last terminated, e.g. OOMKilled.
+func WorkloadPodUsage(ctx context.Context, client kubernetes.Interface, metricsClient metricsv.Interface, namespace, workloadType, name string) ([]PodUsage, error) {
+    pods, err := workloadPods(ctx, client, namespace, workloadType, name)
+    if err != nil {
+        return nil, err
+    }
+
+    var result []PodUsage
+    for _, pod := range pods {
+        u := PodUsage{Pod: pod.Name, Phase: string(pod.Status.Phase), Node: pod.Spec.NodeName, Restarts: make(map[string]int32)}
+        for _, status := range pod.Status.ContainerStatuses {
+            u.Restarts[status.Name] = status.RestartCount
+            if t := status.LastTerminationState.Terminated; t != nil {
+                if u.LastState == nil {
+                    u.LastState = make(map[string]string)
+                }
+                u.LastState[status.Name] = fmt.Sprintf("%s (exit code %d) at %s", t.Reason, t.ExitCode, t.FinishedAt.Format(time.RFC3339))
+            }
+        }
+        if metricsClient != nil {
+            m, err := metricsClient.MetricsV1beta1().PodMetricses(namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+            if err == nil {
+                u.Usage = make(map[string]Usage)
+                for _, c := range m.Containers {
+                    u.Usage[c.Name] = Usage{CPU: c.Usage.Cpu().MilliValue(), Memory: c.Usage.Memory().Value()}
+                }
+            }
+        }
+        result = append(result, u)
+    }
+    return result, nil
+}
+
+func workloadPods(ctx context.Context, client kubernetes.Interface, namespace, workloadType, name string) ([]corev1.Pod, error) {
+    w, err := getWorkload(ctx, client, namespace, workloadType, name)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get %s: %w", workloadType, err)
+    }
+    var pods *corev1.PodList
+    err = withRetry(ctx, func(ctx context.Context) error {
+        var err error
+        pods, err = client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+            LabelSelector: metav1.FormatLabelSelector(w.Selector),
+        })
+        return err
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to list pods: %w", err)
+    }
+    return pods.Items, nil
+}
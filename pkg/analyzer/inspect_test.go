@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+    "context"
+    "strings"
+    "testing"
+    "time"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/apimachinery/pkg/types"
+    "k8s.io/client-go/kubernetes/fake"
+)
+
+func ownerRefs(kind, name string) []metav1.OwnerReference {
+    return []metav1.OwnerReference{{Kind: kind, Name: name, UID: types.UID(name + "-uid")}}
+}
+
+func event(name string, kind, object string, minute int) *corev1.Event {
+    return &corev1.Event{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+        InvolvedObject: corev1.ObjectReference{
+            Kind: kind,
+            Name: object,
+            UID:  types.UID(object + "-uid"),
+        },
+        Reason:        "Test",
+        Message:       name,
+        LastTimestamp: metav1.NewTime(time.Date(2026, 1, 1, 0, minute, 0, 0, time.UTC)),
+    }
+}
+
+// siblingObjects has a deployment web and a deployment web-frontend whose
+// names share a prefix and whose ReplicaSets and pods match web's selector.
+func siblingObjects() []runtime.Object {
+    web := deployment("web", "100m", "100Mi")
+    web.UID = "web-uid"
+    frontend := deployment("web-frontend", "100m", "100Mi")
+    frontend.UID = "web-frontend-uid"
+    frontendLabels := map[string]string{"app": "web", "tier": "frontend"}
+    frontend.Spec.Selector = &metav1.LabelSelector{MatchLabels: frontendLabels}
+    frontend.Spec.Template.Labels = frontendLabels
+
+    replicaSet := func(name, owner string, labels map[string]string) *appsv1.ReplicaSet {
+        return &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+            Name: name, Namespace: testNamespace, UID: types.UID(name + "-uid"), Labels: labels, OwnerReferences: ownerRefs("Deployment", owner),
+        }}
+    }
+    ownedPod := func(name, owner string, labels map[string]string) *corev1.Pod {
+        p := pod(name)
+        p.UID = types.UID(name + "-uid")
+        p.Labels = labels
+        p.OwnerReferences = ownerRefs("ReplicaSet", owner)
+        return p
+    }
+
+    return []runtime.Object{
+        web, frontend,
+        replicaSet("web-abc", "web", testLabels),
+        replicaSet("web-frontend-xyz", "web-frontend", frontendLabels),
+        ownedPod("web-abc-1", "web-abc", testLabels),
+        ownedPod("web-frontend-xyz-1", "web-frontend-xyz", frontendLabels),
+        event("scaled", "Deployment", "web", 1),
+        event("created", "ReplicaSet", "web-abc", 2),
+        event("oom", "Pod", "web-abc-1", 3),
+        event("frontend-scaled", "Deployment", "web-frontend", 4),
+        event("frontend-created", "ReplicaSet", "web-frontend-xyz", 5),
+        event("frontend-oom", "Pod", "web-frontend-xyz-1", 6),
+    }
+}
+
+func TestWorkloadEvents(t *testing.T) {
+    client := fake.NewSimpleClientset(siblingObjects()...)
+
+    events, err := WorkloadEvents(context.Background(), client, testNamespace, "deployment", "web", 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var got []string
+    for _, e := range events {
+        got = append(got, e.Object+" "+e.Message)
+    }
+    want := "deployment/web scaled, replicaset/web-abc created, pod/web-abc-1 oom"
+    if strings.Join(got, ", ") != want {
+        t.Errorf("expected only web's own events:\n got %s\nwant %s", strings.Join(got, ", "), want)
+    }
+
+    events, err = WorkloadEvents(context.Background(), client, testNamespace, "deployment", "web", 1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(events) != 1 || events[0].Message != "oom" {
+        t.Errorf("expected the limit to keep the most recent event, got %+v", events)
+    }
+
+    events, err = WorkloadEvents(context.Background(), client, testNamespace, "deployment", "web-frontend", 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(events) != 3 || events[0].Message != "frontend-scaled" {
+        t.Errorf("expected web-frontend's 3 events, got %+v", events)
+    }
+}
+
+func TestLogsTail(t *testing.T) {
+    client := fake.NewSimpleClientset(siblingObjects()...)
+
+    logs, err := LogsTail(context.Background(), client, testNamespace, "deployment", "web-frontend", "", "app", 10)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // The fake clientset always answers "fake logs"
+    if logs != "pod/web-frontend-xyz-1:\nfake logs" {
+        t.Errorf("unexpected logs: %q", logs)
+    }
+
+    if _, err := LogsTail(context.Background(), client, testNamespace, "deployment", "web-frontend", "other-pod", "app", 10); err == nil {
+        t.Error("expected a pod of another workload to be an error")
+    }
+}
@@ -3,32 +3,81 @@ package analyzer
 import (
     "context"
     "fmt"
+    "log/slog"
+    autoscalingv2 "k8s.io/api/autoscaling/v2"
+    corev1 "k8s.io/api/core/v1"
     metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
     "k8s.io/client-go/kubernetes"
-    "k8s.io/client-go/rest"
+    metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
     metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+    "k8s-workload-analyzer/pkg/logging"
+    "k8s-workload-analyzer/pkg/tracing"
 )
 
-func GetMetrics(client *kubernetes.Clientset, namespace, name string, config *rest.Config) (map[string]string, error) {
-    // Create metrics client
-    metricsClient, err := metricsv.NewForConfig(config)
-    if err != nil {
-        return nil, fmt.Errorf("failed to create metrics client: %v", err)
+// EfficiencyBands splits the efficiency rate into Low, Medium and High.
+// Values are percentages of requests actually used.
+type EfficiencyBands struct {
+    Low  float64
+    High float64
+}
+
+// DefaultEfficiencyBands label the rates analysis returns; callers with
+// configured bands relabel them with Rate.
+var DefaultEfficiencyBands = EfficiencyBands{Low: 50, High: 80}
+
+// Level names the band a percentage falls in.
+func (b EfficiencyBands) Level(percent float64) string {
+    switch {
+    case percent < b.Low:
+        return "Low"
+    case percent < b.High:
+        return "Medium"
     }
+    return "High"
+}
 
-    // Get deployment to find pod selector
-    deployment, err := client.AppsV1().Deployments(namespace).Get(context.Background(), name, metav1.GetOptions{})
+// Rate formats a percentage as an efficiency rate, e.g. "High (85.2%)".
+func (b EfficiencyBands) Rate(percent float64) string {
+    return fmt.Sprintf("%s (%.1f%%)", b.Level(percent), percent)
+}
+
+// containerUsage is the average usage of one container across sampled pods.
+type containerUsage struct {
+    CPU    int64 // millicores
+    Memory int64 // bytes
+}
+
+func GetMetrics(ctx context.Context, client kubernetes.Interface, metricsClient metricsv.Interface, namespace, workloadType, name string) (map[string]string, error) {
+    // Get workload to find pod selector
+    w, err := getWorkload(ctx, client, namespace, workloadType, name)
     if err != nil {
-        return nil, fmt.Errorf("failed to get deployment: %v", err)
+        return nil, fmt.Errorf("failed to get %s: %w", workloadType, err)
     }
 
-    // Get pods using deployment's selector
-    selector := metav1.FormatLabelSelector(deployment.Spec.Selector)
-    pods, err := client.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
-        LabelSelector: selector,
+    metrics, _, err := workloadMetrics(ctx, client, metricsClient, namespace, w)
+    return metrics, err
+}
+
+func workloadMetrics(ctx context.Context, client kubernetes.Interface, metricsClient metricsv.Interface, namespace string, w *workload) (map[string]string, map[string]containerUsage, error) {
+    // Get pods using workload's selector
+    selector := metav1.FormatLabelSelector(w.Selector)
+    var pods *corev1.PodList
+    done := logging.Phase(ctx, "pods", "namespace", namespace, "selector", selector)
+    spanCtx, span := tracing.Start(ctx, "pods.list", tracing.Namespace.String(namespace), tracing.Kind.String(w.Type()), tracing.Workload.String(w.Name))
+    err := withRetry(spanCtx, func(ctx context.Context) error {
+        var err error
+        pods, err = client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+            LabelSelector: selector,
+        })
+        return err
     })
+    if err == nil {
+        span.SetAttributes(tracing.Pods.Int(len(pods.Items)))
+    }
+    tracing.End(span, err)
+    done()
     if err != nil {
-        return nil, fmt.Errorf("failed to list pods: %v", err)
+        return nil, nil, fmt.Errorf("failed to list pods: %w", err)
     }
 
     if len(pods.Items) == 0 {
@@ -36,18 +85,36 @@ func GetMetrics(client *kubernetes.Clientset, namespace, name string, config *re
             "cpu_utilization": "N/A",
             "memory_utilization": "N/A",
             "replica_count": "0",
-        }, nil
+            "efficiency_rate": "N/A (No running pods)",
+        }, nil, nil
     }
 
     var totalCPU, totalMemory int64
     podCount := 0
+    perContainer := make(map[string]containerUsage)
+
+    // A nil metrics client means usage collection is disabled
+    sampled := pods.Items
+    if metricsClient == nil {
+        sampled = nil
+    }
 
     // Get metrics for each pod
-    for _, pod := range pods.Items {
-        fmt.Printf("Getting metrics for pod: %s\n", pod.Name)
-        podMetrics, err := metricsClient.MetricsV1beta1().PodMetricses(namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+    defer logging.Phase(ctx, "metrics", "namespace", namespace, "pods", len(sampled))()
+    for _, pod := range sampled {
+        var podMetrics *metricsv1beta1.PodMetrics
+        spanCtx, span := tracing.Start(ctx, "metrics.get", tracing.Namespace.String(namespace), tracing.Pod.String(pod.Name))
+        err := withRetry(spanCtx, func(ctx context.Context) error {
+            var err error
+            podMetrics, err = metricsClient.MetricsV1beta1().PodMetricses(namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+            return err
+        })
+        tracing.End(span, err)
+        if ctx.Err() != nil {
+            return nil, nil, ctx.Err()
+        }
         if err != nil {
-            fmt.Printf("Error getting metrics for pod %s: %v\n", pod.Name, err)
+            slog.WarnContext(ctx, "failed to get pod metrics", "namespace", namespace, "pod", pod.Name, "error", err)
             continue
         }
 
@@ -58,6 +125,11 @@ func GetMetrics(client *kubernetes.Clientset, namespace, name string, config *re
             
             totalCPU += cpuQuantity.MilliValue()
             totalMemory += memQuantity.Value()
+
+            usage := perContainer[container.Name]
+            usage.CPU += cpuQuantity.MilliValue()
+            usage.Memory += memQuantity.Value()
+            perContainer[container.Name] = usage
         }
         podCount++
     }
@@ -69,20 +141,18 @@ func GetMetrics(client *kubernetes.Clientset, namespace, name string, config *re
     if podCount > 0 {
         avgCPU := totalCPU / int64(podCount)
         avgMemory := totalMemory / int64(podCount)
+        for name, usage := range perContainer {
+            perContainer[name] = containerUsage{CPU: usage.CPU / int64(podCount), Memory: usage.Memory / int64(podCount)}
+        }
         
         metrics["cpu_utilization"] = fmt.Sprintf("%dm", avgCPU)
         
-        // Format memory in Gi if over 1000Mi
         memoryMi := avgMemory / (1024 * 1024)
-        if memoryMi >= 1000 {
-            metrics["memory_utilization"] = fmt.Sprintf("%.2fGi", float64(memoryMi)/1024.0)
-        } else {
-            metrics["memory_utilization"] = fmt.Sprintf("%dMi", memoryMi)
-        }
+        metrics["memory_utilization"] = formatMemory(avgMemory)
 
         // Calculate efficiency rate based on resource usage vs requests
-        if len(deployment.Spec.Template.Spec.Containers) > 0 {
-            container := deployment.Spec.Template.Spec.Containers[0]
+        if len(w.PodSpec.Containers) > 0 {
+            container := w.PodSpec.Containers[0]
             
             // Get CPU request
             cpuRequest := container.Resources.Requests["cpu"]
@@ -95,32 +165,54 @@ func GetMetrics(client *kubernetes.Clientset, namespace, name string, config *re
                 cpuEfficiency := float64(avgCPU) / float64(cpuRequestValue) * 100
                 memEfficiency := float64(memoryMi) / float64(memRequestValue) * 100
                 avgEfficiency := (cpuEfficiency + memEfficiency) / 2
-
-                var efficiencyLevel string
-                if avgEfficiency < 50 {
-                    efficiencyLevel = "Low"
-                } else if avgEfficiency < 80 {
-                    efficiencyLevel = "Medium"
-                } else {
-                    efficiencyLevel = "High"
-                }
-                
-                metrics["efficiency_rate"] = fmt.Sprintf("%s (%.1f%%)", efficiencyLevel, avgEfficiency)
+                metrics["efficiency_rate"] = DefaultEfficiencyBands.Rate(avgEfficiency)
             }
         } else {
             metrics["efficiency_rate"] = "N/A (No containers)"
         }
         
         metrics["replica_count"] = fmt.Sprintf("%d", podCount)
+    } else if metricsClient == nil {
+        metrics["efficiency_rate"] = "N/A (Metrics disabled)"
+        metrics["replica_count"] = fmt.Sprintf("%d", len(pods.Items))
+        perContainer = nil
     } else {
-        metrics["efficiency_rate"] = "N/A (No running pods)"
+        // Pods are running but no metrics could be fetched for any of them
+        metrics["efficiency_rate"] = "N/A (No metrics)"
+        metrics["replica_count"] = fmt.Sprintf("%d", len(pods.Items))
+        perContainer = nil
     }
 
     // Get HPA info if available
-    hpa, err := client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(context.Background(), name, metav1.GetOptions{})
+    var hpa *autoscalingv2.HorizontalPodAutoscaler
+    err = withRetry(ctx, func(ctx context.Context) error {
+        var err error
+        hpa, err = client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, w.Name, metav1.GetOptions{})
+        return err
+    })
     if err == nil {
         metrics["replica_count"] = fmt.Sprintf("%d/%d", hpa.Status.CurrentReplicas, hpa.Status.DesiredReplicas)
     }
 
-    return metrics, nil
-}
\ No newline at end of file
+    return metrics, perContainer, nil
+}
+
+// formatMemory renders bytes in Mi, switching to Gi from 1000Mi up.
+func formatMemory(bytes int64) string {
+    memoryMi := bytes / (1024 * 1024)
+    if memoryMi >= 1000 {
+        return fmt.Sprintf("%.2fGi", float64(memoryMi)/1024.0)
+    }
+    return fmt.Sprintf("%dMi", memoryMi)
+}
+
+// EfficiencyPercent extracts the percentage from an efficiency rate such as
+// "High (85.2%)". It returns false for rates without one, e.g. "N/A".
+func EfficiencyPercent(rate string) (float64, bool) {
+    var level string
+    var percent float64
+    if _, err := fmt.Sscanf(rate, "%s (%f%%)", &level, &percent); err != nil {
+        return 0, false
+    }
+    return percent, true
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import "math"
+
+// Minimum requests we recommend, so idle containers still get scheduled
+// with some room to start up.
+const (
+    minCPURequest    = 10               // millicores
+    minMemoryRequest = 16 * 1024 * 1024 // bytes
+)
+
+// ResourceRecommendation right-sizes one container's requests from its
+// observed usage plus headroom. Limits are only raised, never lowered.
+type ResourceRecommendation struct {
+    Cluster                  string  `json:"cluster"`
+    Namespace                string  `json:"namespace"`
+    Kind                     string  `json:"kind"`
+    Name                     string  `json:"name"`
+    Container                string  `json:"container"`
+    CPURequest               int64   `json:"cpu_request_millicores"`
+    RecommendedCPURequest    int64   `json:"recommended_cpu_request_millicores"`
+    MemoryRequest            int64   `json:"memory_request_bytes"`
+    RecommendedMemoryRequest int64   `json:"recommended_memory_request_bytes"`
+    MemoryLimit              int64   `json:"memory_limit_bytes"`
+    RecommendedMemoryLimit   int64   `json:"recommended_memory_limit_bytes"`
+    MonthlySavings           float64 `json:"monthly_savings"`
+    Currency                 string  `json:"currency"`
+}
+
+// RecommendResources returns a recommendation for every container with usage
+// data. headroom is the percentage added on top of observed usage.
+func RecommendResources(details *WorkloadDetails, headroom float64, pricing Pricing) []ResourceRecommendation {
+    var recommendations []ResourceRecommendation
+    factor := 1 + headroom/100
+    replicas := float64(details.DesiredReplicas)
+
+    for _, c := range details.Containers {
+        if !c.HasUsage {
+            continue
+        }
+
+        // Round CPU up to 5m and memory up to whole Mi
+        cpu := max(int64(math.Ceil(float64(c.CPUUsage)*factor/5))*5, minCPURequest)
+        memory := max(int64(math.Ceil(float64(c.MemoryUsage)*factor/(1024*1024)))*1024*1024, minMemoryRequest)
+
+        limit := c.MemoryLimit
+        if limit > 0 && limit < memory {
+            limit = memory
+        }
+
+        current := monthlyPrice(c.CPURequest, c.MemoryRequest, pricing) * replicas
+        recommended := monthlyPrice(cpu, memory, pricing) * replicas
+
+        recommendations = append(recommendations, ResourceRecommendation{
+            Cluster:                  details.Cluster,
+            Namespace:                details.Namespace,
+            Kind:                     details.Kind,
+            Name:                     details.Deployment,
+            Container:                c.Name,
+            CPURequest:               c.CPURequest,
+            RecommendedCPURequest:    cpu,
+            MemoryRequest:            c.MemoryRequest,
+            RecommendedMemoryRequest: memory,
+            MemoryLimit:              c.MemoryLimit,
+            RecommendedMemoryLimit:   limit,
+            MonthlySavings:           current - recommended,
+            Currency:                 pricing.Currency,
+        })
+    }
+    return recommendations
+}
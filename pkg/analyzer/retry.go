@@ -0,0 +1,31 @@
+package analyzer
+
+import (
+    "context"
+    "time"
+
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    "k8s-workload-analyzer/pkg/retry"
+)
+
+// withRetry retries Kubernetes API calls that failed with a transient error,
+// honoring the server's suggested delay when there is one.
+func withRetry(ctx context.Context, fn func(ctx context.Context) error) error {
+    return retry.Do(ctx, retry.DefaultPolicy, func(ctx context.Context) error {
+        err := fn(ctx)
+        if err == nil || !isTransient(err) {
+            return err
+        }
+        seconds, _ := apierrors.SuggestsClientDelay(err)
+        return retry.Retryable(err, time.Duration(seconds)*time.Second)
+    })
+}
+
+func isTransient(err error) bool {
+    return apierrors.IsTooManyRequests(err) ||
+        apierrors.IsServerTimeout(err) ||
+        apierrors.IsTimeout(err) ||
+        apierrors.IsInternalError(err) ||
+        apierrors.IsServiceUnavailable(err) ||
+        apierrors.IsUnexpectedServerError(err)
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+    "context"
+    "testing"
+
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/codes"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    "go.opentelemetry.io/otel/sdk/trace/tracetest"
+    "k8s.io/client-go/kubernetes/fake"
+    "k8s-workload-analyzer/pkg/tracing"
+)
+
+func TestAnalyzeWorkloadSpans(t *testing.T) {
+    exporter := tracetest.NewInMemoryExporter()
+    previous := otel.GetTracerProvider()
+    otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+    defer otel.SetTracerProvider(previous)
+
+    client := fake.NewSimpleClientset(deployment("web", "100m", "100Mi"), pod("web-1"), pod("web-2"))
+    metricsClient := newMetricsClient(t, podMetrics("web-1", "50m", "50Mi"))
+
+    ctx, root := tracing.Start(context.Background(), "test")
+    if _, err := AnalyzeWorkload(ctx, client, metricsClient, testNamespace, "deployment", "web"); err != nil {
+        t.Fatal(err)
+    }
+    root.End()
+
+    spans := exporter.GetSpans()
+    counts := make(map[string]int)
+    for _, span := range spans {
+        counts[span.Name]++
+        if span.Name != "test" && span.Parent.TraceID() != root.SpanContext().TraceID() {
+            t.Errorf("span %s is not part of the trace", span.Name)
+        }
+        attrs := make(map[string]string)
+        for _, kv := range span.Attributes {
+            attrs[string(kv.Key)] = kv.Value.Emit()
+        }
+        switch span.Name {
+        case "workload.lookup":
+            if attrs[string(tracing.Namespace)] != testNamespace || attrs[string(tracing.Kind)] != "deployment" || attrs[string(tracing.Workload)] != "web" {
+                t.Errorf("unexpected lookup attributes: %v", attrs)
+            }
+        case "pods.list":
+            if attrs[string(tracing.Pods)] != "2" {
+                t.Errorf("unexpected pod listing attributes: %v", attrs)
+            }
+        case "metrics.get":
+            // web-2 has no metrics
+            wantError := attrs[string(tracing.Pod)] == "web-2"
+            if (span.Status.Code == codes.Error) != wantError {
+                t.Errorf("unexpected status of metrics.get for %s: %v", attrs[string(tracing.Pod)], span.Status)
+            }
+        }
+    }
+    for name, want := range map[string]int{"workload.lookup": 1, "pods.list": 1, "metrics.get": 2} {
+        if counts[name] != want {
+            t.Errorf("expected %d %s spans, got %d", want, name, counts[name])
+        }
+    }
+}
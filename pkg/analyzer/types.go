@@ -1,22 +1,116 @@
 package analyzer
 
+import (
+    "encoding/json"
+    "fmt"
+    "sort"
+
+    corev1 "k8s.io/api/core/v1"
+)
+
 type WorkloadDetails struct {
-    Namespace         string
-    Deployment        string
-    Kind             string
-    MainContainer     string
-    PodQoSClass      string
-    ReplicaCount     string
-    CPUUtilization   string
-    MemoryUtilization string
-    EfficiencyRate   string
-    ReliabilityRisk  string
-    ContainerCount   string    // Add this field
-    NetworkTraffic   string    // Add this field
-    OpsaniFlags      string    // Add this field
-    Analysis         string
-    Opportunities    []string
-    Cautions        []string
-    Blockers        []string
-    Recommendations []string
-}
\ No newline at end of file
+    Cluster           string            `json:"cluster"`
+    Namespace         string            `json:"namespace"`
+    Deployment        string            `json:"name"`
+    Kind             string             `json:"kind"`
+    MainContainer     string            `json:"main_container"`
+    PodQoSClass      string             `json:"pod_qos_class"`
+    ReplicaCount     string             `json:"replica_count"`
+    DesiredReplicas  int32              `json:"desired_replicas"`
+    CPUUtilization   string             `json:"cpu_utilization"`
+    MemoryUtilization string            `json:"memory_utilization"`
+    EfficiencyRate   string             `json:"efficiency_rate"`
+    ReliabilityRisk  string             `json:"reliability_risk,omitempty"`
+    ContainerCount   string             `json:"container_count"`    // Add this field
+    NetworkTraffic   string             `json:"network_traffic,omitempty"`    // Add this field
+    OpsaniFlags      string             `json:"opsani_flags,omitempty"`    // Add this field
+    Containers       []ContainerDetails `json:"containers"`
+    Findings         []Finding          `json:"findings"`
+    SkippedRules     map[string]string  `json:"skipped_rules,omitempty"`
+    Cost             *Cost              `json:"cost,omitempty"`
+    Source           *Source            `json:"source,omitempty"`
+    Analysis         string             `json:"analysis,omitempty"`
+    Opportunities    []Claim            `json:"opportunities,omitempty"`
+    Cautions        []Claim             `json:"cautions,omitempty"`
+    DroppedClaims   int                 `json:"dropped_claims,omitempty"`
+    Blockers        []string            `json:"blockers,omitempty"`
+    Recommendations []string            `json:"recommendations,omitempty"`
+    PromptVersion   string              `json:"prompt_version,omitempty"`
+    AIFocus         string              `json:"ai_focus,omitempty"`
+
+    // PodSpec is the workload's pod template, kept for rule evaluation.
+    PodSpec *corev1.PodSpec `json:"-"`
+}
+
+// Claim is an AI opportunity or caution with the findings and metric values
+// it cites. Unverified claims cite nothing that could be checked against the
+// analysis.
+type Claim struct {
+    Text       string            `json:"text"`
+    Findings   []string          `json:"findings,omitempty"`
+    Metrics    map[string]string `json:"metrics,omitempty"`
+    Unverified bool              `json:"unverified,omitempty"`
+}
+
+func (c Claim) String() string {
+    return c.Text
+}
+
+// Citations lists the cited finding IDs, then the cited metrics as
+// name=value sorted by name.
+func (c Claim) Citations() []string {
+    citations := append([]string{}, c.Findings...)
+    names := make([]string, 0, len(c.Metrics))
+    for name := range c.Metrics {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        citations = append(citations, fmt.Sprintf("%s=%s", name, c.Metrics[name]))
+    }
+    return citations
+}
+
+// UnmarshalJSON also accepts the plain strings of results saved before
+// claims carried citations.
+func (c *Claim) UnmarshalJSON(data []byte) error {
+    var text string
+    if err := json.Unmarshal(data, &text); err == nil {
+        *c = Claim{Text: text, Unverified: true}
+        return nil
+    }
+    type plain Claim
+    return json.Unmarshal(data, (*plain)(c))
+}
+
+// Source locates a workload analyzed from a manifest file. Line is the
+// 1-based line where the workload's YAML document starts; Containers maps
+// container names to the line of their entry in the pod template.
+type Source struct {
+    File       string         `json:"file"`
+    Line       int            `json:"line"`
+    Containers map[string]int `json:"containers,omitempty"`
+}
+
+// LineOf returns the line a finding for container should point at, falling
+// back to the start of the document.
+func (s *Source) LineOf(container string) int {
+    if line, ok := s.Containers[container]; ok {
+        return line
+    }
+    return s.Line
+}
+
+// ContainerDetails holds the configured resources of one container and its
+// average usage across running pods. CPU is in millicores, memory in bytes.
+type ContainerDetails struct {
+    Name          string `json:"name"`
+    Image         string `json:"image"`
+    CPURequest    int64  `json:"cpu_request_millicores"`
+    CPULimit      int64  `json:"cpu_limit_millicores"`
+    MemoryRequest int64  `json:"memory_request_bytes"`
+    MemoryLimit   int64  `json:"memory_limit_bytes"`
+    HasUsage      bool   `json:"has_usage"`
+    CPUUsage      int64  `json:"cpu_usage_millicores"`
+    MemoryUsage   int64  `json:"memory_usage_bytes"`
+}
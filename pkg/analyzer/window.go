@@ -0,0 +1,119 @@
+package analyzer
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "time"
+
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// UsageSample is the average usage of each container across a workload's
+// pods at one point in time. CPU is in millicores, memory in bytes.
+type UsageSample struct {
+    Time       time.Time
+    Pods       int
+    Containers map[string]Usage
+}
+
+type Usage struct {
+    CPU    int64 `json:"cpu_millicores"`
+    Memory int64 `json:"memory_bytes"`
+}
+
+// SampleUsage reads the current usage of the pods matching selector in one
+// metrics API call. It doesn't retry; callers sample again soon anyway.
+func SampleUsage(ctx context.Context, metricsClient metricsv.Interface, namespace, selector string) (UsageSample, error) {
+    sample := UsageSample{Time: time.Now(), Containers: make(map[string]Usage)}
+    list, err := metricsClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+    if err != nil {
+        return sample, fmt.Errorf("failed to get pod metrics: %w", err)
+    }
+
+    for _, pod := range list.Items {
+        for _, c := range pod.Containers {
+            usage := sample.Containers[c.Name]
+            usage.CPU += c.Usage.Cpu().MilliValue()
+            usage.Memory += c.Usage.Memory().Value()
+            sample.Containers[c.Name] = usage
+        }
+    }
+    sample.Pods = len(list.Items)
+    if sample.Pods > 0 {
+        for name, usage := range sample.Containers {
+            sample.Containers[name] = Usage{CPU: usage.CPU / int64(sample.Pods), Memory: usage.Memory / int64(sample.Pods)}
+        }
+    }
+    return sample, nil
+}
+
+// UsageWindow keeps the samples of the last Window and summarizes them.
+type UsageWindow struct {
+    Window  time.Duration
+    samples []UsageSample
+}
+
+// Stat summarizes one resource of a container over the window.
+type Stat struct {
+    Min, Avg, Max, Last int64
+}
+
+type ContainerStats struct {
+    Name    string
+    Samples int
+    CPU     Stat
+    Memory  Stat
+}
+
+// Add appends a sample and drops the ones that fell out of the window.
+func (w *UsageWindow) Add(sample UsageSample) {
+    w.samples = append(w.samples, sample)
+    cutoff := sample.Time.Add(-w.Window)
+    for len(w.samples) > 1 && w.samples[0].Time.Before(cutoff) {
+        w.samples = w.samples[1:]
+    }
+}
+
+// Len returns the number of samples in the window.
+func (w *UsageWindow) Len() int {
+    return len(w.samples)
+}
+
+// Stats returns min/avg/max/last usage per container, sorted by name.
+// Containers missing from some samples, e.g. while pods restart, are
+// summarized over the samples they appear in.
+func (w *UsageWindow) Stats() []ContainerStats {
+    byName := make(map[string]*ContainerStats)
+    var cpuSum, memorySum = make(map[string]int64), make(map[string]int64)
+    for _, sample := range w.samples {
+        for name, usage := range sample.Containers {
+            s, ok := byName[name]
+            if !ok {
+                s = &ContainerStats{Name: name, CPU: Stat{Min: usage.CPU}, Memory: Stat{Min: usage.Memory}}
+                byName[name] = s
+            }
+            s.Samples++
+            s.CPU.add(usage.CPU)
+            s.Memory.add(usage.Memory)
+            cpuSum[name] += usage.CPU
+            memorySum[name] += usage.Memory
+        }
+    }
+
+    stats := make([]ContainerStats, 0, len(byName))
+    for name, s := range byName {
+        s.CPU.Avg = cpuSum[name] / int64(s.Samples)
+        s.Memory.Avg = memorySum[name] / int64(s.Samples)
+        stats = append(stats, *s)
+    }
+    sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+    return stats
+}
+
+func (s *Stat) add(value int64) {
+    s.Min = min(s.Min, value)
+    s.Max = max(s.Max, value)
+    s.Last = value
+}
@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+    "testing"
+    "time"
+)
+
+func TestUsageWindow(t *testing.T) {
+    start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+    w := &UsageWindow{Window: time.Minute}
+    for i, usage := range []Usage{{CPU: 100, Memory: 64}, {CPU: 300, Memory: 32}, {CPU: 200, Memory: 96}} {
+        w.Add(UsageSample{
+            Time:       start.Add(time.Duration(i) * 30 * time.Second),
+            Pods:       2,
+            Containers: map[string]Usage{"app": usage},
+        })
+    }
+    stats := w.Stats()
+    if len(stats) != 1 || stats[0].Samples != 3 {
+        t.Fatalf("unexpected stats: %+v", stats)
+    }
+    if cpu := stats[0].CPU; cpu != (Stat{Min: 100, Avg: 200, Max: 300, Last: 200}) {
+        t.Errorf("unexpected CPU stats: %+v", cpu)
+    }
+    if memory := stats[0].Memory; memory != (Stat{Min: 32, Avg: 64, Max: 96, Last: 96}) {
+        t.Errorf("unexpected memory stats: %+v", memory)
+    }
+
+    // The first sample falls out of the window; a sidecar appears
+    w.Add(UsageSample{
+        Time:       start.Add(90 * time.Second),
+        Containers: map[string]Usage{"app": {CPU: 400, Memory: 64}, "sidecar": {CPU: 10, Memory: 16}},
+    })
+    stats = w.Stats()
+    if w.Len() != 3 || len(stats) != 2 || stats[0].CPU.Min != 200 || stats[0].CPU.Max != 400 {
+        t.Errorf("unexpected stats after sliding: %+v", stats)
+    }
+    if stats[1].Name != "sidecar" || stats[1].Samples != 1 || stats[1].CPU.Avg != 10 {
+        t.Errorf("unexpected sidecar stats: %+v", stats[1])
+    }
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+    "context"
+    "fmt"
+    "strings"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/types"
+    "k8s.io/client-go/kubernetes"
+)
+
+// WorkloadTypes lists the workload types the analyzer understands, in the
+// order namespace scans visit them.
+var WorkloadTypes = []string{"deployment", "statefulset", "daemonset"}
+
+// workload holds the parts of a Deployment, StatefulSet or DaemonSet the
+// analyzer needs, so the rest of the package doesn't switch on kind.
+type workload struct {
+    Kind     string
+    Name     string
+    UID      types.UID
+    Replicas *int32
+    Desired  int32
+    Selector *metav1.LabelSelector
+    PodSpec  *corev1.PodSpec
+}
+
+// Type is the lower-case workload type used on the command line.
+func (w *workload) Type() string {
+    return strings.ToLower(w.Kind)
+}
+
+func fromDeployment(d *appsv1.Deployment) *workload {
+    return &workload{Kind: "Deployment", Name: d.Name, UID: d.UID, Replicas: d.Spec.Replicas, Desired: replicasOrOne(d.Spec.Replicas), Selector: d.Spec.Selector, PodSpec: &d.Spec.Template.Spec}
+}
+
+func fromStatefulSet(s *appsv1.StatefulSet) *workload {
+    return &workload{Kind: "StatefulSet", Name: s.Name, UID: s.UID, Replicas: s.Spec.Replicas, Desired: replicasOrOne(s.Spec.Replicas), Selector: s.Spec.Selector, PodSpec: &s.Spec.Template.Spec}
+}
+
+func fromDaemonSet(d *appsv1.DaemonSet) *workload {
+    return &workload{Kind: "DaemonSet", Name: d.Name, UID: d.UID, Desired: d.Status.DesiredNumberScheduled, Selector: d.Spec.Selector, PodSpec: &d.Spec.Template.Spec}
+}
+
+// replicasOrOne applies the API server default for an unset replica count.
+func replicasOrOne(replicas *int32) int32 {
+    if replicas == nil {
+        return 1
+    }
+    return *replicas
+}
+
+func getWorkload(ctx context.Context, client kubernetes.Interface, namespace, workloadType, name string) (*workload, error) {
+    var w *workload
+    err := withRetry(ctx, func(ctx context.Context) error {
+        switch workloadType {
+        case "deployment":
+            deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+            if err != nil {
+                return err
+            }
+            w = fromDeployment(deployment)
+        case "statefulset":
+            sts, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+            if err != nil {
+                return err
+            }
+            w = fromStatefulSet(sts)
+        case "daemonset":
+            ds, err := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+            if err != nil {
+                return err
+            }
+            w = fromDaemonSet(ds)
+        default:
+            return fmt.Errorf("unsupported workload type: %s", workloadType)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return w, nil
+}
+
+func listWorkloads(ctx context.Context, client kubernetes.Interface, namespace string) ([]*workload, error) {
+    var workloads []*workload
+    err := withRetry(ctx, func(ctx context.Context) error {
+        workloads = nil
+
+        deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+        if err != nil {
+            return err
+        }
+        for i := range deployments.Items {
+            workloads = append(workloads, fromDeployment(&deployments.Items[i]))
+        }
+
+        statefulSets, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+        if err != nil {
+            return err
+        }
+        for i := range statefulSets.Items {
+            workloads = append(workloads, fromStatefulSet(&statefulSets.Items[i]))
+        }
+
+        daemonSets, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+        if err != nil {
+            return err
+        }
+        for i := range daemonSets.Items {
+            workloads = append(workloads, fromDaemonSet(&daemonSets.Items[i]))
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to list workloads: %w", err)
+    }
+    return workloads, nil
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "time"
+
+    "gopkg.in/yaml.v3"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// FileName is the project-local config file looked up in the working
+// directory; the user config lives at $HOME/.config/kwa/config.yaml.
+const FileName = ".kwa.yaml"
+
+type Config struct {
+    Timeout    time.Duration         `yaml:"timeout"`
+    Metrics    MetricsConfig         `yaml:"metrics"`
+    Efficiency EfficiencyConfig      `yaml:"efficiency"`
+    Rules      map[string]RuleConfig `yaml:"rules"`
+    AI         AIConfig              `yaml:"ai"`
+    Pricing    PricingConfig         `yaml:"pricing"`
+    Output     OutputConfig          `yaml:"output"`
+    History    HistoryConfig         `yaml:"history"`
+
+    // Sources lists the files that were merged, lowest precedence first.
+    Sources []string `yaml:"-"`
+}
+
+type MetricsConfig struct {
+    Source string `yaml:"source"` // metrics-server or none
+}
+
+// EfficiencyConfig holds percentages. Low and High split the efficiency rate
+// into Low/Medium/High; the ratios drive the usage rules.
+type EfficiencyConfig struct {
+    Low                  float64 `yaml:"low"`
+    High                 float64 `yaml:"high"`
+    OverProvisionedBelow float64 `yaml:"over_provisioned_below"`
+    NearLimitAbove       float64 `yaml:"near_limit_above"`
+}
+
+// RuleConfig enables/disables a rule or overrides its severity. Rules are
+// keyed by ID (KWA001) or name (missing-requests).
+type RuleConfig struct {
+    Enabled  *bool  `yaml:"enabled,omitempty"`
+    Severity string `yaml:"severity,omitempty"`
+}
+
+type AIConfig struct {
+    Provider    string        `yaml:"provider"`
+    Model       string        `yaml:"model"`
+    Temperature float64       `yaml:"temperature"`
+    BaseURL     string        `yaml:"base_url"`
+    APIKey      string        `yaml:"api_key,omitempty"`
+    APIKeyEnv   string        `yaml:"api_key_env"`
+    Timeout     time.Duration `yaml:"timeout"`
+    MaxRetries  int           `yaml:"max_retries"`
+    Focus       string        `yaml:"focus"`
+    PromptsDir  string        `yaml:"prompts_dir,omitempty"`
+}
+
+type PricingConfig struct {
+    Currency      string  `yaml:"currency"`
+    CPUCoreHour   float64 `yaml:"cpu_core_hour"`
+    MemoryGiBHour float64 `yaml:"memory_gib_hour"`
+}
+
+type OutputConfig struct {
+    Format string `yaml:"format"`
+}
+
+// HistoryConfig controls the local store every cluster analysis is saved to.
+type HistoryConfig struct {
+    Enabled bool   `yaml:"enabled"`
+    Path    string `yaml:"path"` // defaults to $XDG_DATA_HOME/kwa/history.db
+}
+
+func Default() *Config {
+    return &Config{
+        Timeout: 5 * time.Minute,
+        Metrics: MetricsConfig{Source: "metrics-server"},
+        Efficiency: EfficiencyConfig{
+            Low:                  50,
+            High:                 80,
+            OverProvisionedBelow: 50,
+            NearLimitAbove:       90,
+        },
+        AI: AIConfig{
+            Provider:    "openai",
+            Model:       "gpt-3.5-turbo",
+            Temperature: 0.1,
+            BaseURL:     "https://api.openai.com/v1",
+            APIKeyEnv:   "OPENAI_API_KEY",
+            Timeout:     60 * time.Second,
+            MaxRetries:  4,
+            Focus:       "general",
+        },
+        Pricing: PricingConfig{
+            Currency:      "USD",
+            CPUCoreHour:   0.0316,
+            MemoryGiBHour: 0.0042,
+        },
+        Output:  OutputConfig{Format: "text"},
+        History: HistoryConfig{Enabled: true},
+    }
+}
+
+// Load merges the defaults, the user config, the project-local config (or
+// only path when given), and KWA_* environment variables, then validates.
+func Load(path string) (*Config, error) {
+    cfg := Default()
+
+    var files []string
+    if path != "" {
+        files = []string{path}
+    } else {
+        if home, err := os.UserHomeDir(); err == nil {
+            files = append(files, filepath.Join(home, ".config", "kwa", "config.yaml"))
+        }
+        files = append(files, FileName)
+    }
+
+    for _, file := range files {
+        data, err := os.ReadFile(file)
+        if err != nil {
+            if path == "" && errors.Is(err, os.ErrNotExist) {
+                continue
+            }
+            return nil, fmt.Errorf("failed to read config: %v", err)
+        }
+        decoder := yaml.NewDecoder(bytes.NewReader(data))
+        decoder.KnownFields(true)
+        if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+            return nil, fmt.Errorf("failed to parse config %s: %v", file, err)
+        }
+        cfg.Sources = append(cfg.Sources, file)
+    }
+
+    if err := applyEnv(cfg, os.LookupEnv); err != nil {
+        return nil, err
+    }
+    if err := cfg.Validate(); err != nil {
+        return nil, err
+    }
+    return cfg, nil
+}
+
+var outputFormats = map[string]bool{"text": true, "json": true, "sarif": true, "junit": true, "html": true, "prometheus": true}
+
+func (c *Config) Validate() error {
+    var errs []error
+    check := func(ok bool, format string, args ...interface{}) {
+        if !ok {
+            errs = append(errs, fmt.Errorf(format, args...))
+        }
+    }
+
+    check(c.Timeout >= 0, "timeout must not be negative")
+    check(c.Metrics.Source == "metrics-server" || c.Metrics.Source == "none", "metrics.source must be metrics-server or none, got %q", c.Metrics.Source)
+    check(c.Efficiency.Low > 0 && c.Efficiency.Low < c.Efficiency.High, "efficiency.low must be positive and below efficiency.high")
+    check(c.Efficiency.OverProvisionedBelow > 0 && c.Efficiency.OverProvisionedBelow <= 100, "efficiency.over_provisioned_below must be in (0, 100]")
+    check(c.Efficiency.NearLimitAbove > 0 && c.Efficiency.NearLimitAbove <= 100, "efficiency.near_limit_above must be in (0, 100]")
+    for key, rule := range c.Rules {
+        if rule.Severity != "" {
+            _, err := analyzer.ParseSeverity(rule.Severity)
+            check(err == nil, "rules.%s.severity: %v", key, err)
+        }
+    }
+    check(c.AI.Provider == "openai", "ai.provider must be openai, got %q", c.AI.Provider)
+    check(c.AI.Model != "", "ai.model must be set")
+    check(c.AI.Temperature >= 0 && c.AI.Temperature <= 2, "ai.temperature must be between 0 and 2")
+    check(c.AI.BaseURL != "", "ai.base_url must be set")
+    check(c.AI.Timeout > 0, "ai.timeout must be positive")
+    check(c.AI.MaxRetries >= 1, "ai.max_retries must be at least 1")
+    check(c.AI.Focus != "", "ai.focus must be set")
+    check(c.Pricing.CPUCoreHour >= 0 && c.Pricing.MemoryGiBHour >= 0, "pricing must not be negative")
+    check(outputFormats[c.Output.Format], "output.format must be text, json, sarif, junit, html or prometheus, got %q", c.Output.Format)
+
+    if len(errs) > 0 {
+        return fmt.Errorf("invalid config: %v", errors.Join(errs...))
+    }
+    return nil
+}
+
+// ResolveAPIKey returns the configured API key, falling back to the
+// environment variable named by api_key_env.
+func (c *AIConfig) ResolveAPIKey() string {
+    if c.APIKey != "" {
+        return c.APIKey
+    }
+    if c.APIKeyEnv != "" {
+        return os.Getenv(c.APIKeyEnv)
+    }
+    return ""
+}
+
+// YAML renders the effective config with secrets redacted.
+func (c *Config) YAML() ([]byte, error) {
+    redacted := *c
+    if redacted.AI.APIKey != "" {
+        redacted.AI.APIKey = "REDACTED"
+    }
+    var buf bytes.Buffer
+    encoder := yaml.NewEncoder(&buf)
+    encoder.SetIndent(2)
+    if err := encoder.Encode(&redacted); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
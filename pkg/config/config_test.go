@@ -0,0 +1,147 @@
+package config
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+    t.Helper()
+    if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// inTempDirs points HOME and the working directory at empty temp dirs and
+// returns them.
+func inTempDirs(t *testing.T) (home, project string) {
+    t.Helper()
+    home, project = t.TempDir(), t.TempDir()
+    t.Setenv("HOME", home)
+    wd, err := os.Getwd()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Chdir(project); err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { os.Chdir(wd) })
+    return home, project
+}
+
+func TestLoadDefaults(t *testing.T) {
+    inTempDirs(t)
+
+    cfg, err := Load("")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(cfg.Sources) != 0 {
+        t.Errorf("expected no config files, got %v", cfg.Sources)
+    }
+    if cfg.Efficiency.Low != 50 || cfg.Efficiency.High != 80 || cfg.AI.Model != "gpt-3.5-turbo" {
+        t.Errorf("expected the defaults, got %+v", cfg)
+    }
+}
+
+func TestLoadPrecedence(t *testing.T) {
+    home, project := inTempDirs(t)
+    user := filepath.Join(home, ".config", "kwa", "config.yaml")
+    writeFile(t, user, "efficiency:\n  low: 40\nai:\n  model: user-model\n  temperature: 0.5\n")
+    writeFile(t, filepath.Join(project, FileName), "ai:\n  model: project-model\n")
+
+    cfg, err := Load("")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(cfg.Sources) != 2 || cfg.Sources[0] != user || cfg.Sources[1] != FileName {
+        t.Errorf("expected the user then the project config, got %v", cfg.Sources)
+    }
+    if cfg.AI.Model != "project-model" || cfg.AI.Temperature != 0.5 || cfg.Efficiency.Low != 40 || cfg.Efficiency.High != 80 {
+        t.Errorf("expected the project config over the user config over defaults, got %+v %+v", cfg.AI, cfg.Efficiency)
+    }
+
+    // The environment wins over every file
+    t.Setenv("KWA_AI_MODEL", "env-model")
+    t.Setenv("KWA_AI_TIMEOUT", "90s")
+    t.Setenv("KWA_EFFICIENCY_HIGH", "70")
+    t.Setenv("KWA_HISTORY_ENABLED", "false")
+    cfg, err = Load("")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if cfg.AI.Model != "env-model" || cfg.AI.Timeout != 90*time.Second || cfg.Efficiency.High != 70 || cfg.History.Enabled {
+        t.Errorf("expected environment overrides, got %+v %+v %+v", cfg.AI, cfg.Efficiency, cfg.History)
+    }
+    if cfg.Efficiency.Low != 40 {
+        t.Errorf("expected settings without overrides to keep the file value, got %v", cfg.Efficiency.Low)
+    }
+
+    // An explicit path replaces discovery but not the environment
+    explicit := filepath.Join(project, "ci.yaml")
+    writeFile(t, explicit, "ai:\n  model: ci-model\n  temperature: 0.2\n")
+    cfg, err = Load(explicit)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(cfg.Sources) != 1 || cfg.AI.Temperature != 0.2 || cfg.AI.Model != "env-model" || cfg.Efficiency.Low != 50 {
+        t.Errorf("expected only %s plus the environment, got %v %+v %+v", explicit, cfg.Sources, cfg.AI, cfg.Efficiency)
+    }
+}
+
+func TestLoadErrors(t *testing.T) {
+    _, project := inTempDirs(t)
+
+    tests := []struct {
+        name    string
+        content string
+        env     map[string]string
+        want    string
+    }{
+        {"unknown field", "ai:\n  modle: gpt-4\n", nil, "field modle not found"},
+        {"unknown section", "metric:\n  source: none\n", nil, "field metric not found"},
+        {"bad type", "timeout: soon\n", nil, "failed to parse config"},
+        {"invalid value", "efficiency:\n  low: 90\n  high: 80\n", nil, "efficiency.low must be positive and below efficiency.high"},
+        {"bad severity", "rules:\n  KWA001:\n    severity: urgent\n", nil, "rules.KWA001.severity"},
+        {"bad env", "", map[string]string{"KWA_AI_TIMEOUT": "soon"}, "invalid KWA_AI_TIMEOUT"},
+        {"env validated", "", map[string]string{"KWA_OUTPUT_FORMAT": "xml"}, "output.format must be"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            for name, value := range tt.env {
+                t.Setenv(name, value)
+            }
+            file := filepath.Join(project, "config.yaml")
+            writeFile(t, file, tt.content)
+            _, err := Load(file)
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Errorf("expected an error containing %q, got %v", tt.want, err)
+            }
+        })
+    }
+
+    if _, err := Load(filepath.Join(project, "missing.yaml")); err == nil {
+        t.Error("expected a missing explicit config to be an error")
+    }
+}
+
+func TestYAMLRedactsAPIKey(t *testing.T) {
+    cfg := Default()
+    cfg.AI.APIKey = "sk-secret"
+    data, err := cfg.YAML()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if strings.Contains(string(data), "sk-secret") || !strings.Contains(string(data), "api_key: REDACTED") {
+        t.Errorf("expected the API key to be redacted:\n%s", data)
+    }
+    if cfg.AI.APIKey != "sk-secret" {
+        t.Error("expected YAML to leave the config unchanged")
+    }
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+    "fmt"
+    "reflect"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// EnvPrefix prefixes environment overrides. Each scalar setting maps to
+// KWA_<SECTION>_<KEY>, e.g. ai.model is KWA_AI_MODEL.
+const EnvPrefix = "KWA"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+    return applyEnvValue(reflect.ValueOf(cfg).Elem(), EnvPrefix, lookup)
+}
+
+func applyEnvValue(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+    t := v.Type()
+    for i := 0; i < t.NumField(); i++ {
+        field := t.Field(i)
+        tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+        if tag == "" || tag == "-" {
+            continue
+        }
+        name := prefix + "_" + strings.ToUpper(tag)
+        fv := v.Field(i)
+
+        if fv.Kind() == reflect.Struct && fv.Type() != durationType {
+            if err := applyEnvValue(fv, name, lookup); err != nil {
+                return err
+            }
+            continue
+        }
+
+        value, ok := lookup(name)
+        if !ok {
+            continue
+        }
+        if err := setValue(fv, value); err != nil {
+            return fmt.Errorf("invalid %s: %v", name, err)
+        }
+    }
+    return nil
+}
+
+func setValue(v reflect.Value, value string) error {
+    if v.Type() == durationType {
+        d, err := time.ParseDuration(value)
+        if err != nil {
+            return err
+        }
+        v.SetInt(int64(d))
+        return nil
+    }
+
+    switch v.Kind() {
+    case reflect.String:
+        v.SetString(value)
+    case reflect.Int:
+        n, err := strconv.Atoi(value)
+        if err != nil {
+            return err
+        }
+        v.SetInt(int64(n))
+    case reflect.Float64:
+        f, err := strconv.ParseFloat(value, 64)
+        if err != nil {
+            return err
+        }
+        v.SetFloat(f)
+    case reflect.Bool:
+        b, err := strconv.ParseBool(value)
+        if err != nil {
+            return err
+        }
+        v.SetBool(b)
+    default:
+        // Maps such as rules are only configurable from files
+    }
+    return nil
+}
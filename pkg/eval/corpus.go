@@ -0,0 +1,91 @@
+package eval
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "gopkg.in/yaml.v3"
+)
+
+// Case is one fixture workload: its manifest, a snapshot of its usage, and
+// the issues a good analysis must raise.
+type Case struct {
+    Name        string           `yaml:"name"`
+    Description string           `yaml:"description,omitempty"`
+    Manifest    string           `yaml:"manifest"`
+    Pods        int              `yaml:"pods,omitempty"`
+    Usage       map[string]Usage `yaml:"usage,omitempty"`
+    Expect      []Expectation    `yaml:"expect"`
+
+    file string
+}
+
+// Usage is a container's usage in every pod, as Kubernetes quantities.
+type Usage struct {
+    CPU    string `yaml:"cpu"`
+    Memory string `yaml:"memory"`
+}
+
+// Expectation is an issue the analysis should raise, either by citing a
+// finding or by mentioning a keyword in an opportunity or caution.
+type Expectation struct {
+    Finding string `yaml:"finding,omitempty"`
+    Keyword string `yaml:"keyword,omitempty"`
+}
+
+func (e Expectation) String() string {
+    if e.Finding != "" {
+        return e.Finding
+    }
+    return fmt.Sprintf("%q", e.Keyword)
+}
+
+// LoadCorpus reads the *.yaml cases in dir, sorted by file name.
+func LoadCorpus(dir string) ([]*Case, error) {
+    files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+    if err != nil {
+        return nil, err
+    }
+    if len(files) == 0 {
+        return nil, fmt.Errorf("no cases (*.yaml) in %s", dir)
+    }
+    sort.Strings(files)
+
+    var cases []*Case
+    for _, file := range files {
+        data, err := os.ReadFile(file)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read case: %v", err)
+        }
+        c := &Case{file: file}
+        if err := yaml.Unmarshal(data, c); err != nil {
+            return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+        }
+        if c.Name == "" {
+            c.Name = strings.TrimSuffix(filepath.Base(file), ".yaml")
+        }
+        if err := c.validate(); err != nil {
+            return nil, fmt.Errorf("invalid case %s: %v", file, err)
+        }
+        cases = append(cases, c)
+    }
+    return cases, nil
+}
+
+func (c *Case) validate() error {
+    if strings.TrimSpace(c.Manifest) == "" {
+        return fmt.Errorf("manifest is required")
+    }
+    if len(c.Expect) == 0 {
+        return fmt.Errorf("expect lists no issues")
+    }
+    for _, e := range c.Expect {
+        if (e.Finding == "") == (e.Keyword == "") {
+            return fmt.Errorf("each expectation needs exactly one of finding or keyword")
+        }
+    }
+    return nil
+}
@@ -0,0 +1,339 @@
+// Package eval scores the AI analysis against a corpus of fixture workloads,
+// so prompt and model changes can be compared before they ship.
+package eval
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math"
+    "slices"
+    "sort"
+    "strings"
+    "time"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/resource"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/client-go/kubernetes"
+    "k8s.io/client-go/kubernetes/fake"
+    "k8s.io/client-go/kubernetes/scheme"
+    metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+    metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+    "k8s-workload-analyzer/pkg/ai"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// Namespace is where cases without one are placed.
+const Namespace = "eval"
+
+// Config is one variant of the pipeline, e.g. a model or prompt revision.
+type Config struct {
+    Variant  string
+    Provider ai.Provider
+    // Price is per million tokens; nil when unknown.
+    Price *Price
+    // Finish adds findings and cost as a real run would.
+    Finish func(ctx context.Context, details *analyzer.WorkloadDetails, cluster string)
+    // Enrich builds the AI enricher a real run would use around provider.
+    Enrich func(provider ai.Provider, client kubernetes.Interface) func(ctx context.Context, details *analyzer.WorkloadDetails) error
+    // Timeout bounds each case; 0 disables.
+    Timeout time.Duration
+}
+
+// Price is the cost of a million prompt and completion tokens.
+type Price struct {
+    Input  float64 `json:"input"`
+    Output float64 `json:"output"`
+}
+
+// DefaultPrices are list prices in USD of OpenAI models, per million tokens.
+var DefaultPrices = map[string]Price{
+    "gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+    "gpt-4o-mini":   {Input: 0.15, Output: 0.60},
+    "gpt-4o":        {Input: 2.50, Output: 10.00},
+    "gpt-4.1-mini":  {Input: 0.40, Output: 1.60},
+    "gpt-4.1":       {Input: 2.00, Output: 8.00},
+}
+
+// Result is the outcome of one case. Responded is false when the model was
+// never heard from, e.g. a network error or a missing recording.
+type Result struct {
+    Case      string        `json:"case"`
+    Error     string        `json:"error,omitempty"`
+    Responded bool          `json:"responded"`
+    ValidJSON bool          `json:"valid_json"`
+    Latency   time.Duration `json:"latency"`
+    Usage     ai.Usage      `json:"usage"`
+    Claims    int           `json:"claims"`
+    Supported int           `json:"supported_claims"`
+    Dropped   int           `json:"dropped_claims"`
+    Found     []string      `json:"found,omitempty"`
+    Missed    []string      `json:"missed,omitempty"`
+}
+
+// Summary scores a variant over the corpus. Precision is the share of
+// claims raising an expected issue, recall the share of expected issues
+// raised; cases that failed count as raising nothing.
+type Summary struct {
+    Variant          string        `json:"variant"`
+    PromptVersion    string        `json:"prompt_version,omitempty"`
+    Cases            int           `json:"cases"`
+    Errors           int           `json:"errors"`
+    ValidRate        float64       `json:"valid_json_rate"`
+    Precision        float64       `json:"precision"`
+    Recall           float64       `json:"recall"`
+    LatencyP50       time.Duration `json:"latency_p50"`
+    LatencyP95       time.Duration `json:"latency_p95"`
+    PromptTokens     int           `json:"prompt_tokens"`
+    CompletionTokens int           `json:"completion_tokens"`
+    Cost             *float64      `json:"cost,omitempty"`
+    Results          []Result      `json:"results"`
+}
+
+// Run evaluates every case in turn, so latencies don't compete.
+func Run(ctx context.Context, cfg Config, cases []*Case) *Summary {
+    sum := &Summary{Variant: cfg.Variant, Cases: len(cases)}
+    var claims, supported, expected, found, responded, valid int
+    var latencies []time.Duration
+
+    for _, c := range cases {
+        m := &meter{Provider: cfg.Provider}
+        res := runCase(ctx, cfg, c, m)
+        if ctx.Err() != nil {
+            break
+        }
+        sum.Results = append(sum.Results, res)
+
+        expected += len(c.Expect)
+        found += len(res.Found)
+        claims += res.Claims
+        supported += res.Supported
+        sum.PromptTokens += res.Usage.PromptTokens
+        sum.CompletionTokens += res.Usage.CompletionTokens
+        if m.version != "" {
+            sum.PromptVersion = m.version
+        }
+        if res.Error != "" {
+            sum.Errors++
+        }
+        if res.Responded {
+            responded++
+            latencies = append(latencies, res.Latency)
+            if res.ValidJSON {
+                valid++
+            }
+        }
+    }
+
+    sum.ValidRate = ratio(valid, responded)
+    sum.Precision = ratio(supported, claims)
+    sum.Recall = ratio(found, expected)
+    sum.LatencyP50 = percentile(latencies, 50)
+    sum.LatencyP95 = percentile(latencies, 95)
+    if cfg.Price != nil {
+        cost := (float64(sum.PromptTokens)*cfg.Price.Input + float64(sum.CompletionTokens)*cfg.Price.Output) / 1e6
+        sum.Cost = &cost
+    }
+    return sum
+}
+
+func runCase(ctx context.Context, cfg Config, c *Case, m *meter) Result {
+    res := Result{Case: c.Name}
+    fail := func(err error) Result {
+        res.Error = err.Error()
+        for _, e := range c.Expect {
+            res.Missed = append(res.Missed, e.String())
+        }
+        return res
+    }
+    if cfg.Timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+        defer cancel()
+    }
+
+    client, metricsClient, details, err := c.cluster()
+    if err != nil {
+        return fail(err)
+    }
+    details, err = analyzer.AnalyzeWorkload(ctx, client, metricsClient, details.Namespace, details.Kind, details.Deployment)
+    if err != nil {
+        return fail(fmt.Errorf("failed to analyze workload: %v", err))
+    }
+    cfg.Finish(ctx, details, "eval")
+
+    err = cfg.Enrich(m, client)(ctx, details)
+    res.Responded = m.responded
+    res.ValidJSON = m.responded && !m.invalid
+    res.Latency = m.latency
+    res.Usage = m.usage
+    if err != nil {
+        return fail(err)
+    }
+
+    res.Dropped = details.DroppedClaims
+    res.Supported, res.Found, res.Missed = score(c.Expect, append(slices.Clone(details.Opportunities), details.Cautions...))
+    res.Claims = len(details.Opportunities) + len(details.Cautions)
+    return res
+}
+
+// score counts the claims raising any expected issue, and splits the
+// expectations into those raised and those missed.
+func score(expect []Expectation, claims []analyzer.Claim) (supported int, found, missed []string) {
+    raises := func(e Expectation, c analyzer.Claim) bool {
+        if e.Finding != "" {
+            return slices.Contains(c.Findings, e.Finding)
+        }
+        return strings.Contains(strings.ToLower(c.Text), strings.ToLower(e.Keyword))
+    }
+    for _, c := range claims {
+        if slices.ContainsFunc(expect, func(e Expectation) bool { return raises(e, c) }) {
+            supported++
+        }
+    }
+    for _, e := range expect {
+        if slices.ContainsFunc(claims, func(c analyzer.Claim) bool { return raises(e, c) }) {
+            found = append(found, e.String())
+        } else {
+            missed = append(missed, e.String())
+        }
+    }
+    return supported, found, missed
+}
+
+// cluster builds fake Kubernetes and metrics clients holding the case's
+// workload, its pods and their usage.
+func (c *Case) cluster() (kubernetes.Interface, *metricsfake.Clientset, *analyzer.WorkloadDetails, error) {
+    obj, _, err := scheme.Codecs.UniversalDeserializer().Decode([]byte(c.Manifest), nil, nil)
+    if err != nil {
+        return nil, nil, nil, fmt.Errorf("failed to decode manifest: %v", err)
+    }
+    meta, ok := obj.(metav1.Object)
+    if !ok {
+        return nil, nil, nil, fmt.Errorf("manifest is not a workload")
+    }
+    if meta.GetNamespace() == "" {
+        meta.SetNamespace(Namespace)
+    }
+    details, ok := analyzer.DetailsFromObject(obj, Namespace)
+    if !ok {
+        return nil, nil, nil, fmt.Errorf("unsupported kind %s", obj.GetObjectKind().GroupVersionKind().Kind)
+    }
+    template := podTemplate(obj)
+
+    var containers []metricsv1beta1.ContainerMetrics
+    for _, container := range template.Spec.Containers {
+        u, ok := c.Usage[container.Name]
+        if !ok {
+            continue
+        }
+        cpu, err := resource.ParseQuantity(u.CPU)
+        if err != nil {
+            return nil, nil, nil, fmt.Errorf("invalid cpu usage of %s: %v", container.Name, err)
+        }
+        memory, err := resource.ParseQuantity(u.Memory)
+        if err != nil {
+            return nil, nil, nil, fmt.Errorf("invalid memory usage of %s: %v", container.Name, err)
+        }
+        containers = append(containers, metricsv1beta1.ContainerMetrics{
+            Name:  container.Name,
+            Usage: corev1.ResourceList{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory},
+        })
+    }
+    if len(containers) != len(c.Usage) {
+        return nil, nil, nil, fmt.Errorf("usage names a container the manifest doesn't have")
+    }
+
+    pods := c.Pods
+    if pods == 0 {
+        pods = int(max(details.DesiredReplicas, 1))
+    }
+    objects := []runtime.Object{obj}
+    metricsClient := metricsfake.NewSimpleClientset()
+    for i := range pods {
+        name := fmt.Sprintf("%s-%d", details.Deployment, i)
+        objects = append(objects, &corev1.Pod{
+            ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: details.Namespace, Labels: template.Labels},
+            Spec:       template.Spec,
+            Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+        })
+        if len(containers) == 0 {
+            continue
+        }
+        // The generated fake looks pod metrics up under "pods"
+        err := metricsClient.Tracker().Create(metricsv1beta1.SchemeGroupVersion.WithResource("pods"), &metricsv1beta1.PodMetrics{
+            ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: details.Namespace},
+            Containers: containers,
+        }, details.Namespace)
+        if err != nil {
+            return nil, nil, nil, fmt.Errorf("failed to add pod metrics: %v", err)
+        }
+    }
+    return fake.NewSimpleClientset(objects...), metricsClient, details, nil
+}
+
+func podTemplate(obj runtime.Object) *corev1.PodTemplateSpec {
+    switch o := obj.(type) {
+    case *appsv1.Deployment:
+        return &o.Spec.Template
+    case *appsv1.StatefulSet:
+        return &o.Spec.Template
+    case *appsv1.DaemonSet:
+        return &o.Spec.Template
+    }
+    return &corev1.PodTemplateSpec{}
+}
+
+// meter wraps a provider, measuring the one analysis of a case.
+type meter struct {
+    ai.Provider
+    responded bool
+    invalid   bool
+    latency   time.Duration
+    usage     ai.Usage
+    version   string
+}
+
+func (m *meter) AnalyzeWorkload(ctx context.Context, kind, yaml string, evidence ai.Evidence) (*ai.WorkloadAnalysis, error) {
+    start := time.Now()
+    analysis, err := m.Provider.AnalyzeWorkload(ctx, kind, yaml, evidence)
+    m.latency += time.Since(start)
+
+    var invalid *ai.InvalidResponseError
+    switch {
+    case errors.As(err, &invalid):
+        m.responded, m.invalid = true, true
+        m.addUsage(invalid.Usage)
+    case err == nil:
+        m.responded = true
+        m.addUsage(analysis.Usage)
+        m.version = analysis.PromptVersion
+    }
+    return analysis, err
+}
+
+func (m *meter) addUsage(u ai.Usage) {
+    m.usage.PromptTokens += u.PromptTokens
+    m.usage.CompletionTokens += u.CompletionTokens
+}
+
+func ratio(n, d int) float64 {
+    if d == 0 {
+        return 0
+    }
+    return float64(n) / float64(d)
+}
+
+// percentile returns the nearest-rank percentile p of durations.
+func percentile(durations []time.Duration, p float64) time.Duration {
+    if len(durations) == 0 {
+        return 0
+    }
+    sorted := slices.Clone(durations)
+    sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+    rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+    return sorted[max(rank-1, 0)]
+}
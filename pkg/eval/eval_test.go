@@ -0,0 +1,135 @@
+package eval
+
+import (
+    "context"
+    "sort"
+    "testing"
+
+    "k8s.io/client-go/kubernetes"
+    "k8s-workload-analyzer/pkg/ai"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/rules"
+)
+
+// citingProvider raises one caution per finding in the evidence, plus one
+// claim citing a finding that doesn't exist.
+type citingProvider struct {
+    ai.Provider
+    invalid map[string]bool
+}
+
+func (p *citingProvider) AnalyzeWorkload(ctx context.Context, kind, yaml string, ev ai.Evidence) (*ai.WorkloadAnalysis, error) {
+    if p.invalid[kind] {
+        return nil, &ai.InvalidResponseError{Content: "not json", Usage: ai.Usage{PromptTokens: 100}}
+    }
+    var ids []string
+    for id := range ev.Findings {
+        ids = append(ids, id)
+    }
+    sort.Strings(ids)
+    analysis := &ai.WorkloadAnalysis{
+        PromptVersion: "test",
+        Usage:         ai.Usage{PromptTokens: 1000, CompletionTokens: 200},
+        Opportunities: []ai.Claim{{Text: "made up", Findings: []string{"KWA999"}}, {Text: "tune requests"}},
+    }
+    for _, id := range ids {
+        analysis.Cautions = append(analysis.Cautions, ai.Claim{Text: ev.Findings[id], Findings: []string{id}})
+    }
+    return analysis, nil
+}
+
+func newConfig(t *testing.T, provider ai.Provider) Config {
+    t.Helper()
+    engine, err := rules.NewEngine(rules.DefaultOptions())
+    if err != nil {
+        t.Fatal(err)
+    }
+    return Config{
+        Variant:  "test",
+        Provider: provider,
+        Price:    &Price{Input: 1, Output: 2},
+        Finish: func(ctx context.Context, details *analyzer.WorkloadDetails, cluster string) {
+            details.Cluster = cluster
+            details.Findings = engine.Evaluate(details)
+        },
+        Enrich: func(provider ai.Provider, _ kubernetes.Interface) func(ctx context.Context, details *analyzer.WorkloadDetails) error {
+            return func(ctx context.Context, details *analyzer.WorkloadDetails) error {
+                ev := ai.Evidence{Findings: map[string]string{}}
+                for _, f := range details.Findings {
+                    ev.Findings[f.ID] = f.Message
+                }
+                analysis, err := provider.AnalyzeWorkload(ctx, details.Kind, "", ev)
+                if err != nil {
+                    return err
+                }
+                details.DroppedClaims = analysis.Ground(ev)
+                for _, c := range analysis.Opportunities {
+                    details.Opportunities = append(details.Opportunities, analyzer.Claim{Text: c.Text, Findings: c.Findings})
+                }
+                for _, c := range analysis.Cautions {
+                    details.Cautions = append(details.Cautions, analyzer.Claim{Text: c.Text, Findings: c.Findings})
+                }
+                return nil
+            }
+        },
+    }
+}
+
+func TestRunCorpus(t *testing.T) {
+    cases, err := LoadCorpus("../../eval")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    sum := Run(context.Background(), newConfig(t, &citingProvider{}), cases)
+    if sum.Cases != len(cases) || sum.Errors != 0 || sum.ValidRate != 1 {
+        t.Fatalf("unexpected summary: %+v", sum)
+    }
+    for _, res := range sum.Results {
+        if len(res.Found) == 0 || res.Dropped != 1 {
+            t.Errorf("%s: expected findings raised and the made-up claim dropped, got %+v", res.Case, res)
+        }
+        for _, missed := range res.Missed {
+            // Keywords aren't in finding messages; every expected finding is
+            if missed[0] != '"' {
+                t.Errorf("%s: expected finding %s to fire", res.Case, missed)
+            }
+        }
+    }
+    if sum.Precision <= 0 || sum.Precision >= 1 || sum.Recall <= 0 {
+        t.Errorf("unexpected scores: precision %.2f recall %.2f", sum.Precision, sum.Recall)
+    }
+    if sum.PromptVersion != "test" || sum.PromptTokens != 1000*len(cases) || sum.Cost == nil || *sum.Cost != float64(1400*len(cases))/1e6 {
+        t.Errorf("unexpected version, tokens or cost: %+v", sum)
+    }
+}
+
+func TestRunInvalidJSON(t *testing.T) {
+    cases, err := LoadCorpus("../../eval")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    sum := Run(context.Background(), newConfig(t, &citingProvider{invalid: map[string]bool{"daemonset": true}}), cases)
+    if sum.Errors != 1 || sum.ValidRate != float64(len(cases)-1)/float64(len(cases)) {
+        t.Errorf("expected one invalid response, got %+v", sum)
+    }
+    for _, res := range sum.Results {
+        if res.Case == "privileged-daemonset" && (res.ValidJSON || !res.Responded || len(res.Found) != 0 || res.Usage.PromptTokens != 100) {
+            t.Errorf("unexpected result for the invalid response: %+v", res)
+        }
+    }
+}
+
+func TestScore(t *testing.T) {
+    expect := []Expectation{{Finding: "KWA003/app"}, {Keyword: "oom"}, {Finding: "KWA006"}}
+    claims := []analyzer.Claim{
+        {Text: "Privileged container", Findings: []string{"KWA003/app"}},
+        {Text: "Risk of OOMKilled pods"},
+        {Text: "Unrelated"},
+    }
+    supported, found, missed := score(expect, claims)
+    if supported != 2 || len(found) != 2 || len(missed) != 1 || missed[0] != "KWA006" {
+        t.Errorf("got supported=%d found=%v missed=%v", supported, found, missed)
+    }
+}
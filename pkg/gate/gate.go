@@ -0,0 +1,183 @@
+package gate
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "path"
+    "sort"
+    "strings"
+
+    "gopkg.in/yaml.v3"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// Baseline lists findings that are accepted and don't fail the gate. It is
+// meant to be checked in next to the manifests it covers.
+type Baseline struct {
+    Accepted []Entry `yaml:"accepted"`
+}
+
+// Entry accepts a finding ID (e.g. "KWA005/app" or "KWA006") on a workload
+// given as "namespace/kind/name". Both fields take path.Match patterns, so
+// "*" accepts a finding everywhere.
+type Entry struct {
+    ID       string `yaml:"id"`
+    Workload string `yaml:"workload"`
+    Reason   string `yaml:"reason,omitempty"`
+}
+
+// LoadBaseline reads a baseline file. An empty path is an empty baseline.
+func LoadBaseline(file string) (*Baseline, error) {
+    b := &Baseline{}
+    if file == "" {
+        return b, nil
+    }
+    data, err := os.ReadFile(file)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read baseline: %v", err)
+    }
+    if err := yaml.Unmarshal(data, b); err != nil {
+        return nil, fmt.Errorf("failed to parse baseline %s: %v", file, err)
+    }
+    for i, entry := range b.Accepted {
+        if entry.ID == "" {
+            return nil, fmt.Errorf("invalid baseline %s: entry %d has no id", file, i+1)
+        }
+        if entry.Workload == "" {
+            b.Accepted[i].Workload = "*"
+        }
+        if _, err := path.Match(entry.ID, ""); err != nil {
+            return nil, fmt.Errorf("invalid baseline %s: bad id pattern %q", file, entry.ID)
+        }
+        if _, err := path.Match(entry.Workload, ""); err != nil {
+            return nil, fmt.Errorf("invalid baseline %s: bad workload pattern %q", file, entry.Workload)
+        }
+    }
+    return b, nil
+}
+
+// Accepts reports whether the baseline covers finding f on the workload.
+func (b *Baseline) Accepts(workload string, f analyzer.Finding) bool {
+    for _, entry := range b.Accepted {
+        idMatch, _ := path.Match(entry.ID, f.ID)
+        workloadMatch := entry.Workload == "*"
+        if !workloadMatch {
+            workloadMatch, _ = path.Match(entry.Workload, workload)
+        }
+        if idMatch && workloadMatch {
+            return true
+        }
+    }
+    return false
+}
+
+// Write saves the baseline as YAML.
+func (b *Baseline) Write(w io.Writer) error {
+    encoder := yaml.NewEncoder(w)
+    encoder.SetIndent(2)
+    if err := encoder.Encode(b); err != nil {
+        return fmt.Errorf("failed to encode baseline: %v", err)
+    }
+    return encoder.Close()
+}
+
+// WorkloadKey identifies a workload in baselines and summaries.
+func WorkloadKey(details *analyzer.WorkloadDetails) string {
+    return fmt.Sprintf("%s/%s/%s", details.Namespace, details.Kind, details.Deployment)
+}
+
+// Violation is a finding at or above the gate threshold.
+type Violation struct {
+    Workload *analyzer.WorkloadDetails
+    Finding  analyzer.Finding
+}
+
+// Result is the outcome of checking workloads against a threshold.
+type Result struct {
+    Threshold  analyzer.Severity
+    Workloads  int
+    Violations []Violation
+    Baselined  int // findings at or above the threshold accepted by the baseline
+    Below      int // findings under the threshold
+}
+
+func (r *Result) Failed() bool {
+    return len(r.Violations) > 0
+}
+
+// Check gates the workloads' findings on threshold. Findings accepted by
+// the baseline are counted but never fail the gate.
+func Check(workloads []*analyzer.WorkloadDetails, threshold analyzer.Severity, baseline *Baseline) *Result {
+    if baseline == nil {
+        baseline = &Baseline{}
+    }
+    r := &Result{Threshold: threshold, Workloads: len(workloads)}
+    for _, details := range workloads {
+        key := WorkloadKey(details)
+        for _, f := range details.Findings {
+            switch {
+            case !f.Severity.AtLeast(threshold):
+                r.Below++
+            case baseline.Accepts(key, f):
+                r.Baselined++
+            default:
+                r.Violations = append(r.Violations, Violation{Workload: details, Finding: f})
+            }
+        }
+    }
+    sort.SliceStable(r.Violations, func(i, j int) bool {
+        a, b := r.Violations[i].Finding, r.Violations[j].Finding
+        if a.Severity.Rank() != b.Severity.Rank() {
+            return a.Severity.Rank() > b.Severity.Rank()
+        }
+        return WorkloadKey(r.Violations[i].Workload) < WorkloadKey(r.Violations[j].Workload)
+    })
+    return r
+}
+
+// WriteSummary prints one line per violation and a closing status line,
+// short enough to read in a CI log.
+func (r *Result) WriteSummary(w io.Writer) {
+    for _, v := range r.Violations {
+        location := WorkloadKey(v.Workload)
+        if v.Workload.Source != nil {
+            location = fmt.Sprintf("%s:%d (%s)", v.Workload.Source.File, v.Workload.Source.LineOf(v.Finding.Container), location)
+        } else if v.Workload.Cluster != "" {
+            location = fmt.Sprintf("%s/%s", v.Workload.Cluster, location)
+        }
+        fmt.Fprintf(w, "%-8s %-12s %s: %s\n", strings.ToUpper(string(v.Finding.Severity)), v.Finding.ID, location, v.Finding.Message)
+    }
+
+    status := "PASS"
+    if r.Failed() {
+        status = "FAIL"
+    }
+    fmt.Fprintf(w, "%s: %d finding(s) at or above %s in %d workload(s)", status, len(r.Violations), r.Threshold, r.Workloads)
+    var notes []string
+    if r.Baselined > 0 {
+        notes = append(notes, fmt.Sprintf("%d baselined", r.Baselined))
+    }
+    if r.Below > 0 {
+        notes = append(notes, fmt.Sprintf("%d below threshold", r.Below))
+    }
+    if len(notes) > 0 {
+        fmt.Fprintf(w, " (%s)", strings.Join(notes, ", "))
+    }
+    fmt.Fprintln(w)
+}
+
+// NewBaseline accepts every current violation, so a gate can be adopted
+// without fixing existing findings first.
+func (r *Result) NewBaseline(reason string) *Baseline {
+    b := &Baseline{}
+    seen := make(map[string]bool)
+    for _, v := range r.Violations {
+        entry := Entry{ID: v.Finding.ID, Workload: WorkloadKey(v.Workload), Reason: reason}
+        if key := entry.ID + " " + entry.Workload; !seen[key] {
+            seen[key] = true
+            b.Accepted = append(b.Accepted, entry)
+        }
+    }
+    return b
+}
@@ -0,0 +1,94 @@
+package gate
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+func TestBaselineAccepts(t *testing.T) {
+    b := &Baseline{Accepted: []Entry{
+        {ID: "KWA005/app", Workload: "shop/deployment/web"},
+        {ID: "KWA006", Workload: "*"},
+        {ID: "KWA00[12]/*", Workload: "staging/*/*"},
+    }}
+
+    tests := []struct {
+        name     string
+        workload string
+        id       string
+        want     bool
+    }{
+        {"exact", "shop/deployment/web", "KWA005/app", true},
+        {"other container", "shop/deployment/web", "KWA005/sidecar", false},
+        {"other workload", "shop/deployment/api", "KWA005/app", false},
+        {"any workload", "prod/statefulset/db", "KWA006", true},
+        {"id patterns", "staging/deployment/api", "KWA002/app", true},
+        {"id pattern miss", "staging/deployment/api", "KWA003/app", false},
+        {"workload pattern miss", "prod/deployment/api", "KWA001/app", false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := b.Accepts(tt.workload, analyzer.Finding{ID: tt.id}); got != tt.want {
+                t.Errorf("Accepts(%q, %q) = %v, want %v", tt.workload, tt.id, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestLoadBaseline(t *testing.T) {
+    dir := t.TempDir()
+    write := func(name, content string) string {
+        file := filepath.Join(dir, name)
+        if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        return file
+    }
+
+    b, err := LoadBaseline(write("ok.yaml", "accepted:\n  - id: KWA006\n"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(b.Accepted) != 1 || b.Accepted[0].Workload != "*" {
+        t.Errorf("expected a missing workload to default to *, got %+v", b.Accepted)
+    }
+
+    for name, content := range map[string]string{
+        "no-id.yaml":    "accepted:\n  - workload: shop/deployment/web\n",
+        "bad-id.yaml":   "accepted:\n  - id: \"KWA[\"\n",
+        "not-yaml.yaml": "accepted: [\n",
+    } {
+        if _, err := LoadBaseline(write(name, content)); err == nil {
+            t.Errorf("expected %s to be rejected", name)
+        }
+    }
+}
+
+func TestCheck(t *testing.T) {
+    details := &analyzer.WorkloadDetails{
+        Namespace:  "shop",
+        Kind:       "deployment",
+        Deployment: "web",
+        Findings: []analyzer.Finding{
+            {ID: "KWA001/app", Severity: analyzer.SeverityLow},
+            {ID: "KWA005/app", Severity: analyzer.SeverityHigh},
+            {ID: "KWA006", Severity: analyzer.SeverityCritical},
+        },
+    }
+    baseline := &Baseline{Accepted: []Entry{{ID: "KWA006", Workload: "*"}}}
+
+    r := Check([]*analyzer.WorkloadDetails{details}, analyzer.SeverityMedium, baseline)
+    if !r.Failed() || len(r.Violations) != 1 || r.Violations[0].Finding.ID != "KWA005/app" {
+        t.Errorf("expected only KWA005/app to fail the gate, got %+v", r.Violations)
+    }
+    if r.Baselined != 1 || r.Below != 1 {
+        t.Errorf("expected 1 baselined and 1 below threshold, got %d and %d", r.Baselined, r.Below)
+    }
+
+    if r := Check([]*analyzer.WorkloadDetails{details}, analyzer.SeverityCritical, baseline); r.Failed() {
+        t.Errorf("expected the baselined critical finding not to fail the gate, got %+v", r.Violations)
+    }
+}
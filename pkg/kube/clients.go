@@ -0,0 +1,33 @@
+package kube
+
+import (
+    "fmt"
+
+    "k8s.io/client-go/dynamic"
+    "k8s.io/client-go/kubernetes"
+    metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// Clients builds the Kubernetes and metrics API clients for the cluster.
+func (c *Cluster) Clients() (kubernetes.Interface, metricsv.Interface, error) {
+    k8sClient, err := kubernetes.NewForConfig(c.Config)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to create kubernetes client: %v", err)
+    }
+
+    metricsClient, err := metricsv.NewForConfig(c.Config)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to create metrics client: %v", err)
+    }
+    return k8sClient, metricsClient, nil
+}
+
+// DynamicClient builds a client for custom resources such as the
+// operator's WorkloadAnalysis.
+func (c *Cluster) DynamicClient() (dynamic.Interface, error) {
+    client, err := dynamic.NewForConfig(c.Config)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create dynamic client: %v", err)
+    }
+    return client, nil
+}
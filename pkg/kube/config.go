@@ -0,0 +1,76 @@
+package kube
+
+import (
+    "fmt"
+
+    "k8s.io/client-go/rest"
+    "k8s.io/client-go/tools/clientcmd"
+)
+
+// InClusterName is reported as the cluster name when the service account
+// config of the pod we run in is used.
+const InClusterName = "in-cluster"
+
+type ConfigOptions struct {
+    Kubeconfig        string   // explicit kubeconfig path; KUBECONFIG and ~/.kube/config otherwise
+    Context           string   // kubeconfig context, the current context when empty
+    Impersonate       string   // user to impersonate
+    ImpersonateGroups []string // groups to impersonate
+}
+
+// Cluster is a loaded REST config together with the name it is known by.
+type Cluster struct {
+    Name      string // kubeconfig context name or InClusterName
+    Namespace string // default namespace of the context or pod
+    Config    *rest.Config
+}
+
+// LoadConfig resolves a REST config the way kubectl does: explicit path,
+// KUBECONFIG, ~/.kube/config, then the in-cluster service account.
+func LoadConfig(opts ConfigOptions) (*Cluster, error) {
+    rules := clientcmd.NewDefaultClientConfigLoadingRules()
+    if opts.Kubeconfig != "" {
+        rules.ExplicitPath = opts.Kubeconfig
+    }
+
+    overrides := &clientcmd.ConfigOverrides{CurrentContext: opts.Context}
+    overrides.AuthInfo.Impersonate = opts.Impersonate
+    overrides.AuthInfo.ImpersonateGroups = opts.ImpersonateGroups
+
+    clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+    config, err := clientConfig.ClientConfig()
+    if err != nil {
+        return nil, fmt.Errorf("failed to load kubeconfig: %v", err)
+    }
+
+    // The in-cluster fallback ignores auth overrides, so apply them here
+    if opts.Impersonate != "" {
+        config.Impersonate.UserName = opts.Impersonate
+        config.Impersonate.Groups = opts.ImpersonateGroups
+    }
+
+    namespace, _, err := clientConfig.Namespace()
+    if err != nil || namespace == "" {
+        namespace = "default"
+    }
+
+    return &Cluster{
+        Name:      contextName(clientConfig, opts.Context),
+        Namespace: namespace,
+        Config:    config,
+    }, nil
+}
+
+func contextName(clientConfig clientcmd.ClientConfig, override string) string {
+    raw, err := clientConfig.RawConfig()
+    if err != nil || len(raw.Contexts) == 0 {
+        return InClusterName
+    }
+    if override != "" {
+        return override
+    }
+    if raw.CurrentContext != "" {
+        return raw.CurrentContext
+    }
+    return InClusterName
+}
@@ -0,0 +1,177 @@
+package kube
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "k8s.io/client-go/tools/clientcmd"
+    clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: eu
+  cluster:
+    server: https://eu.example.com
+- name: us
+  cluster:
+    server: https://us.example.com
+users:
+- name: admin
+  user:
+    token: secret-token
+contexts:
+- name: prod-eu
+  context:
+    cluster: eu
+    user: admin
+    namespace: payments
+- name: prod-us
+  context:
+    cluster: us
+    user: admin
+current-context: prod-eu
+`
+
+func writeKubeconfig(t *testing.T, content string) string {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), "config")
+    if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    // Keep the environment's own kubeconfig out of the test
+    t.Setenv("KUBECONFIG", "")
+    t.Setenv("HOME", t.TempDir())
+    return path
+}
+
+func TestLoadConfig(t *testing.T) {
+    path := writeKubeconfig(t, kubeconfig)
+
+    tests := []struct {
+        name          string
+        opts          ConfigOptions
+        wantName      string
+        wantNamespace string
+        wantHost      string
+        wantUser      string
+        wantGroups    string
+    }{
+        {
+            name:          "current context",
+            opts:          ConfigOptions{Kubeconfig: path},
+            wantName:      "prod-eu",
+            wantNamespace: "payments",
+            wantHost:      "https://eu.example.com",
+        },
+        {
+            name:          "context override",
+            opts:          ConfigOptions{Kubeconfig: path, Context: "prod-us"},
+            wantName:      "prod-us",
+            wantNamespace: "default",
+            wantHost:      "https://us.example.com",
+        },
+        {
+            name:          "impersonation",
+            opts:          ConfigOptions{Kubeconfig: path, Impersonate: "jane", ImpersonateGroups: []string{"sre", "oncall"}},
+            wantName:      "prod-eu",
+            wantNamespace: "payments",
+            wantHost:      "https://eu.example.com",
+            wantUser:      "jane",
+            wantGroups:    "sre,oncall",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            cluster, err := LoadConfig(tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if cluster.Name != tt.wantName || cluster.Namespace != tt.wantNamespace || cluster.Config.Host != tt.wantHost {
+                t.Errorf("expected %s/%s at %s, got %s/%s at %s",
+                    tt.wantName, tt.wantNamespace, tt.wantHost, cluster.Name, cluster.Namespace, cluster.Config.Host)
+            }
+            if cluster.Config.BearerToken != "secret-token" {
+                t.Errorf("expected the context's credentials, got token %q", cluster.Config.BearerToken)
+            }
+            impersonate := cluster.Config.Impersonate
+            if impersonate.UserName != tt.wantUser || strings.Join(impersonate.Groups, ",") != tt.wantGroups {
+                t.Errorf("expected to impersonate %q in %q, got %q in %v", tt.wantUser, tt.wantGroups, impersonate.UserName, impersonate.Groups)
+            }
+        })
+    }
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+    path := writeKubeconfig(t, kubeconfig)
+
+    tests := []struct {
+        name string
+        opts ConfigOptions
+        want string
+    }{
+        {"unknown context", ConfigOptions{Kubeconfig: path, Context: "staging"}, `context "staging" does not exist`},
+        {"missing file", ConfigOptions{Kubeconfig: filepath.Join(t.TempDir(), "missing")}, "failed to load kubeconfig"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := LoadConfig(tt.opts)
+            if err == nil || !strings.Contains(err.Error(), tt.want) {
+                t.Errorf("expected an error containing %q, got %v", tt.want, err)
+            }
+        })
+    }
+}
+
+func TestContextName(t *testing.T) {
+    withContexts := clientcmdapi.Config{
+        Contexts:       map[string]*clientcmdapi.Context{"prod-eu": {}, "prod-us": {}},
+        CurrentContext: "prod-eu",
+    }
+    noCurrent := withContexts
+    noCurrent.CurrentContext = ""
+
+    tests := []struct {
+        name     string
+        config   clientcmdapi.Config
+        override string
+        want     string
+    }{
+        {"current context", withContexts, "", "prod-eu"},
+        {"override", withContexts, "prod-us", "prod-us"},
+        {"no current context", noCurrent, "", InClusterName},
+        // Without a kubeconfig the in-cluster service account is used
+        {"no kubeconfig", clientcmdapi.Config{}, "", InClusterName},
+        {"no kubeconfig with override", clientcmdapi.Config{}, "prod-us", InClusterName},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            clientConfig := clientcmd.NewDefaultClientConfig(tt.config, &clientcmd.ConfigOverrides{})
+            if got := contextName(clientConfig, tt.override); got != tt.want {
+                t.Errorf("expected %q, got %q", tt.want, got)
+            }
+        })
+    }
+}
+
+func TestClients(t *testing.T) {
+    path := writeKubeconfig(t, kubeconfig)
+    cluster, err := LoadConfig(ConfigOptions{Kubeconfig: path})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, _, err := cluster.Clients(); err != nil {
+        t.Errorf("Clients() error = %v", err)
+    }
+    if _, err := cluster.DynamicClient(); err != nil {
+        t.Errorf("DynamicClient() error = %v", err)
+    }
+
+    cluster.Config.Host = "://bad"
+    if _, _, err := cluster.Clients(); err == nil || !strings.Contains(err.Error(), "failed to create kubernetes client") {
+        t.Errorf("expected an invalid host to fail, got %v", err)
+    }
+}
@@ -0,0 +1,129 @@
+// Package logging sets up kwa's structured logs and ties the Kubernetes and
+// AI calls made for one run or request together by a request ID.
+package logging
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "log/slog"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// Log formats.
+const (
+    FormatText = "text"
+    FormatJSON = "json"
+)
+
+// New returns a logger writing to w at level ("debug", "info", "warn" or
+// "error") in format. Records logged with a context carry its request ID.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+    lvl, err := ParseLevel(level)
+    if err != nil {
+        return nil, err
+    }
+    opts := &slog.HandlerOptions{Level: lvl}
+    var h slog.Handler
+    switch format {
+    case FormatText:
+        h = slog.NewTextHandler(w, opts)
+    case FormatJSON:
+        h = slog.NewJSONHandler(w, opts)
+    default:
+        return nil, fmt.Errorf("unsupported log format %q (want text, json)", format)
+    }
+    return slog.New(handler{h}), nil
+}
+
+func ParseLevel(level string) (slog.Level, error) {
+    var lvl slog.Level
+    if err := lvl.UnmarshalText([]byte(level)); err != nil || strings.ContainsAny(level, "+-") {
+        return 0, fmt.Errorf("unsupported log level %q (want debug, info, warn, error)", level)
+    }
+    return lvl, nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose logs and calls are tagged with id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of ctx, or "" if it has none.
+func RequestID(ctx context.Context) string {
+    id, _ := ctx.Value(requestIDKey{}).(string)
+    return id
+}
+
+// NewRequestID returns a random ID, short enough to grep for.
+func NewRequestID() string {
+    b := make([]byte, 6)
+    rand.Read(b)
+    return hex.EncodeToString(b)
+}
+
+// handler adds the request ID of the context to every record.
+type handler struct {
+    slog.Handler
+}
+
+func (h handler) Handle(ctx context.Context, r slog.Record) error {
+    if id := RequestID(ctx); id != "" {
+        r.AddAttrs(slog.String("request_id", id))
+    }
+    return h.Handler.Handle(ctx, r)
+}
+
+func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+    return handler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h handler) WithGroup(name string) slog.Handler {
+    return handler{h.Handler.WithGroup(name)}
+}
+
+// Phase starts timing a step of the pipeline. Call the returned function,
+// usually with defer, when the step is done to log its duration.
+func Phase(ctx context.Context, name string, attrs ...any) func() {
+    start := time.Now()
+    return func() {
+        slog.DebugContext(ctx, "phase done", append([]any{"phase", name, "duration", time.Since(start)}, attrs...)...)
+    }
+}
+
+// Transport logs every call made through next at debug level, tagged with
+// service (e.g. "kube" or "ai") and the request ID of the call's context.
+func Transport(service string, next http.RoundTripper) http.RoundTripper {
+    if next == nil {
+        next = http.DefaultTransport
+    }
+    return &transport{service: service, next: next}
+}
+
+type transport struct {
+    service string
+    next    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+    start := time.Now()
+    resp, err := t.next.RoundTrip(req)
+    attrs := []any{
+        "service", t.service,
+        "method", req.Method,
+        "url", req.URL.Redacted(),
+        "duration", time.Since(start),
+    }
+    if err != nil {
+        slog.DebugContext(req.Context(), "http call failed", append(attrs, "error", err)...)
+        return nil, err
+    }
+    slog.DebugContext(req.Context(), "http call", append(attrs, "status", resp.StatusCode)...)
+    return resp, nil
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func TestRequestIDs(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusNotFound)
+    }))
+    defer srv.Close()
+
+    var buf bytes.Buffer
+    logger, err := New(&buf, "debug", FormatJSON)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer slog.SetDefault(slog.Default())
+    slog.SetDefault(logger)
+
+    ctx := WithRequestID(context.Background(), "abc123")
+    done := Phase(ctx, "lookup", "name", "web")
+    req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/apis", nil)
+    resp, err := (&http.Client{Transport: Transport("kube", nil)}).Do(req)
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    done()
+    slog.Info("no context")
+
+    var records []map[string]interface{}
+    for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+        var r map[string]interface{}
+        if err := json.Unmarshal([]byte(line), &r); err != nil {
+            t.Fatalf("invalid JSON log line %q: %v", line, err)
+        }
+        records = append(records, r)
+    }
+    if len(records) != 3 {
+        t.Fatalf("expected 3 records, got %d:\n%s", len(records), buf.String())
+    }
+    call, phase, plain := records[0], records[1], records[2]
+    if call["msg"] != "http call" || call["service"] != "kube" || call["status"] != float64(404) || call["request_id"] != "abc123" {
+        t.Errorf("unexpected call record: %v", call)
+    }
+    if phase["phase"] != "lookup" || phase["name"] != "web" || phase["request_id"] != "abc123" || phase["duration"] == nil {
+        t.Errorf("unexpected phase record: %v", phase)
+    }
+    if _, ok := plain["request_id"]; ok {
+        t.Errorf("expected no request ID without a context, got %v", plain)
+    }
+}
+
+func TestNew(t *testing.T) {
+    for _, level := range []string{"debug", "INFO", "warn", "error"} {
+        if _, err := New(&bytes.Buffer{}, level, FormatText); err != nil {
+            t.Errorf("level %s: %v", level, err)
+        }
+    }
+    if _, err := New(&bytes.Buffer{}, "verbose", FormatText); err == nil {
+        t.Error("expected an unknown level to fail")
+    }
+    if _, err := New(&bytes.Buffer{}, "info+2", FormatText); err == nil {
+        t.Error("expected a level offset to fail")
+    }
+    if _, err := New(&bytes.Buffer{}, "info", "logfmt"); err == nil {
+        t.Error("expected an unknown format to fail")
+    }
+}
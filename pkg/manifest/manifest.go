@@ -0,0 +1,182 @@
+package manifest
+
+import (
+    "bytes"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "strings"
+
+    "gopkg.in/yaml.v3"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/client-go/kubernetes/scheme"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// Document is one YAML document of a manifest file.
+type Document struct {
+    File string
+    Line int // 1-based line the document starts on
+    Data []byte
+}
+
+// Load reads workloads from manifest files or directories. Directories are
+// walked for .yaml, .yml and .json files. Documents of other kinds are
+// skipped; documents that don't parse are an error.
+func Load(paths []string, namespace string) ([]*analyzer.WorkloadDetails, error) {
+    files, err := expand(paths)
+    if err != nil {
+        return nil, err
+    }
+
+    var workloads []*analyzer.WorkloadDetails
+    for _, file := range files {
+        data, err := os.ReadFile(file)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read manifest: %v", err)
+        }
+        for _, doc := range SplitDocuments(file, data) {
+            details, err := Decode(doc, namespace)
+            if err != nil {
+                return nil, err
+            }
+            if details != nil {
+                workloads = append(workloads, details)
+            }
+        }
+    }
+    return workloads, nil
+}
+
+// Decode turns a document into workload details, or nil when it isn't a
+// Deployment, StatefulSet or DaemonSet.
+func Decode(doc Document, namespace string) (*analyzer.WorkloadDetails, error) {
+    obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(doc.Data, nil, nil)
+    if err != nil {
+        // Not a Kubernetes object, or a kind client-go doesn't know (CRDs)
+        if runtime.IsMissingKind(err) || runtime.IsNotRegisteredError(err) {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("%s:%d: failed to decode manifest: %v", doc.File, doc.Line, err)
+    }
+    details, ok := analyzer.DetailsFromObject(obj, namespace)
+    if !ok {
+        return nil, nil
+    }
+    details.Source = &analyzer.Source{
+        File:       doc.File,
+        Line:       doc.Line,
+        Containers: containerLines(doc),
+    }
+    return details, nil
+}
+
+// containerLines finds the line of each container in the pod template so
+// findings can point at the container rather than the whole document.
+func containerLines(doc Document) map[string]int {
+    var root yaml.Node
+    if err := yaml.Unmarshal(doc.Data, &root); err != nil || len(root.Content) == 0 {
+        return nil
+    }
+    node := root.Content[0]
+    for _, key := range []string{"spec", "template", "spec", "containers"} {
+        if node = mappingValue(node, key); node == nil {
+            return nil
+        }
+    }
+    if node.Kind != yaml.SequenceNode {
+        return nil
+    }
+
+    lines := make(map[string]int)
+    for _, item := range node.Content {
+        if name := mappingValue(item, "name"); name != nil {
+            lines[name.Value] = doc.Line + item.Line - 1
+        }
+    }
+    return lines
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+    if node.Kind != yaml.MappingNode {
+        return nil
+    }
+    for i := 0; i+1 < len(node.Content); i += 2 {
+        if node.Content[i].Value == key {
+            return node.Content[i+1]
+        }
+    }
+    return nil
+}
+
+// SplitDocuments splits a multi-document YAML file on "---" separators,
+// keeping the line each document starts on. Empty documents are dropped.
+func SplitDocuments(file string, data []byte) []Document {
+    var docs []Document
+    lines := bytes.SplitAfter(data, []byte("\n"))
+    start := 1
+    var current []byte
+
+    flush := func() {
+        if len(bytes.TrimSpace(stripComments(current))) > 0 {
+            docs = append(docs, Document{File: file, Line: start, Data: current})
+        }
+        current = nil
+    }
+
+    for i, line := range lines {
+        trimmed := bytes.TrimRight(line, " \t\r\n")
+        if bytes.Equal(trimmed, []byte("---")) || bytes.HasPrefix(trimmed, []byte("--- ")) {
+            flush()
+            start = i + 2
+            continue
+        }
+        if current == nil {
+            start = i + 1
+        }
+        current = append(current, line...)
+    }
+    flush()
+    return docs
+}
+
+func stripComments(data []byte) []byte {
+    var out []byte
+    for _, line := range bytes.Split(data, []byte("\n")) {
+        if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+            out = append(out, line...)
+        }
+    }
+    return out
+}
+
+func expand(paths []string) ([]string, error) {
+    var files []string
+    for _, path := range paths {
+        info, err := os.Stat(path)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read manifest: %v", err)
+        }
+        if !info.IsDir() {
+            files = append(files, path)
+            continue
+        }
+        err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+            if err != nil {
+                return err
+            }
+            switch strings.ToLower(filepath.Ext(p)) {
+            case ".yaml", ".yml", ".json":
+                if !d.IsDir() {
+                    files = append(files, p)
+                }
+            }
+            return nil
+        })
+        if err != nil {
+            return nil, fmt.Errorf("failed to read manifests: %v", err)
+        }
+    }
+    return files, nil
+}
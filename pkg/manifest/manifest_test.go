@@ -0,0 +1,112 @@
+package manifest
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+const multiDoc = `# leading comment
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: app
+          image: web:1.0
+        - name: sidecar
+          image: proxy:1.0
+--- # only a comment follows
+# nothing here
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+    spec:
+      containers:
+        - name: postgres
+          image: postgres:16
+`
+
+func TestSplitDocuments(t *testing.T) {
+    docs := SplitDocuments("all.yaml", []byte(multiDoc))
+
+    wantLines := []int{1, 7, 28}
+    if len(docs) != len(wantLines) {
+        t.Fatalf("expected %d documents, got %d", len(wantLines), len(docs))
+    }
+    for i, doc := range docs {
+        if doc.File != "all.yaml" || doc.Line != wantLines[i] {
+            t.Errorf("document %d: expected all.yaml:%d, got %s:%d", i, wantLines[i], doc.File, doc.Line)
+        }
+    }
+}
+
+func TestLoadLineMapping(t *testing.T) {
+    file := filepath.Join(t.TempDir(), "all.yaml")
+    if err := os.WriteFile(file, []byte(multiDoc), 0o644); err != nil {
+        t.Fatal(err)
+    }
+
+    workloads, err := Load([]string{filepath.Dir(file)}, "shop")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(workloads) != 2 {
+        t.Fatalf("expected the deployment and statefulset, got %d workloads", len(workloads))
+    }
+
+    tests := []struct {
+        workload  int
+        container string
+        want      int
+    }{
+        {0, "app", 21},
+        {0, "sidecar", 23},
+        {0, "", 7},
+        {1, "postgres", 42},
+        {1, "missing", 28},
+    }
+    for _, tt := range tests {
+        details := workloads[tt.workload]
+        if details.Namespace != "shop" {
+            t.Errorf("%s: expected the default namespace, got %q", details.Deployment, details.Namespace)
+        }
+        if got := details.Source.LineOf(tt.container); got != tt.want {
+            t.Errorf("%s: line of %q = %d, want %d", details.Deployment, tt.container, got, tt.want)
+        }
+    }
+}
+
+func TestLoadInvalidManifest(t *testing.T) {
+    file := filepath.Join(t.TempDir(), "bad.yaml")
+    if err := os.WriteFile(file, []byte("apiVersion: apps/v1\nkind: Deployment\nspec: [\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := Load([]string{file}, "default"); err == nil {
+        t.Error("expected a document that doesn't parse to be an error")
+    }
+}
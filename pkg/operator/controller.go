@@ -0,0 +1,465 @@
+package operator
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "go.opentelemetry.io/otel/attribute"
+    corev1 "k8s.io/api/core/v1"
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    "k8s.io/apimachinery/pkg/api/meta"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+    "k8s.io/apimachinery/pkg/labels"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/apimachinery/pkg/types"
+    "k8s.io/client-go/dynamic"
+    "k8s.io/client-go/informers"
+    "k8s.io/client-go/kubernetes"
+    "k8s.io/client-go/kubernetes/scheme"
+    typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+    "k8s.io/client-go/tools/cache"
+    "k8s.io/client-go/tools/record"
+    "k8s.io/client-go/util/workqueue"
+    metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/logging"
+    "k8s-workload-analyzer/pkg/tracing"
+)
+
+// Config is what the operator needs to analyze workloads in one cluster.
+type Config struct {
+    Cluster    string
+    Namespaces []string // empty watches all namespaces
+    Selector   string   // label selector for workloads; empty selects all
+
+    Client  kubernetes.Interface
+    Metrics metricsv.Interface // nil disables usage collection
+    Dynamic dynamic.Interface  // writes WorkloadAnalysis resources
+
+    // Recorder emits events on analyzed workloads. Nil records them
+    // through the Kubernetes API.
+    Recorder record.EventRecorder
+
+    // Finish fills in findings and cost, like the CLI does for every
+    // analyzed workload.
+    Finish func(ctx context.Context, details *analyzer.WorkloadDetails, cluster string)
+
+    // Interval is how often every workload is re-analyzed, on top of
+    // analyses triggered by spec changes.
+    Interval time.Duration
+
+    // Timeout bounds one analysis; 0 disables.
+    Timeout time.Duration
+
+    // Workers is the number of workloads analyzed concurrently.
+    Workers int
+}
+
+// Controller keeps a WorkloadAnalysis up to date for every selected
+// workload. Workloads are queued by informers and processed through a rate
+// limited queue, so a burst of changes or failing analyses doesn't hammer
+// the API server or metrics-server.
+type Controller struct {
+    cfg       Config
+    queue     workqueue.TypedRateLimitingInterface[string]
+    factories []informers.SharedInformerFactory
+    synced    []cache.InformerSynced
+    recorder  record.EventRecorder
+    stop      func()
+    now       func() time.Time
+}
+
+func New(cfg Config) (*Controller, error) {
+    if _, err := labels.Parse(cfg.Selector); err != nil {
+        return nil, fmt.Errorf("invalid label selector %q: %v", cfg.Selector, err)
+    }
+    if cfg.Workers < 1 {
+        cfg.Workers = 1
+    }
+
+    c := &Controller{
+        cfg: cfg,
+        queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+            workqueue.DefaultTypedControllerRateLimiter[string](),
+            workqueue.TypedRateLimitingQueueConfig[string]{Name: "workloadanalysis"},
+        ),
+        recorder: cfg.Recorder,
+        stop:     func() {},
+        now:      time.Now,
+    }
+    if c.recorder == nil {
+        broadcaster := record.NewBroadcaster()
+        broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: cfg.Client.CoreV1().Events("")})
+        c.recorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "kwa-operator"})
+        c.stop = broadcaster.Shutdown
+    }
+
+    namespaces := cfg.Namespaces
+    if len(namespaces) == 0 {
+        namespaces = []string{metav1.NamespaceAll}
+    }
+    for _, ns := range namespaces {
+        factory := informers.NewSharedInformerFactoryWithOptions(cfg.Client, cfg.Interval,
+            informers.WithNamespace(ns),
+            informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+                opts.LabelSelector = cfg.Selector
+            }),
+        )
+        for workloadType, informer := range map[string]cache.SharedIndexInformer{
+            "deployment":  factory.Apps().V1().Deployments().Informer(),
+            "statefulset": factory.Apps().V1().StatefulSets().Informer(),
+            "daemonset":   factory.Apps().V1().DaemonSets().Informer(),
+        } {
+            if _, err := informer.AddEventHandler(c.handler(workloadType)); err != nil {
+                return nil, fmt.Errorf("failed to watch %ss: %v", workloadType, err)
+            }
+            c.synced = append(c.synced, informer.HasSynced)
+        }
+        c.factories = append(c.factories, factory)
+    }
+    return c, nil
+}
+
+// handler queues new workloads, spec changes and periodic resyncs. Updates
+// that only touch metadata or status, like the operator's own annotations,
+// are ignored.
+func (c *Controller) handler(workloadType string) cache.ResourceEventHandler {
+    return cache.ResourceEventHandlerFuncs{
+        AddFunc: func(obj interface{}) {
+            c.enqueue(workloadType, obj)
+        },
+        UpdateFunc: func(oldObj, newObj interface{}) {
+            if needsAnalysis(oldObj, newObj) {
+                c.enqueue(workloadType, newObj)
+            }
+        },
+    }
+}
+
+// needsAnalysis reports whether an update is a resync, which the informer
+// delivers with an unchanged resource version, or a spec change.
+func needsAnalysis(oldObj, newObj interface{}) bool {
+    before, err := meta.Accessor(oldObj)
+    if err != nil {
+        return false
+    }
+    after, err := meta.Accessor(newObj)
+    if err != nil {
+        return false
+    }
+    return before.GetResourceVersion() == after.GetResourceVersion() || before.GetGeneration() != after.GetGeneration()
+}
+
+func (c *Controller) enqueue(workloadType string, obj interface{}) {
+    o, err := meta.Accessor(obj)
+    if err != nil {
+        return
+    }
+    c.queue.AddRateLimited(workloadKey(workloadType, o.GetNamespace(), o.GetName()))
+}
+
+func workloadKey(workloadType, namespace, name string) string {
+    return workloadType + "/" + namespace + "/" + name
+}
+
+func splitKey(key string) (workloadType, namespace, name string, err error) {
+    parts := strings.Split(key, "/")
+    if len(parts) != 3 {
+        return "", "", "", fmt.Errorf("invalid key %q", key)
+    }
+    return parts[0], parts[1], parts[2], nil
+}
+
+// Run starts the informers and workers and blocks until ctx is done.
+func (c *Controller) Run(ctx context.Context) error {
+    defer c.stop()
+    defer c.queue.ShutDown()
+
+    for _, factory := range c.factories {
+        factory.Start(ctx.Done())
+    }
+    if !cache.WaitForCacheSync(ctx.Done(), c.synced...) {
+        return fmt.Errorf("failed to sync workload informers")
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < c.cfg.Workers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for c.processNext(ctx) {
+            }
+        }()
+    }
+
+    <-ctx.Done()
+    c.queue.ShutDown()
+    wg.Wait()
+    for _, factory := range c.factories {
+        factory.Shutdown()
+    }
+    return nil
+}
+
+func (c *Controller) processNext(ctx context.Context) bool {
+    key, shutdown := c.queue.Get()
+    if shutdown {
+        return false
+    }
+    defer c.queue.Done(key)
+
+    id := logging.NewRequestID()
+    ctx = logging.WithRequestID(ctx, id)
+    ctx, span := tracing.StartRoot(ctx, "reconcile", tracing.RequestID.String(id), attribute.String("kwa.key", key))
+    start := time.Now()
+    err := c.reconcile(ctx, key)
+    tracing.End(span, err)
+    if err != nil {
+        if ctx.Err() == nil {
+            slog.WarnContext(ctx, "failed to analyze workload, retrying", "key", key, "error", err)
+            c.queue.AddRateLimited(key)
+        }
+        return true
+    }
+    slog.DebugContext(ctx, "analyzed workload", "key", key, "duration", time.Since(start))
+    c.queue.Forget(key)
+    return true
+}
+
+// reconcile analyzes one workload and writes the result to its
+// WorkloadAnalysis, its annotations and events.
+func (c *Controller) reconcile(ctx context.Context, key string) error {
+    workloadType, namespace, name, err := splitKey(key)
+    if err != nil {
+        return err
+    }
+    if c.cfg.Timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+        defer cancel()
+    }
+
+    target, err := c.getTarget(ctx, workloadType, namespace, name)
+    if apierrors.IsNotFound(err) {
+        // Deleted; the WorkloadAnalysis is garbage collected with its owner
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("failed to get %s: %v", workloadType, err)
+    }
+    obj, err := meta.Accessor(target)
+    if err != nil {
+        return err
+    }
+
+    details, err := analyzer.AnalyzeWorkload(ctx, c.cfg.Client, c.cfg.Metrics, namespace, workloadType, name)
+    if err != nil {
+        return err
+    }
+    details.Cluster = c.cfg.Cluster
+    if c.cfg.Finish != nil {
+        c.cfg.Finish(ctx, details, c.cfg.Cluster)
+    }
+
+    status := newStatus(details, obj.GetGeneration(), c.now())
+    previous, err := c.writeStatus(ctx, workloadType, target, status)
+    if err != nil {
+        return err
+    }
+    if err := c.annotate(ctx, workloadType, obj, status); err != nil {
+        return err
+    }
+    c.recordEvents(target, previous, status)
+    return nil
+}
+
+func (c *Controller) getTarget(ctx context.Context, workloadType, namespace, name string) (runtime.Object, error) {
+    apps := c.cfg.Client.AppsV1()
+    switch workloadType {
+    case "deployment":
+        return apps.Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+    case "statefulset":
+        return apps.StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+    case "daemonset":
+        return apps.DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+    }
+    return nil, fmt.Errorf("unsupported workload type: %s", workloadType)
+}
+
+// writeStatus creates the WorkloadAnalysis of target if needed and updates
+// its status. It returns the previous status, nil on the first analysis.
+func (c *Controller) writeStatus(ctx context.Context, workloadType string, target runtime.Object, status Status) (*Status, error) {
+    obj, _ := meta.Accessor(target)
+    kind := kindOf(workloadType)
+    resources := c.cfg.Dynamic.Resource(Resource).Namespace(obj.GetNamespace())
+    name := ResourceName(workloadType, obj.GetName())
+
+    var previous *Status
+    current, err := resources.Get(ctx, name, metav1.GetOptions{})
+    switch {
+    case apierrors.IsNotFound(err):
+        current, err = resources.Create(ctx, newResource(name, kind, obj), metav1.CreateOptions{})
+        if err != nil {
+            return nil, fmt.Errorf("failed to create %s %s: %v", Kind, name, err)
+        }
+    case err != nil:
+        return nil, fmt.Errorf("failed to get %s %s: %v", Kind, name, err)
+    default:
+        if existing, ok, _ := unstructured.NestedMap(current.Object, "status"); ok {
+            if previous, err = statusFromUnstructured(existing); err != nil {
+                return nil, err
+            }
+        }
+    }
+
+    fields, err := status.toUnstructured()
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode status: %v", err)
+    }
+    current.Object["status"] = fields
+    if _, err := resources.UpdateStatus(ctx, current, metav1.UpdateOptions{}); err != nil {
+        return nil, fmt.Errorf("failed to update %s %s: %v", Kind, name, err)
+    }
+    return previous, nil
+}
+
+// newResource builds a WorkloadAnalysis owned by its target, so it is
+// deleted along with the workload.
+func newResource(name, kind string, target metav1.Object) *unstructured.Unstructured {
+    u := &unstructured.Unstructured{Object: map[string]interface{}{
+        "spec": map[string]interface{}{
+            "targetRef": map[string]interface{}{
+                "apiVersion": "apps/v1",
+                "kind":       kind,
+                "name":       target.GetName(),
+            },
+        },
+    }}
+    u.SetAPIVersion(Group + "/" + Version)
+    u.SetKind(Kind)
+    u.SetName(name)
+    u.SetNamespace(target.GetNamespace())
+    u.SetOwnerReferences([]metav1.OwnerReference{{
+        APIVersion: "apps/v1",
+        Kind:       kind,
+        Name:       target.GetName(),
+        UID:        target.GetUID(),
+    }})
+    return u
+}
+
+func kindOf(workloadType string) string {
+    switch workloadType {
+    case "statefulset":
+        return "StatefulSet"
+    case "daemonset":
+        return "DaemonSet"
+    }
+    return "Deployment"
+}
+
+// annotate sets the efficiency and finding summary on the workload. It only
+// patches when they changed, to keep writes to the workload rare.
+func (c *Controller) annotate(ctx context.Context, workloadType string, obj metav1.Object, status Status) error {
+    want := map[string]string{
+        AnnotationEfficiency: status.EfficiencyRate,
+        AnnotationFindings:   findingSummary(status.Findings),
+    }
+    current := obj.GetAnnotations()
+    changed := false
+    for k, v := range want {
+        if current[k] != v {
+            changed = true
+        }
+    }
+    if !changed {
+        return nil
+    }
+
+    patch, err := json.Marshal(map[string]interface{}{
+        "metadata": map[string]interface{}{"annotations": want},
+    })
+    if err != nil {
+        return err
+    }
+    apps := c.cfg.Client.AppsV1()
+    namespace, name := obj.GetNamespace(), obj.GetName()
+    switch workloadType {
+    case "deployment":
+        _, err = apps.Deployments(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+    case "statefulset":
+        _, err = apps.StatefulSets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+    case "daemonset":
+        _, err = apps.DaemonSets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+    }
+    if err != nil {
+        return fmt.Errorf("failed to annotate %s %s: %v", workloadType, name, err)
+    }
+    return nil
+}
+
+// findingSummary counts findings by severity, e.g. "1 critical, 2 medium",
+// or "none".
+func findingSummary(findings []FindingStatus) string {
+    counts := make(map[analyzer.Severity]int)
+    for _, f := range findings {
+        counts[analyzer.Severity(f.Severity)]++
+    }
+    severities := make([]analyzer.Severity, 0, len(counts))
+    for s := range counts {
+        severities = append(severities, s)
+    }
+    sort.Slice(severities, func(i, j int) bool { return severities[i].Rank() > severities[j].Rank() })
+
+    var parts []string
+    for _, s := range severities {
+        parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+    }
+    if len(parts) == 0 {
+        return "none"
+    }
+    return strings.Join(parts, ", ")
+}
+
+// recordEvents emits an event on the first analysis and whenever a finding
+// appears or is resolved, rather than on every periodic analysis.
+func (c *Controller) recordEvents(target runtime.Object, previous *Status, status Status) {
+    if previous == nil {
+        c.recorder.Eventf(target, corev1.EventTypeNormal, "Analyzed", "Efficiency %s, %s", status.EfficiencyRate, pluralFindings(len(status.Findings)))
+    }
+
+    before := make(map[string]bool)
+    if previous != nil {
+        for _, f := range previous.Findings {
+            before[f.ID] = true
+        }
+    }
+    after := make(map[string]bool)
+    for _, f := range status.Findings {
+        after[f.ID] = true
+        if !before[f.ID] {
+            c.recorder.Eventf(target, corev1.EventTypeWarning, "FindingDetected", "[%s] %s: %s", f.Severity, f.ID, f.Message)
+        }
+    }
+    if previous != nil {
+        for _, f := range previous.Findings {
+            if !after[f.ID] {
+                c.recorder.Eventf(target, corev1.EventTypeNormal, "FindingResolved", "%s resolved", f.ID)
+            }
+        }
+    }
+}
+
+func pluralFindings(n int) string {
+    if n == 1 {
+        return "1 finding"
+    }
+    return fmt.Sprintf("%d findings", n)
+}
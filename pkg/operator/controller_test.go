@@ -0,0 +1,246 @@
+package operator
+
+import (
+    "context"
+    "strings"
+    "testing"
+    "time"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/resource"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/apimachinery/pkg/runtime/schema"
+    "k8s.io/apimachinery/pkg/types"
+    dynamicfake "k8s.io/client-go/dynamic/fake"
+    "k8s.io/client-go/kubernetes/fake"
+    "k8s.io/client-go/tools/record"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/rules"
+)
+
+type testController struct {
+    *Controller
+    client   *fake.Clientset
+    dynamic  *dynamicfake.FakeDynamicClient
+    recorder *record.FakeRecorder
+}
+
+func newTestController(t *testing.T, cfg Config, objects ...runtime.Object) *testController {
+    t.Helper()
+    engine, err := rules.NewEngine(rules.DefaultOptions())
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tc := &testController{
+        client: fake.NewSimpleClientset(objects...),
+        dynamic: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+            map[schema.GroupVersionResource]string{Resource: Kind + "List"}),
+        recorder: record.NewFakeRecorder(100),
+    }
+    cfg.Cluster = "test"
+    cfg.Client = tc.client
+    cfg.Dynamic = tc.dynamic
+    cfg.Recorder = tc.recorder
+    cfg.Finish = func(ctx context.Context, details *analyzer.WorkloadDetails, cluster string) {
+        details.Findings = engine.Evaluate(details)
+    }
+
+    c, err := New(cfg)
+    if err != nil {
+        t.Fatal(err)
+    }
+    c.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+    tc.Controller = c
+    return tc
+}
+
+func newDeployment(name string, labels map[string]string, resources corev1.ResourceRequirements) *appsv1.Deployment {
+    replicas := int32(2)
+    return &appsv1.Deployment{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("uid-" + name), Labels: labels, Generation: 1},
+        Spec: appsv1.DeploymentSpec{
+            Replicas: &replicas,
+            Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+            Template: corev1.PodTemplateSpec{
+                ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+                Spec: corev1.PodSpec{Containers: []corev1.Container{{
+                    Name:           "app",
+                    Image:          "web:1.0",
+                    Resources:      resources,
+                    ReadinessProbe: &corev1.Probe{},
+                }}},
+            },
+        },
+    }
+}
+
+func (tc *testController) analysis(t *testing.T, name string) (*unstructured.Unstructured, *Status) {
+    t.Helper()
+    obj, err := tc.dynamic.Resource(Resource).Namespace("default").Get(context.Background(), name, metav1.GetOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    fields, _, _ := unstructured.NestedMap(obj.Object, "status")
+    status, err := statusFromUnstructured(fields)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return obj, status
+}
+
+func (tc *testController) events() []string {
+    var events []string
+    for {
+        select {
+        case e := <-tc.recorder.Events:
+            events = append(events, e)
+        default:
+            return events
+        }
+    }
+}
+
+func TestReconcile(t *testing.T) {
+    tc := newTestController(t, Config{}, newDeployment("web", nil, corev1.ResourceRequirements{}))
+    ctx := context.Background()
+
+    if err := tc.reconcile(ctx, "deployment/default/web"); err != nil {
+        t.Fatal(err)
+    }
+
+    obj, status := tc.analysis(t, "deployment-web")
+    if owners := obj.GetOwnerReferences(); len(owners) != 1 || owners[0].Kind != "Deployment" || owners[0].Name != "web" {
+        t.Errorf("unexpected owner references: %+v", owners)
+    }
+    if target, _, _ := unstructured.NestedString(obj.Object, "spec", "targetRef", "name"); target != "web" {
+        t.Errorf("expected targetRef web, got %q", target)
+    }
+    if status.ObservedGeneration != 1 || status.LastAnalyzed != "2024-05-01T12:00:00Z" {
+        t.Errorf("unexpected status: %+v", status)
+    }
+    // Missing requests (high) and missing memory limit (medium)
+    if status.FindingCount != 2 || status.Findings[0].Rule != "KWA001" || status.Findings[1].Rule != "KWA002" {
+        t.Errorf("unexpected findings: %+v", status.Findings)
+    }
+
+    deployment, err := tc.client.AppsV1().Deployments("default").Get(ctx, "web", metav1.GetOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := deployment.Annotations[AnnotationFindings]; got != "1 high, 1 medium" {
+        t.Errorf("unexpected findings annotation %q", got)
+    }
+    if got := deployment.Annotations[AnnotationEfficiency]; got == "" {
+        t.Error("expected an efficiency annotation")
+    }
+
+    events := tc.events()
+    if len(events) != 3 || !strings.HasPrefix(events[0], "Normal Analyzed") || !strings.HasPrefix(events[1], "Warning FindingDetected [high] KWA001/app") {
+        t.Errorf("unexpected events: %q", events)
+    }
+
+    // Re-analyzing without changes is quiet
+    if err := tc.reconcile(ctx, "deployment/default/web"); err != nil {
+        t.Fatal(err)
+    }
+    if events := tc.events(); len(events) != 0 {
+        t.Errorf("expected no events, got %q", events)
+    }
+
+    // Fixing the workload resolves its findings
+    deployment.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{
+        Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+        Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+    }
+    deployment.Generation = 2
+    if _, err := tc.client.AppsV1().Deployments("default").Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    if err := tc.reconcile(ctx, "deployment/default/web"); err != nil {
+        t.Fatal(err)
+    }
+    if _, status := tc.analysis(t, "deployment-web"); status.FindingCount != 0 || status.ObservedGeneration != 2 {
+        t.Errorf("unexpected status after fix: %+v", status)
+    }
+    events = tc.events()
+    if len(events) != 2 || events[0] != "Normal FindingResolved KWA001/app resolved" {
+        t.Errorf("unexpected events: %q", events)
+    }
+}
+
+func TestReconcileDeleted(t *testing.T) {
+    tc := newTestController(t, Config{})
+    if err := tc.reconcile(context.Background(), "deployment/default/gone"); err != nil {
+        t.Fatalf("expected deleted workloads to be ignored, got %v", err)
+    }
+}
+
+func TestNeedsAnalysis(t *testing.T) {
+    before := newDeployment("web", nil, corev1.ResourceRequirements{})
+    before.ResourceVersion = "1"
+
+    annotated := before.DeepCopy()
+    annotated.ResourceVersion = "2"
+    annotated.Annotations = map[string]string{AnnotationFindings: "none"}
+
+    changed := annotated.DeepCopy()
+    changed.ResourceVersion = "3"
+    changed.Generation = 2
+
+    tests := []struct {
+        name          string
+        before, after *appsv1.Deployment
+        want          bool
+    }{
+        {"resync", before, before, true},
+        {"annotations only", before, annotated, false},
+        {"spec change", annotated, changed, true},
+    }
+    for _, tt := range tests {
+        if got := needsAnalysis(tt.before, tt.after); got != tt.want {
+            t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+        }
+    }
+}
+
+func TestRunSelectsWorkloads(t *testing.T) {
+    selected := map[string]string{"kwa.io/analyze": "true"}
+    tc := newTestController(t, Config{Namespaces: []string{"default"}, Selector: "kwa.io/analyze=true"},
+        newDeployment("web", selected, corev1.ResourceRequirements{}),
+        newDeployment("batch", nil, corev1.ResourceRequirements{}),
+    )
+
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan error)
+    go func() { done <- tc.Run(ctx) }()
+
+    resources := tc.dynamic.Resource(Resource).Namespace("default")
+    deadline := time.Now().Add(5 * time.Second)
+    for {
+        if _, err := resources.Get(ctx, "deployment-web", metav1.GetOptions{}); err == nil {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatal("timed out waiting for the WorkloadAnalysis of web")
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+
+    cancel()
+    if err := <-done; err != nil {
+        t.Fatal(err)
+    }
+    if _, err := resources.Get(context.Background(), "deployment-batch", metav1.GetOptions{}); err == nil {
+        t.Error("expected batch to be skipped by the label selector")
+    }
+}
+
+func TestInvalidSelector(t *testing.T) {
+    if _, err := New(Config{Selector: "a=(b"}); err == nil {
+        t.Error("expected an error for an invalid selector")
+    }
+}
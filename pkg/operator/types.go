@@ -0,0 +1,101 @@
+package operator
+
+import (
+    "fmt"
+    "time"
+
+    "k8s.io/apimachinery/pkg/runtime"
+    "k8s.io/apimachinery/pkg/runtime/schema"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// Group and version of the WorkloadAnalysis CRD in deploy/crd.yaml.
+const (
+    Group   = "kwa.io"
+    Version = "v1alpha1"
+    Kind    = "WorkloadAnalysis"
+)
+
+// Resource is the WorkloadAnalysis resource. Resources are namespaced and
+// named after their target, see ResourceName.
+var Resource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "workloadanalyses"}
+
+// Annotations the operator sets on analyzed workloads.
+const (
+    AnnotationEfficiency = "kwa.io/efficiency"
+    AnnotationFindings   = "kwa.io/findings"
+)
+
+// TargetRef points a WorkloadAnalysis at the workload it describes.
+type TargetRef struct {
+    APIVersion string `json:"apiVersion"`
+    Kind       string `json:"kind"`
+    Name       string `json:"name"`
+}
+
+// Status is the status of a WorkloadAnalysis. Costs are strings since CRDs
+// discourage floats.
+type Status struct {
+    ObservedGeneration int64           `json:"observedGeneration"`
+    LastAnalyzed       string          `json:"lastAnalyzed"`
+    Replicas           string          `json:"replicas"`
+    CPUUtilization     string          `json:"cpuUtilization"`
+    MemoryUtilization  string          `json:"memoryUtilization"`
+    EfficiencyRate     string          `json:"efficiencyRate"`
+    MonthlyCost        string          `json:"monthlyCost,omitempty"`
+    MonthlyWaste       string          `json:"monthlyWaste,omitempty"`
+    FindingCount       int64           `json:"findingCount"`
+    Findings           []FindingStatus `json:"findings,omitempty"`
+}
+
+type FindingStatus struct {
+    ID        string `json:"id"`
+    Rule      string `json:"rule"`
+    Severity  string `json:"severity"`
+    Container string `json:"container,omitempty"`
+    Message   string `json:"message"`
+}
+
+// ResourceName is the name of the WorkloadAnalysis of a workload, e.g.
+// "deployment-web".
+func ResourceName(workloadType, name string) string {
+    return workloadType + "-" + name
+}
+
+func newStatus(details *analyzer.WorkloadDetails, generation int64, now time.Time) Status {
+    status := Status{
+        ObservedGeneration: generation,
+        LastAnalyzed:       now.UTC().Format(time.RFC3339),
+        Replicas:           details.ReplicaCount,
+        CPUUtilization:     details.CPUUtilization,
+        MemoryUtilization:  details.MemoryUtilization,
+        EfficiencyRate:     details.EfficiencyRate,
+        FindingCount:       int64(len(details.Findings)),
+    }
+    if details.Cost != nil {
+        status.MonthlyCost = fmt.Sprintf("%.2f %s", details.Cost.MonthlyRequested, details.Cost.Currency)
+        status.MonthlyWaste = fmt.Sprintf("%.2f %s", details.Cost.MonthlyWaste, details.Cost.Currency)
+    }
+    for _, f := range details.Findings {
+        status.Findings = append(status.Findings, FindingStatus{
+            ID:        f.ID,
+            Rule:      f.RuleID,
+            Severity:  string(f.Severity),
+            Container: f.Container,
+            Message:   f.Message,
+        })
+    }
+    return status
+}
+
+func (s Status) toUnstructured() (map[string]interface{}, error) {
+    return runtime.DefaultUnstructuredConverter.ToUnstructured(&s)
+}
+
+func statusFromUnstructured(obj map[string]interface{}) (*Status, error) {
+    var status Status
+    if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &status); err != nil {
+        return nil, fmt.Errorf("failed to decode status: %v", err)
+    }
+    return &status, nil
+}
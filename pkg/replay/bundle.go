@@ -0,0 +1,154 @@
+package replay
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+)
+
+// Services recorded in a bundle, each in its own subdirectory.
+const (
+    Kube = "kube" // Kubernetes API and metrics-server
+    AI   = "ai"
+)
+
+const (
+    metaFile      = "bundle.json"
+    bundleVersion = 1
+)
+
+// Meta describes how a bundle was recorded.
+type Meta struct {
+    Version  int           `json:"version"`
+    Created  time.Time     `json:"created"`
+    Args     []string      `json:"args,omitempty"`
+    Clusters []ClusterMeta `json:"clusters,omitempty"`
+}
+
+// ClusterMeta is what replay needs of a cluster instead of its kubeconfig.
+type ClusterMeta struct {
+    Name      string `json:"name"`
+    Namespace string `json:"namespace,omitempty"`
+    Host      string `json:"host"`
+}
+
+// Bundle is a directory holding every exchange of one run, so the run can be
+// replayed without a cluster or network, e.g. to reproduce a bug report.
+type Bundle struct {
+    Dir  string
+    Meta Meta
+
+    recording bool
+    mu        sync.Mutex
+    seq       map[string]*sequence
+}
+
+// Create starts recording a bundle into dir, which must be empty or missing.
+func Create(dir string, args []string) (*Bundle, error) {
+    if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+        return nil, fmt.Errorf("bundle directory %s is not empty", dir)
+    }
+    b := &Bundle{
+        Dir:       dir,
+        Meta:      Meta{Version: bundleVersion, Created: time.Now().UTC(), Args: args},
+        recording: true,
+    }
+    if err := b.save(); err != nil {
+        return nil, err
+    }
+    return b, nil
+}
+
+// Open loads a recorded bundle for replay.
+func Open(dir string) (*Bundle, error) {
+    data, err := os.ReadFile(filepath.Join(dir, metaFile))
+    if err != nil {
+        return nil, fmt.Errorf("failed to open bundle: %v", err)
+    }
+    b := &Bundle{Dir: dir}
+    if err := json.Unmarshal(data, &b.Meta); err != nil {
+        return nil, fmt.Errorf("failed to read bundle %s: %v", dir, err)
+    }
+    if b.Meta.Version != bundleVersion {
+        return nil, fmt.Errorf("unsupported bundle version %d", b.Meta.Version)
+    }
+    return b, nil
+}
+
+// Recording reports whether the bundle was created rather than opened.
+func (b *Bundle) Recording() bool {
+    return b.recording
+}
+
+// Transport returns the round tripper for a service: one that records calls
+// made through next, or one that replays them.
+func (b *Bundle) Transport(service string, next http.RoundTripper) http.RoundTripper {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.seq == nil {
+        b.seq = make(map[string]*sequence)
+    }
+    // Transports of one service share numbering so repeated requests replay
+    // in order even when clients are recreated
+    seq, ok := b.seq[service]
+    if !ok {
+        seq = &sequence{}
+        b.seq[service] = seq
+    }
+    dir := filepath.Join(b.Dir, service)
+    if b.recording {
+        return &Recorder{Dir: dir, Next: next, seq: seq}
+    }
+    return &Player{Dir: dir, seq: seq}
+}
+
+// AddCluster records the cluster a run connected to.
+func (b *Bundle) AddCluster(c ClusterMeta) error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    for _, existing := range b.Meta.Clusters {
+        if existing.Name == c.Name {
+            return nil
+        }
+    }
+    b.Meta.Clusters = append(b.Meta.Clusters, c)
+    return b.save()
+}
+
+// Cluster returns a recorded cluster by name, or the first one when name is
+// empty.
+func (b *Bundle) Cluster(name string) (ClusterMeta, error) {
+    for _, c := range b.Meta.Clusters {
+        if name == "" || c.Name == name {
+            return c, nil
+        }
+    }
+    if name == "" {
+        return ClusterMeta{}, fmt.Errorf("bundle %s has no cluster recordings", b.Dir)
+    }
+    return ClusterMeta{}, fmt.Errorf("bundle %s has no recordings of cluster %q", b.Dir, name)
+}
+
+// Has reports whether any exchange of a service was recorded.
+func (b *Bundle) Has(service string) bool {
+    entries, err := os.ReadDir(filepath.Join(b.Dir, service))
+    return err == nil && len(entries) > 0
+}
+
+func (b *Bundle) save() error {
+    if err := os.MkdirAll(b.Dir, 0o755); err != nil {
+        return fmt.Errorf("failed to create bundle: %v", err)
+    }
+    data, err := json.MarshalIndent(b.Meta, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to encode bundle: %v", err)
+    }
+    if err := os.WriteFile(filepath.Join(b.Dir, metaFile), append(data, '\n'), 0o644); err != nil {
+        return fmt.Errorf("failed to save bundle: %v", err)
+    }
+    return nil
+}
@@ -0,0 +1,222 @@
+// Package replay records HTTP exchanges to a directory and plays them back,
+// so a run can be repeated without the servers it talked to.
+package replay
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+    "unicode/utf8"
+)
+
+// Exchange is one recorded request and its response. Credentials are never
+// recorded. Binary responses, e.g. protobuf, are base64 encoded.
+type Exchange struct {
+    Method      string        `json:"method"`
+    URL         string        `json:"url"`
+    Request     string        `json:"request,omitempty"`
+    Status      int           `json:"status"`
+    ContentType string        `json:"content_type,omitempty"`
+    Encoding    string        `json:"encoding,omitempty"`
+    Response    string        `json:"response"`
+    Duration    time.Duration `json:"duration"`
+}
+
+// Recorder is an http.RoundTripper that saves every exchange made through
+// Next as a file in Dir. Watch streams are passed through unrecorded.
+type Recorder struct {
+    Dir  string
+    Next http.RoundTripper
+
+    once sync.Once
+    seq  *sequence
+}
+
+// Player is an http.RoundTripper that answers requests from the exchanges
+// in Dir, failing those that weren't recorded.
+type Player struct {
+    Dir string
+
+    once sync.Once
+    seq  *sequence
+}
+
+// sequence numbers repeated identical requests, so polling the same URL
+// replays the responses in the order they were recorded.
+type sequence struct {
+    mu    sync.Mutex
+    count map[string]int
+}
+
+func (s *sequence) next(key string) int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.count == nil {
+        s.count = make(map[string]int)
+    }
+    n := s.count[key]
+    s.count[key]++
+    return n
+}
+
+// Key identifies a request by method, URL and body.
+func Key(method, url string, body []byte) string {
+    hash := sha256.New()
+    fmt.Fprintf(hash, "%s %s\n", method, url)
+    hash.Write(body)
+    return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+    next := r.Next
+    if next == nil {
+        next = http.DefaultTransport
+    }
+    if isWatch(req) {
+        return next.RoundTrip(req)
+    }
+    r.once.Do(func() {
+        if r.seq == nil {
+            r.seq = &sequence{}
+        }
+    })
+
+    body, err := readBody(req)
+    if err != nil {
+        return nil, err
+    }
+    start := time.Now()
+    resp, err := next.RoundTrip(req)
+    if err != nil {
+        return nil, err
+    }
+    data, err := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if err != nil {
+        return nil, err
+    }
+    resp.Body = io.NopCloser(bytes.NewReader(data))
+
+    ex := Exchange{
+        Method:      req.Method,
+        URL:         req.URL.String(),
+        Request:     string(body),
+        Status:      resp.StatusCode,
+        ContentType: resp.Header.Get("Content-Type"),
+        Response:    string(data),
+        Duration:    time.Since(start),
+    }
+    if !utf8.Valid(data) {
+        ex.Encoding = "base64"
+        ex.Response = base64.StdEncoding.EncodeToString(data)
+    }
+    key := Key(req.Method, req.URL.String(), body)
+    if err := save(r.Dir, fmt.Sprintf("%s-%d", key, r.seq.next(key)), ex); err != nil {
+        return nil, err
+    }
+    return resp, nil
+}
+
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+    if isWatch(req) {
+        return nil, fmt.Errorf("watch streams are not recorded")
+    }
+    p.once.Do(func() {
+        if p.seq == nil {
+            p.seq = &sequence{}
+        }
+    })
+
+    body, err := readBody(req)
+    if err != nil {
+        return nil, err
+    }
+    key := Key(req.Method, req.URL.String(), body)
+    ex, err := p.load(key, p.seq.next(key))
+    if err != nil {
+        return nil, err
+    }
+    data := []byte(ex.Response)
+    if ex.Encoding == "base64" {
+        if data, err = base64.StdEncoding.DecodeString(ex.Response); err != nil {
+            return nil, fmt.Errorf("failed to read recording %s: %v", key, err)
+        }
+    }
+
+    header := make(http.Header)
+    if ex.ContentType != "" {
+        header.Set("Content-Type", ex.ContentType)
+    }
+    return &http.Response{
+        Status:        fmt.Sprintf("%d %s", ex.Status, http.StatusText(ex.Status)),
+        StatusCode:    ex.Status,
+        Proto:         "HTTP/1.1",
+        ProtoMajor:    1,
+        ProtoMinor:    1,
+        Header:        header,
+        Body:          io.NopCloser(bytes.NewReader(data)),
+        ContentLength: int64(len(data)),
+        Request:       req,
+    }, nil
+}
+
+// load reads the n-th recording of a request. Requests repeated more often
+// than they were recorded get the last recorded response.
+func (p *Player) load(key string, n int) (*Exchange, error) {
+    for ; n >= 0; n-- {
+        data, err := os.ReadFile(filepath.Join(p.Dir, fmt.Sprintf("%s-%d.json", key, n)))
+        if os.IsNotExist(err) {
+            continue
+        }
+        if err != nil {
+            return nil, fmt.Errorf("failed to read recording %s: %v", key, err)
+        }
+        var ex Exchange
+        if err := json.Unmarshal(data, &ex); err != nil {
+            return nil, fmt.Errorf("failed to read recording %s: %v", key, err)
+        }
+        return &ex, nil
+    }
+    return nil, fmt.Errorf("no recording of this request in %s", p.Dir)
+}
+
+func isWatch(req *http.Request) bool {
+    return req.URL.Query().Get("watch") == "true"
+}
+
+// readBody reads the request body and restores it for the next transport.
+func readBody(req *http.Request) ([]byte, error) {
+    if req.Body == nil || req.Body == http.NoBody {
+        return nil, nil
+    }
+    body, err := io.ReadAll(req.Body)
+    req.Body.Close()
+    if err != nil {
+        return nil, fmt.Errorf("failed to read request: %v", err)
+    }
+    req.Body = io.NopCloser(bytes.NewReader(body))
+    return body, nil
+}
+
+func save(dir, name string, ex Exchange) error {
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return fmt.Errorf("failed to create recording directory: %v", err)
+    }
+    data, err := json.MarshalIndent(ex, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to encode recording: %v", err)
+    }
+    if err := os.WriteFile(filepath.Join(dir, name+".json"), append(data, '\n'), 0o644); err != nil {
+        return fmt.Errorf("failed to save recording: %v", err)
+    }
+    return nil
+}
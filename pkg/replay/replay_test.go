@@ -0,0 +1,110 @@
+package replay
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "strings"
+    "testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusTeapot)
+        w.Write([]byte(`{"echo": "` + string(body) + `"}`))
+    }))
+    dir := t.TempDir()
+
+    recorded := &http.Client{Transport: &Recorder{Dir: dir}}
+    req, _ := http.NewRequest("POST", srv.URL+"/v1?x=1", strings.NewReader("hello"))
+    req.Header.Set("Authorization", "Bearer secret")
+    resp, err := recorded.Do(req)
+    if err != nil {
+        t.Fatal(err)
+    }
+    body, _ := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if string(body) != `{"echo": "hello"}` {
+        t.Fatalf("recorder changed the response: %s", body)
+    }
+    srv.Close()
+
+    files, _ := os.ReadDir(dir)
+    if len(files) != 1 {
+        t.Fatalf("expected one recording, got %d", len(files))
+    }
+    data, _ := os.ReadFile(dir + "/" + files[0].Name())
+    if strings.Contains(string(data), "secret") {
+        t.Error("recording contains credentials")
+    }
+
+    replayed := &http.Client{Transport: &Player{Dir: dir}}
+    resp, err = replayed.Post(srv.URL+"/v1?x=1", "text/plain", strings.NewReader("hello"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    body, _ = io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusTeapot || resp.Header.Get("Content-Type") != "application/json" || string(body) != `{"echo": "hello"}` {
+        t.Errorf("unexpected replay: %d %s", resp.StatusCode, body)
+    }
+
+    if _, err := replayed.Post(srv.URL+"/v1?x=1", "text/plain", strings.NewReader("other")); err == nil {
+        t.Error("expected a missing recording to fail")
+    }
+}
+
+func TestBundleSequencesAndBinary(t *testing.T) {
+    var calls int
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        calls++
+        w.Write([]byte{0xff, byte(calls)})
+    }))
+    dir := t.TempDir()
+
+    b, err := Create(dir, []string{"analyze", "-name=web"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := b.AddCluster(ClusterMeta{Name: "prod", Namespace: "default", Host: srv.URL}); err != nil {
+        t.Fatal(err)
+    }
+    get := func(client *http.Client) []byte {
+        t.Helper()
+        resp, err := client.Get(srv.URL + "/poll")
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer resp.Body.Close()
+        body, _ := io.ReadAll(resp.Body)
+        return body
+    }
+    recorder := &http.Client{Transport: b.Transport(Kube, nil)}
+    get(recorder)
+    get(&http.Client{Transport: b.Transport(Kube, nil)})
+    srv.Close()
+
+    b, err = Open(dir)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if c, err := b.Cluster(""); err != nil || c.Name != "prod" || b.Meta.Args[0] != "analyze" {
+        t.Fatalf("unexpected bundle metadata: %+v %v", b.Meta, err)
+    }
+    if !b.Has(Kube) || b.Has(AI) {
+        t.Error("expected only kube recordings")
+    }
+    player := &http.Client{Transport: b.Transport(Kube, nil)}
+    for _, want := range []byte{1, 2, 2} {
+        if body := get(player); len(body) != 2 || body[0] != 0xff || body[1] != want {
+            t.Errorf("expected response %d, got %v", want, body)
+        }
+    }
+
+    if _, err := Create(dir, nil); err == nil {
+        t.Error("expected recording into a non-empty directory to fail")
+    }
+}
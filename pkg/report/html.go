@@ -0,0 +1,195 @@
+package report
+
+import (
+    _ "embed"
+    "fmt"
+    "html/template"
+    "io"
+    "sort"
+    "strings"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+//go:embed html.tmpl
+var htmlTemplate string
+
+var htmlFuncs = template.FuncMap{
+    "severityClass": func(s analyzer.Severity) string {
+        switch {
+        case s.AtLeast(analyzer.SeverityHigh):
+            return "bad"
+        case s.AtLeast(analyzer.SeverityMedium):
+            return "warn"
+        }
+        return "muted"
+    },
+    "efficiencyClass": func(rate string) string {
+        switch {
+        case strings.Contains(rate, "High"):
+            return "good"
+        case strings.Contains(rate, "Medium"):
+            return "warn"
+        case strings.HasPrefix(rate, "N/A"):
+            return "muted"
+        }
+        return "bad"
+    },
+    "money": func(c *analyzer.Cost, amount float64) string {
+        if c == nil {
+            return "-"
+        }
+        return fmt.Sprintf("%.2f %s", amount, c.Currency)
+    },
+    "anchor": workloadAnchor,
+    "join":   strings.Join,
+    "dict": func(pairs ...interface{}) map[string]interface{} {
+        m := make(map[string]interface{})
+        for i := 0; i+1 < len(pairs); i += 2 {
+            m[pairs[i].(string)] = pairs[i+1]
+        }
+        return m
+    },
+}
+
+func workloadAnchor(details *analyzer.WorkloadDetails) string {
+    return strings.NewReplacer("/", "-", ".", "-").Replace(
+        fmt.Sprintf("%s-%s-%s-%s", details.Cluster, details.Namespace, details.Kind, details.Deployment))
+}
+
+// htmlSummary totals one cluster and namespace for the summary table.
+type htmlSummary struct {
+    Cluster   string
+    Namespace string
+    Workloads int
+    Findings  map[analyzer.Severity]int
+    Cost      *analyzer.Cost
+}
+
+// htmlBar is one row of a usage chart; widths are in SVG pixels.
+type htmlBar struct {
+    Label        string
+    Y            int
+    RequestWidth int
+    UsageWidth   int
+    LimitX       int // 0 when there is no limit
+    Text         string
+}
+
+type htmlChart struct {
+    Height int
+    Bars   []htmlBar
+}
+
+type htmlWorkload struct {
+    *analyzer.WorkloadDetails
+    Chart *htmlChart
+}
+
+type htmlPage struct {
+    Report     *Report
+    Severities []analyzer.Severity
+    Summaries  []htmlSummary
+    Workloads  []htmlWorkload
+}
+
+// WriteHTML writes the report as a single HTML page with no external
+// resources, so it can be mailed to people who don't run the CLI.
+func (r *Report) WriteHTML(w io.Writer) error {
+    tmpl, err := template.New("report").Funcs(htmlFuncs).Parse(htmlTemplate)
+    if err != nil {
+        return fmt.Errorf("failed to parse HTML template: %v", err)
+    }
+
+    page := htmlPage{
+        Report: r,
+        Severities: []analyzer.Severity{
+            analyzer.SeverityCritical, analyzer.SeverityHigh, analyzer.SeverityMedium,
+            analyzer.SeverityLow, analyzer.SeverityInfo,
+        },
+    }
+    summaries := make(map[string]*htmlSummary)
+    var keys []string
+    for _, details := range r.Workloads() {
+        key := details.Cluster + "\x00" + details.Namespace
+        summary, ok := summaries[key]
+        if !ok {
+            summary = &htmlSummary{
+                Cluster:   details.Cluster,
+                Namespace: details.Namespace,
+                Findings:  make(map[analyzer.Severity]int),
+            }
+            summaries[key] = summary
+            keys = append(keys, key)
+        }
+        summary.Workloads++
+        for _, f := range details.Findings {
+            summary.Findings[f.Severity]++
+        }
+        if details.Cost != nil {
+            if summary.Cost == nil {
+                summary.Cost = &analyzer.Cost{Currency: details.Cost.Currency}
+            }
+            summary.Cost.MonthlyRequested += details.Cost.MonthlyRequested
+            summary.Cost.MonthlyWaste += details.Cost.MonthlyWaste
+        }
+        page.Workloads = append(page.Workloads, htmlWorkload{WorkloadDetails: details, Chart: usageChart(details)})
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        page.Summaries = append(page.Summaries, *summaries[key])
+    }
+
+    return tmpl.Execute(w, page)
+}
+
+const (
+    chartLabelWidth = 160
+    chartBarWidth   = 360
+    chartRowHeight  = 22
+)
+
+// usageChart draws CPU and memory usage against requests for each container
+// with usage. Bars are scaled per resource to the larger of request, limit
+// and usage. It returns nil when no container has usage.
+func usageChart(details *analyzer.WorkloadDetails) *htmlChart {
+    chart := &htmlChart{}
+    add := func(label string, request, limit, usage int64, text string) {
+        scale := request
+        if limit > scale {
+            scale = limit
+        }
+        if usage > scale {
+            scale = usage
+        }
+        if scale == 0 {
+            return
+        }
+        bar := htmlBar{
+            Label:        label,
+            Y:            len(chart.Bars) * chartRowHeight,
+            RequestWidth: int(request * chartBarWidth / scale),
+            UsageWidth:   int(usage * chartBarWidth / scale),
+            Text:         text,
+        }
+        if limit > 0 {
+            bar.LimitX = chartLabelWidth + int(limit*chartBarWidth/scale)
+        }
+        chart.Bars = append(chart.Bars, bar)
+    }
+
+    for _, c := range details.Containers {
+        if !c.HasUsage {
+            continue
+        }
+        add(c.Name+" cpu", c.CPURequest, c.CPULimit, c.CPUUsage,
+            fmt.Sprintf("%s of %s", analyzer.FormatCPU(c.CPUUsage), analyzer.FormatCPU(c.CPURequest)))
+        add(c.Name+" memory", c.MemoryRequest, c.MemoryLimit, c.MemoryUsage,
+            fmt.Sprintf("%s of %s", analyzer.FormatMemory(c.MemoryUsage), analyzer.FormatMemory(c.MemoryRequest)))
+    }
+    if len(chart.Bars) == 0 {
+        return nil
+    }
+    chart.Height = len(chart.Bars) * chartRowHeight
+    return chart
+}
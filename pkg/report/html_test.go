@@ -0,0 +1,88 @@
+package report
+
+import (
+    "bytes"
+    "strings"
+    "testing"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+func TestWriteHTMLClaims(t *testing.T) {
+    details := &analyzer.WorkloadDetails{
+        Cluster:    "prod",
+        Namespace:  "shop",
+        Kind:       "deployment",
+        Deployment: "web",
+        Opportunities: []analyzer.Claim{{
+            Text:     "Lower the CPU request",
+            Findings: []string{"KWA004/app"},
+            Metrics:  map[string]string{"cpu_utilization": "12m", "app.cpu_request": "500m"},
+        }},
+        Cautions: []analyzer.Claim{{Text: "Traffic may spike", Unverified: true}},
+    }
+    var buf bytes.Buffer
+    if err := New(analyzer.ClusterResult{Cluster: "prod", Workloads: []*analyzer.WorkloadDetails{details}}).WriteHTML(&buf); err != nil {
+        t.Fatal(err)
+    }
+    out := buf.String()
+
+    for _, want := range []string{
+        "<li>Lower the CPU request<br><span class=\"muted\">&#8627; KWA004/app, app.cpu_request=500m, cpu_utilization=12m</span></li>",
+        "<li>Traffic may spike <span class=\"bad\">(unverified)</span></li>",
+    } {
+        if !strings.Contains(out, want) {
+            t.Errorf("expected the page to contain %s", want)
+        }
+    }
+}
+
+func TestWriteHTMLEscapes(t *testing.T) {
+    const evil = `<script>alert("x")</script>`
+    details := &analyzer.WorkloadDetails{
+        Cluster:         `prod"><img src=x onerror=alert(1)>`,
+        Namespace:       "shop",
+        Kind:            "deployment",
+        Deployment:      "web" + evil,
+        ReplicaCount:    "2",
+        EfficiencyRate:  "High (90.0%)",
+        ReliabilityRisk: evil,
+        Analysis:        "Requests are high & " + evil,
+        Containers: []analyzer.ContainerDetails{{
+            Name:       "app" + evil,
+            CPURequest: 500,
+            HasUsage:   true,
+            CPUUsage:   100,
+        }},
+        Findings: []analyzer.Finding{{
+            ID: "KWA001/app", RuleID: "KWA001", Severity: analyzer.SeverityHigh, Container: "app",
+            Message: "container has no limit " + evil,
+        }},
+        Opportunities:   []analyzer.Claim{{Text: evil, Findings: []string{evil}}},
+        Recommendations: []string{evil},
+    }
+    var buf bytes.Buffer
+    if err := New(analyzer.ClusterResult{Cluster: details.Cluster, Workloads: []*analyzer.WorkloadDetails{details}}).WriteHTML(&buf); err != nil {
+        t.Fatal(err)
+    }
+    out := buf.String()
+
+    // The page's own sorting script is the only one
+    if n := strings.Count(out, "<script>"); n != 1 {
+        t.Errorf("expected workload text to be escaped, found %d <script> tags", n)
+    }
+    for _, unsafe := range []string{`<img src=x`, `"><img`, `alert("x")`} {
+        if strings.Contains(out, unsafe) {
+            t.Errorf("expected %s to be escaped", unsafe)
+        }
+    }
+    for _, want := range []string{
+        "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;",
+        "Requests are high &amp; ",
+        `<text x="0" y="15">app&lt;script&gt;`,
+    } {
+        if !strings.Contains(out, want) {
+            t.Errorf("expected the page to contain %s", want)
+        }
+    }
+}
@@ -0,0 +1,162 @@
+package report
+
+import (
+    "encoding/xml"
+    "fmt"
+    "io"
+    "sort"
+    "strings"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+type junitTestSuites struct {
+    XMLName  xml.Name         `xml:"testsuites"`
+    Name     string           `xml:"name,attr"`
+    Tests    int              `xml:"tests,attr"`
+    Failures int              `xml:"failures,attr"`
+    Skipped  int              `xml:"skipped,attr"`
+    Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+    Name      string          `xml:"name,attr"`
+    Tests     int             `xml:"tests,attr"`
+    Failures  int             `xml:"failures,attr"`
+    Errors    int             `xml:"errors,attr"`
+    Skipped   int             `xml:"skipped,attr"`
+    Time      string          `xml:"time,attr"`
+    Timestamp string          `xml:"timestamp,attr"`
+    Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+    Name      string        `xml:"name,attr"`
+    Classname string        `xml:"classname,attr"`
+    File      string        `xml:"file,attr,omitempty"`
+    Line      int           `xml:"line,attr,omitempty"`
+    Time      string        `xml:"time,attr"`
+    Failure   *junitFailure `xml:"failure,omitempty"`
+    Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+    Message string `xml:"message,attr"`
+    Type    string `xml:"type,attr"`
+    Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+    Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes every rule evaluated on every workload as a JUnit
+// testcase, grouped into one testsuite per namespace (per cluster and
+// namespace when the report spans clusters). A rule fails with its findings
+// as the message, is skipped when it didn't apply, and passes otherwise.
+func (r *Report) WriteJUnit(w io.Writer) error {
+    suites := make(map[string]*junitTestSuite)
+    var names []string
+    timestamp := r.GeneratedAt.Format("2006-01-02T15:04:05")
+
+    for _, details := range r.Workloads() {
+        name := details.Namespace
+        if len(r.Clusters) > 1 {
+            name = details.Cluster + "/" + details.Namespace
+        }
+        suite, ok := suites[name]
+        if !ok {
+            suite = &junitTestSuite{Name: name, Time: "0", Timestamp: timestamp}
+            suites[name] = suite
+            names = append(names, name)
+        }
+
+        for _, rule := range r.evaluatedRules(details) {
+            tc := junitTestCase{
+                Name:      fmt.Sprintf("%s %s", rule.ID, rule.Name),
+                Classname: fmt.Sprintf("%s.%s", details.Kind, details.Deployment),
+                Time:      "0",
+            }
+            if details.Source != nil {
+                tc.File = details.Source.File
+                tc.Line = details.Source.Line
+            }
+
+            var findings []analyzer.Finding
+            for _, f := range details.Findings {
+                if f.RuleID == rule.ID {
+                    findings = append(findings, f)
+                }
+            }
+            if len(findings) > 0 {
+                var messages []string
+                for _, f := range findings {
+                    messages = append(messages, fmt.Sprintf("[%s] %s", f.Severity, f.Message))
+                }
+                tc.Failure = &junitFailure{
+                    Message: findings[0].Message,
+                    Type:    string(findings[0].Severity),
+                    Text:    strings.Join(messages, "\n"),
+                }
+                if details.Source != nil {
+                    tc.Line = details.Source.LineOf(findings[0].Container)
+                }
+                suite.Failures++
+            } else if reason, ok := details.SkippedRules[rule.ID]; ok {
+                tc.Skipped = &junitSkipped{Message: reason}
+                suite.Skipped++
+            }
+            suite.Cases = append(suite.Cases, tc)
+            suite.Tests++
+        }
+    }
+
+    sort.Strings(names)
+    out := junitTestSuites{Name: "kwa"}
+    for _, name := range names {
+        suite := suites[name]
+        out.Suites = append(out.Suites, *suite)
+        out.Tests += suite.Tests
+        out.Failures += suite.Failures
+        out.Skipped += suite.Skipped
+    }
+
+    if _, err := io.WriteString(w, xml.Header); err != nil {
+        return err
+    }
+    encoder := xml.NewEncoder(w)
+    encoder.Indent("", "  ")
+    if err := encoder.Encode(out); err != nil {
+        return err
+    }
+    _, err := io.WriteString(w, "\n")
+    return err
+}
+
+// evaluatedRules returns the rules a workload was checked against. Reports
+// without rule metadata fall back to the rules seen in its results.
+func (r *Report) evaluatedRules(details *analyzer.WorkloadDetails) []RuleInfo {
+    if len(r.Rules) > 0 {
+        return r.Rules
+    }
+    seen := make(map[string]bool)
+    var ids []string
+    for _, f := range details.Findings {
+        if !seen[f.RuleID] {
+            seen[f.RuleID] = true
+            ids = append(ids, f.RuleID)
+        }
+    }
+    for id := range details.SkippedRules {
+        if !seen[id] {
+            seen[id] = true
+            ids = append(ids, id)
+        }
+    }
+    sort.Strings(ids)
+    rules := make([]RuleInfo, 0, len(ids))
+    for _, id := range ids {
+        rules = append(rules, r.Rule(id))
+    }
+    return rules
+}
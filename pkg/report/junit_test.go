@@ -0,0 +1,100 @@
+package report
+
+import (
+    "bytes"
+    "encoding/xml"
+    "strings"
+    "testing"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+func TestWriteJUnit(t *testing.T) {
+    rep := manifestReport(t)
+    rep.Clusters[0].Workloads = append(rep.Clusters[0].Workloads, &analyzer.WorkloadDetails{
+        Namespace:    "billing",
+        Kind:         "statefulset",
+        Deployment:   "db",
+        SkippedRules: map[string]string{"KWA006": "statefulsets are checked separately"},
+        Findings: []analyzer.Finding{
+            {ID: "KWA001/db", RuleID: "KWA001", Severity: analyzer.SeverityHigh, Container: "db", Message: `container "db" has <no> memory limit & more`},
+            {ID: "KWA001/backup", RuleID: "KWA001", Severity: analyzer.SeverityHigh, Container: "backup", Message: "container backup has no memory limit"},
+        },
+    })
+
+    var buf bytes.Buffer
+    if err := rep.WriteJUnit(&buf); err != nil {
+        t.Fatal(err)
+    }
+    if !strings.HasPrefix(buf.String(), xml.Header) {
+        t.Errorf("expected an XML header, got %q", buf.String()[:40])
+    }
+
+    var out struct {
+        Tests    int `xml:"tests,attr"`
+        Failures int `xml:"failures,attr"`
+        Skipped  int `xml:"skipped,attr"`
+        Suites   []struct {
+            Name     string `xml:"name,attr"`
+            Tests    int    `xml:"tests,attr"`
+            Failures int    `xml:"failures,attr"`
+            Skipped  int    `xml:"skipped,attr"`
+            Cases    []struct {
+                Name      string `xml:"name,attr"`
+                Classname string `xml:"classname,attr"`
+                File      string `xml:"file,attr"`
+                Line      int    `xml:"line,attr"`
+                Failure   *struct {
+                    Message string `xml:"message,attr"`
+                    Type    string `xml:"type,attr"`
+                    Text    string `xml:",chardata"`
+                } `xml:"failure"`
+                Skipped *struct {
+                    Message string `xml:"message,attr"`
+                } `xml:"skipped"`
+            } `xml:"testcase"`
+        } `xml:"testsuite"`
+    }
+    decoder := xml.NewDecoder(&buf)
+    decoder.Strict = true
+    if err := decoder.Decode(&out); err != nil {
+        t.Fatalf("JUnit output is not well-formed XML: %v", err)
+    }
+
+    // 3 rules on each of 2 workloads; KWA001 fails on both, KWA006 on web
+    if out.Tests != 6 || out.Failures != 3 || out.Skipped != 1 {
+        t.Errorf("expected 6 tests, 3 failures and 1 skipped, got %d, %d and %d", out.Tests, out.Failures, out.Skipped)
+    }
+    if len(out.Suites) != 2 || out.Suites[0].Name != "billing" || out.Suites[1].Name != "shop" {
+        t.Fatalf("expected suites billing and shop, got %+v", out.Suites)
+    }
+
+    billing := out.Suites[0]
+    if billing.Tests != 3 || billing.Failures != 1 || billing.Skipped != 1 {
+        t.Errorf("billing: expected 3 tests, 1 failure and 1 skipped, got %d, %d and %d", billing.Tests, billing.Failures, billing.Skipped)
+    }
+    failure := billing.Cases[0].Failure
+    if billing.Cases[0].Name != "KWA001 memory-limit" || billing.Cases[0].Classname != "statefulset.db" || failure == nil {
+        t.Fatalf("unexpected first case: %+v", billing.Cases[0])
+    }
+    if failure.Message != `container "db" has <no> memory limit & more` || failure.Type != "high" {
+        t.Errorf("unexpected failure %+v", failure)
+    }
+    if failure.Text != "[high] container \"db\" has <no> memory limit & more\n[high] container backup has no memory limit" {
+        t.Errorf("expected both findings in the failure text, got %q", failure.Text)
+    }
+    if billing.Cases[1].Failure != nil || billing.Cases[1].Skipped != nil {
+        t.Errorf("expected KWA002 to pass, got %+v", billing.Cases[1])
+    }
+    if billing.Cases[2].Skipped == nil || billing.Cases[2].Skipped.Message != "statefulsets are checked separately" {
+        t.Errorf("expected KWA006 to be skipped with its reason, got %+v", billing.Cases[2])
+    }
+
+    shop := out.Suites[1]
+    if shop.Failures != 2 {
+        t.Errorf("shop: expected 2 failures, got %d", shop.Failures)
+    }
+    if c := shop.Cases[0]; c.File != "testdata/manifests.yaml" || c.Line != 20 {
+        t.Errorf("expected KWA001 on web to point at the app container, got %s:%d", c.File, c.Line)
+    }
+}
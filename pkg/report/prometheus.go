@@ -0,0 +1,113 @@
+package report
+
+import (
+    "fmt"
+    "io"
+    "sort"
+    "strconv"
+    "strings"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// promMetric is one metric family in the Prometheus text format.
+type promMetric struct {
+    name    string
+    help    string
+    samples []string
+}
+
+func (m *promMetric) add(value float64, labels ...string) {
+    var pairs []string
+    for i := 0; i+1 < len(labels); i += 2 {
+        pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1])))
+    }
+    m.samples = append(m.samples, fmt.Sprintf("%s{%s} %s", m.name, strings.Join(pairs, ","), strconv.FormatFloat(value, 'g', -1, 64)))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// withLabels returns labels with extra label pairs added, without touching
+// the backing array of labels.
+func withLabels(labels []string, extra ...string) []string {
+    return append(labels[:len(labels):len(labels)], extra...)
+}
+
+// WritePrometheus writes the report as gauges in the Prometheus text
+// exposition format, for the node exporter's textfile collector or a
+// /metrics endpoint. CPU is in cores and memory in bytes.
+func (r *Report) WritePrometheus(w io.Writer) error {
+    efficiency := &promMetric{name: "kwa_workload_efficiency_ratio", help: "Share of requested CPU and memory used on average, 0-1."}
+    replicas := &promMetric{name: "kwa_workload_desired_replicas", help: "Desired replicas of the workload."}
+    findings := &promMetric{name: "kwa_workload_findings", help: "Rule findings on the workload by severity."}
+    cost := &promMetric{name: "kwa_workload_monthly_cost", help: "Estimated monthly cost of the workload's requests."}
+    waste := &promMetric{name: "kwa_workload_monthly_waste", help: "Estimated monthly cost of requested but unused capacity."}
+    cpuRequested := &promMetric{name: "kwa_container_cpu_requested_cores", help: "CPU request of the container."}
+    cpuLimit := &promMetric{name: "kwa_container_cpu_limit_cores", help: "CPU limit of the container."}
+    cpuUsed := &promMetric{name: "kwa_container_cpu_used_cores", help: "Average CPU usage of the container across pods."}
+    memoryRequested := &promMetric{name: "kwa_container_memory_requested_bytes", help: "Memory request of the container."}
+    memoryLimit := &promMetric{name: "kwa_container_memory_limit_bytes", help: "Memory limit of the container."}
+    memoryUsed := &promMetric{name: "kwa_container_memory_used_bytes", help: "Average memory usage of the container across pods."}
+    generated := &promMetric{name: "kwa_report_timestamp_seconds", help: "When the analysis was generated, in Unix seconds."}
+
+    severities := []analyzer.Severity{
+        analyzer.SeverityInfo, analyzer.SeverityLow, analyzer.SeverityMedium,
+        analyzer.SeverityHigh, analyzer.SeverityCritical,
+    }
+
+    for _, d := range r.Workloads() {
+        labels := []string{"cluster", d.Cluster, "namespace", d.Namespace, "kind", d.Kind, "workload", d.Deployment}
+
+        if percent, ok := analyzer.EfficiencyPercent(d.EfficiencyRate); ok {
+            efficiency.add(percent/100, labels...)
+        }
+        replicas.add(float64(d.DesiredReplicas), labels...)
+
+        counts := make(map[analyzer.Severity]int)
+        for _, f := range d.Findings {
+            counts[f.Severity]++
+        }
+        // Every severity is written so series don't disappear when fixed
+        for _, severity := range severities {
+            findings.add(float64(counts[severity]), withLabels(labels, "severity", string(severity))...)
+        }
+
+        if d.Cost != nil {
+            cost.add(d.Cost.MonthlyRequested, withLabels(labels, "currency", d.Cost.Currency)...)
+            waste.add(d.Cost.MonthlyWaste, withLabels(labels, "currency", d.Cost.Currency)...)
+        }
+
+        for _, c := range d.Containers {
+            containerLabels := withLabels(labels, "container", c.Name)
+            cpuRequested.add(float64(c.CPURequest)/1000, containerLabels...)
+            memoryRequested.add(float64(c.MemoryRequest), containerLabels...)
+            if c.CPULimit > 0 {
+                cpuLimit.add(float64(c.CPULimit)/1000, containerLabels...)
+            }
+            if c.MemoryLimit > 0 {
+                memoryLimit.add(float64(c.MemoryLimit), containerLabels...)
+            }
+            if c.HasUsage {
+                cpuUsed.add(float64(c.CPUUsage)/1000, containerLabels...)
+                memoryUsed.add(float64(c.MemoryUsage), containerLabels...)
+            }
+        }
+    }
+    if !r.GeneratedAt.IsZero() {
+        generated.samples = []string{fmt.Sprintf("%s %d", generated.name, r.GeneratedAt.Unix())}
+    }
+
+    for _, m := range []*promMetric{
+        efficiency, replicas, findings, cost, waste,
+        cpuRequested, cpuLimit, cpuUsed, memoryRequested, memoryLimit, memoryUsed, generated,
+    } {
+        if len(m.samples) == 0 {
+            continue
+        }
+        sort.Strings(m.samples)
+        if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s\n", m.name, m.help, m.name, strings.Join(m.samples, "\n")); err != nil {
+            return err
+        }
+    }
+    return nil
+}
@@ -0,0 +1,107 @@
+package report
+
+import (
+    "bytes"
+    "regexp"
+    "strings"
+    "testing"
+    "time"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+func TestWritePrometheus(t *testing.T) {
+    details := &analyzer.WorkloadDetails{
+        Cluster:         "prod",
+        Namespace:       "shop",
+        Kind:            "deployment",
+        Deployment:      "web",
+        DesiredReplicas: 3,
+        EfficiencyRate:  "Medium (62.5%)",
+        Containers: []analyzer.ContainerDetails{{
+            Name:          "app",
+            CPURequest:    500,
+            MemoryRequest: 256 << 20,
+            MemoryLimit:   512 << 20,
+            HasUsage:      true,
+            CPUUsage:      250,
+            MemoryUsage:   128 << 20,
+        }},
+        Findings: []analyzer.Finding{
+            {RuleID: "KWA002", Severity: analyzer.SeverityMedium},
+            {RuleID: "KWA004", Severity: analyzer.SeverityMedium},
+        },
+        Cost: &analyzer.Cost{Currency: "USD", MonthlyRequested: 20.5, MonthlyWaste: 7.25},
+    }
+    rep := New(analyzer.ClusterResult{Cluster: "prod", Workloads: []*analyzer.WorkloadDetails{details}})
+    rep.GeneratedAt = time.Unix(1700000000, 0)
+
+    var buf bytes.Buffer
+    if err := rep.WritePrometheus(&buf); err != nil {
+        t.Fatal(err)
+    }
+    out := buf.String()
+
+    const workload = `cluster="prod",namespace="shop",kind="deployment",workload="web"`
+    for _, want := range []string{
+        "# HELP kwa_workload_efficiency_ratio Share of requested CPU and memory used on average, 0-1.\n# TYPE kwa_workload_efficiency_ratio gauge\n",
+        "kwa_workload_efficiency_ratio{" + workload + "} 0.625\n",
+        "kwa_workload_desired_replicas{" + workload + "} 3\n",
+        "kwa_workload_findings{" + workload + `,severity="info"} 0` + "\n",
+        "kwa_workload_findings{" + workload + `,severity="medium"} 2` + "\n",
+        "kwa_workload_findings{" + workload + `,severity="critical"} 0` + "\n",
+        "kwa_workload_monthly_cost{" + workload + `,currency="USD"} 20.5` + "\n",
+        "kwa_workload_monthly_waste{" + workload + `,currency="USD"} 7.25` + "\n",
+        "kwa_container_cpu_requested_cores{" + workload + `,container="app"} 0.5` + "\n",
+        "kwa_container_cpu_used_cores{" + workload + `,container="app"} 0.25` + "\n",
+        "kwa_container_memory_requested_bytes{" + workload + `,container="app"} 2.68435456e+08` + "\n",
+        "kwa_container_memory_limit_bytes{" + workload + `,container="app"} 5.36870912e+08` + "\n",
+        "kwa_container_memory_used_bytes{" + workload + `,container="app"} 1.34217728e+08` + "\n",
+        "kwa_report_timestamp_seconds 1700000000\n",
+    } {
+        if !strings.Contains(out, want) {
+            t.Errorf("expected the output to contain %q", want)
+        }
+    }
+    // A family without samples, like an unset CPU limit, is left out
+    if strings.Contains(out, "kwa_container_cpu_limit_cores") {
+        t.Error("expected no CPU limit series without a limit")
+    }
+
+    // Every line is a comment or a sample of a valid metric name
+    sample := regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*(\{([a-zA-Z_][a-zA-Z0-9_]*="([^"\\]|\\.)*",?)*\})? \S+$`)
+    for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+        if !strings.HasPrefix(line, "# ") && !sample.MatchString(line) {
+            t.Errorf("invalid exposition line %q", line)
+        }
+    }
+}
+
+func TestWritePrometheusEscapesLabels(t *testing.T) {
+    details := &analyzer.WorkloadDetails{
+        Cluster:    `prod "eu"`,
+        Namespace:  `C:\shop`,
+        Kind:       "deployment",
+        Deployment: "web\nhttp_requests_total 1",
+    }
+    rep := New(analyzer.ClusterResult{Workloads: []*analyzer.WorkloadDetails{details}})
+    rep.GeneratedAt = time.Time{}
+    var buf bytes.Buffer
+    if err := rep.WritePrometheus(&buf); err != nil {
+        t.Fatal(err)
+    }
+    out := buf.String()
+
+    want := `kwa_workload_desired_replicas{cluster="prod \"eu\"",namespace="C:\\shop",kind="deployment",workload="web\nhttp_requests_total 1"} 0` + "\n"
+    if !strings.Contains(out, want) {
+        t.Errorf("expected escaped labels %q in:\n%s", want, out)
+    }
+    for _, line := range strings.Split(out, "\n") {
+        if strings.HasPrefix(line, "http_requests_total") {
+            t.Errorf("expected a newline in a label not to start a new sample:\n%s", out)
+        }
+    }
+    if strings.Contains(out, "kwa_report_timestamp_seconds") {
+        t.Error("expected no timestamp for a report without one")
+    }
+}
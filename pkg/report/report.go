@@ -0,0 +1,92 @@
+package report
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "time"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// SchemaVersion identifies the JSON layout of a Report. Bump it when fields
+// are removed or change meaning.
+const SchemaVersion = "kwa/v1"
+
+// Report is the result of one run in the form every command writes as JSON
+// and every renderer reads.
+type Report struct {
+    SchemaVersion string                   `json:"schema_version"`
+    GeneratedAt   time.Time                `json:"generated_at"`
+    Clusters      []analyzer.ClusterResult `json:"clusters"`
+    Comparison    *analyzer.Comparison     `json:"comparison,omitempty"`
+    Rules         []RuleInfo               `json:"rules,omitempty"`
+}
+
+// RuleInfo describes a rule the findings were evaluated with, so renderers
+// like SARIF don't need the engine that produced them.
+type RuleInfo struct {
+    ID          string            `json:"id"`
+    Name        string            `json:"name"`
+    Description string            `json:"description"`
+    Severity    analyzer.Severity `json:"severity"`
+}
+
+// Rule returns the rule with the given ID, or a bare one named after it for
+// reports that predate rule metadata.
+func (r *Report) Rule(id string) RuleInfo {
+    for _, rule := range r.Rules {
+        if rule.ID == id {
+            return rule
+        }
+    }
+    return RuleInfo{ID: id, Name: id}
+}
+
+func New(results ...analyzer.ClusterResult) *Report {
+    return &Report{
+        SchemaVersion: SchemaVersion,
+        GeneratedAt:   time.Now().UTC(),
+        Clusters:      results,
+    }
+}
+
+// Workloads returns every analyzed workload across clusters.
+func (r *Report) Workloads() []*analyzer.WorkloadDetails {
+    var workloads []*analyzer.WorkloadDetails
+    for _, cluster := range r.Clusters {
+        workloads = append(workloads, cluster.Workloads...)
+    }
+    return workloads
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(r)
+}
+
+func Decode(r io.Reader) (*Report, error) {
+    var rep Report
+    if err := json.NewDecoder(r).Decode(&rep); err != nil {
+        return nil, fmt.Errorf("failed to decode report: %v", err)
+    }
+    if rep.SchemaVersion != SchemaVersion {
+        return nil, fmt.Errorf("unsupported report schema %q (want %s)", rep.SchemaVersion, SchemaVersion)
+    }
+    return &rep, nil
+}
+
+// ReadFile loads a report written with -output=json; "-" reads stdin.
+func ReadFile(path string) (*Report, error) {
+    if path == "-" {
+        return Decode(os.Stdin)
+    }
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open report: %v", err)
+    }
+    defer f.Close()
+    return Decode(f)
+}
@@ -0,0 +1,173 @@
+package report
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "path/filepath"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+const (
+    sarifVersion = "2.1.0"
+    sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// The SARIF types cover the subset of the 2.1.0 format code scanning reads.
+type sarifLog struct {
+    Version string     `json:"version"`
+    Schema  string     `json:"$schema"`
+    Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+    Tool    sarifTool     `json:"tool"`
+    Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+    Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+    Name    string      `json:"name"`
+    Version string      `json:"version,omitempty"`
+    Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+    ID                   string             `json:"id"`
+    Name                 string             `json:"name"`
+    ShortDescription     sarifMessage       `json:"shortDescription"`
+    FullDescription      sarifMessage       `json:"fullDescription"`
+    DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+    Properties           sarifProperties    `json:"properties"`
+}
+
+type sarifConfiguration struct {
+    Level string `json:"level"`
+}
+
+type sarifProperties struct {
+    Severity string   `json:"severity"`
+    Tags     []string `json:"tags,omitempty"`
+}
+
+type sarifMessage struct {
+    Text string `json:"text"`
+}
+
+type sarifResult struct {
+    RuleID              string            `json:"ruleId"`
+    RuleIndex           int               `json:"ruleIndex"`
+    Level               string            `json:"level"`
+    Message             sarifMessage      `json:"message"`
+    Locations           []sarifLocation   `json:"locations"`
+    PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+    PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+    LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+    ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+    Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+    URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+    StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+    FullyQualifiedName string `json:"fullyQualifiedName"`
+    Kind               string `json:"kind"`
+}
+
+// sarifLevel maps severities onto the three SARIF levels.
+func sarifLevel(s analyzer.Severity) string {
+    switch {
+    case s.AtLeast(analyzer.SeverityHigh):
+        return "error"
+    case s.AtLeast(analyzer.SeverityMedium):
+        return "warning"
+    }
+    return "note"
+}
+
+// WriteSARIF writes the report's findings as a SARIF 2.1.0 log. Findings
+// from manifests point at the file and line of the container or workload;
+// findings from a cluster only have a logical location.
+func (r *Report) WriteSARIF(w io.Writer, toolVersion string) error {
+    run := sarifRun{
+        Tool: sarifTool{Driver: sarifDriver{
+            Name:    "kwa",
+            Version: toolVersion,
+        }},
+        Results: []sarifResult{},
+    }
+
+    ruleIndex := make(map[string]int)
+    addRule := func(id string) int {
+        if i, ok := ruleIndex[id]; ok {
+            return i
+        }
+        rule := r.Rule(id)
+        run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+            ID:                   rule.ID,
+            Name:                 rule.Name,
+            ShortDescription:     sarifMessage{Text: rule.Name},
+            FullDescription:      sarifMessage{Text: rule.Description},
+            DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+            Properties:           sarifProperties{Severity: string(rule.Severity), Tags: []string{"kubernetes"}},
+        })
+        ruleIndex[id] = len(run.Tool.Driver.Rules) - 1
+        return ruleIndex[id]
+    }
+
+    // Every evaluated rule is listed, even without results, so viewers can
+    // show what was checked
+    for _, rule := range r.Rules {
+        addRule(rule.ID)
+    }
+
+    for _, details := range r.Workloads() {
+        name := fmt.Sprintf("%s/%s/%s", details.Namespace, details.Kind, details.Deployment)
+        if details.Cluster != "" {
+            name = details.Cluster + "/" + name
+        }
+        for _, f := range details.Findings {
+            location := sarifLocation{
+                LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: name, Kind: "object"}},
+            }
+            if details.Source != nil {
+                location.PhysicalLocation = &sarifPhysicalLocation{
+                    ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(details.Source.File)},
+                    Region:           sarifRegion{StartLine: details.Source.LineOf(f.Container)},
+                }
+            }
+            run.Results = append(run.Results, sarifResult{
+                RuleID:    f.RuleID,
+                RuleIndex: addRule(f.RuleID),
+                Level:     sarifLevel(f.Severity),
+                Message:   sarifMessage{Text: f.Message},
+                Locations: []sarifLocation{location},
+                // Stable across runs so code scanning can track a finding
+                PartialFingerprints: map[string]string{"kwaFinding/v1": name + "/" + f.ID},
+            })
+        }
+    }
+    if run.Tool.Driver.Rules == nil {
+        run.Tool.Driver.Rules = []sarifRule{}
+    }
+
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}})
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+    "bytes"
+    "encoding/json"
+    "flag"
+    "os"
+    "testing"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/manifest"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// manifestReport loads testdata/manifests.yaml with findings on the app
+// container and on the workload as a whole.
+func manifestReport(t *testing.T) *Report {
+    t.Helper()
+    workloads, err := manifest.Load([]string{"testdata/manifests.yaml"}, "shop")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(workloads) != 1 {
+        t.Fatalf("expected 1 workload, got %d", len(workloads))
+    }
+    workloads[0].Findings = []analyzer.Finding{
+        {ID: "KWA001/app", RuleID: "KWA001", Severity: analyzer.SeverityHigh, Container: "app", Message: "container app has no memory limit"},
+        {ID: "KWA006", RuleID: "KWA006", Severity: analyzer.SeverityMedium, Message: "deployment web runs a single replica"},
+    }
+    rep := New(analyzer.ClusterResult{Workloads: workloads})
+    rep.Rules = []RuleInfo{
+        {ID: "KWA001", Name: "memory-limit", Description: "Containers should set a memory limit.", Severity: analyzer.SeverityHigh},
+        {ID: "KWA002", Name: "cpu-request", Description: "Containers should request CPU.", Severity: analyzer.SeverityMedium},
+        {ID: "KWA006", Name: "single-replica", Description: "Workloads should run more than one replica.", Severity: analyzer.SeverityMedium},
+    }
+    return rep
+}
+
+func TestWriteSARIF(t *testing.T) {
+    var buf bytes.Buffer
+    if err := manifestReport(t).WriteSARIF(&buf, "1.2.3"); err != nil {
+        t.Fatal(err)
+    }
+
+    golden := "testdata/report.sarif"
+    if *update {
+        if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    want, err := os.ReadFile(golden)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.Equal(buf.Bytes(), want) {
+        t.Errorf("SARIF differs from %s (run with -update to accept):\n%s", golden, buf.String())
+    }
+
+    var log struct {
+        Version string `json:"version"`
+        Schema  string `json:"$schema"`
+        Runs    []struct {
+            Tool struct {
+                Driver map[string]json.RawMessage `json:"driver"`
+            } `json:"tool"`
+            Results []struct {
+                RuleID    string `json:"ruleId"`
+                RuleIndex int    `json:"ruleIndex"`
+                Locations []struct {
+                    PhysicalLocation struct {
+                        ArtifactLocation struct {
+                            URI string `json:"uri"`
+                        } `json:"artifactLocation"`
+                        Region struct {
+                            StartLine int `json:"startLine"`
+                        } `json:"region"`
+                    } `json:"physicalLocation"`
+                } `json:"locations"`
+            } `json:"results"`
+        } `json:"runs"`
+    }
+    if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+        t.Fatal(err)
+    }
+    if log.Version != "2.1.0" || log.Schema != "https://json.schemastore.org/sarif-2.1.0.json" || len(log.Runs) != 1 {
+        t.Fatalf("unexpected SARIF header: version %q, schema %q, %d runs", log.Version, log.Schema, len(log.Runs))
+    }
+    if _, ok := log.Runs[0].Tool.Driver["informationUri"]; ok {
+        t.Error("expected no informationUri")
+    }
+
+    // The app container is on line 20, the deployment document starts on 6
+    wantLines := map[string]int{"KWA001": 20, "KWA006": 6}
+    wantIndex := map[string]int{"KWA001": 0, "KWA006": 2}
+    for _, result := range log.Runs[0].Results {
+        location := result.Locations[0].PhysicalLocation
+        if location.ArtifactLocation.URI != "testdata/manifests.yaml" || location.Region.StartLine != wantLines[result.RuleID] {
+            t.Errorf("%s: expected testdata/manifests.yaml:%d, got %s:%d", result.RuleID, wantLines[result.RuleID], location.ArtifactLocation.URI, location.Region.StartLine)
+        }
+        if result.RuleIndex != wantIndex[result.RuleID] {
+            t.Errorf("%s: expected rule index %d, got %d", result.RuleID, wantIndex[result.RuleID], result.RuleIndex)
+        }
+    }
+}
@@ -0,0 +1,123 @@
+package retry
+
+import (
+    "context"
+    "errors"
+    "math/rand"
+    "net/http"
+    "strconv"
+    "time"
+)
+
+// Policy controls how many times an operation is attempted and how long to
+// wait between attempts.
+type Policy struct {
+    MaxAttempts int
+    BaseDelay   time.Duration
+    MaxDelay    time.Duration
+}
+
+var DefaultPolicy = Policy{
+    MaxAttempts: 4,
+    BaseDelay:   500 * time.Millisecond,
+    MaxDelay:    30 * time.Second,
+}
+
+// Error marks a failure as transient. After, when set, is the delay the
+// server asked for (e.g. via Retry-After) and takes precedence over backoff,
+// capped at the policy's MaxDelay.
+type Error struct {
+    Err   error
+    After time.Duration
+}
+
+func (e *Error) Error() string {
+    return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+    return e.Err
+}
+
+// Retryable wraps err so that Do will try the operation again.
+func Retryable(err error, after time.Duration) error {
+    if err == nil {
+        return nil
+    }
+    return &Error{Err: err, After: after}
+}
+
+// Do runs fn until it succeeds, returns a non-retryable error, the policy is
+// exhausted or ctx is done. Delays use exponential backoff with full jitter.
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+    if policy.MaxAttempts < 1 {
+        policy.MaxAttempts = 1
+    }
+
+    for attempt := 1; ; attempt++ {
+        err := fn(ctx)
+        if err == nil {
+            return nil
+        }
+
+        var retryErr *Error
+        if !errors.As(err, &retryErr) {
+            return err
+        }
+        if attempt >= policy.MaxAttempts {
+            return retryErr.Err
+        }
+        if ctx.Err() != nil {
+            return ctx.Err()
+        }
+
+        delay := retryErr.After
+        if delay <= 0 {
+            delay = policy.backoff(attempt)
+        } else if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+            // Don't let a server stall us for longer than the policy allows
+            delay = policy.MaxDelay
+        }
+
+        timer := time.NewTimer(delay)
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return ctx.Err()
+        case <-timer.C:
+        }
+    }
+}
+
+func (p Policy) backoff(attempt int) time.Duration {
+    delay := p.BaseDelay << (attempt - 1)
+    if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+        delay = p.MaxDelay
+    }
+    if delay <= 0 {
+        return 0
+    }
+    return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// ParseRetryAfter reads a Retry-After header value given either in seconds or
+// as an HTTP date. It returns zero when the header is absent or malformed.
+func ParseRetryAfter(value string) time.Duration {
+    if value == "" {
+        return 0
+    }
+    if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+        return time.Duration(seconds) * time.Second
+    }
+    if when, err := http.ParseTime(value); err == nil {
+        if d := time.Until(when); d > 0 {
+            return d
+        }
+    }
+    return 0
+}
+
+// StatusRetryable reports whether an HTTP status code is worth retrying.
+func StatusRetryable(code int) bool {
+    return code == http.StatusTooManyRequests || code >= 500
+}
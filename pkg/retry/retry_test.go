@@ -0,0 +1,164 @@
+package retry
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "testing"
+    "time"
+)
+
+func TestBackoff(t *testing.T) {
+    p := Policy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+    tests := []struct {
+        attempt int
+        max     time.Duration
+    }{
+        {1, 100 * time.Millisecond},
+        {2, 200 * time.Millisecond},
+        {3, 400 * time.Millisecond},
+        {4, 800 * time.Millisecond},
+        {5, time.Second},
+        {40, time.Second},
+        {70, time.Second}, // the shift overflows
+    }
+    for _, tt := range tests {
+        seen := make(map[time.Duration]bool)
+        for i := 0; i < 50; i++ {
+            d := p.backoff(tt.attempt)
+            if d <= 0 || d > tt.max {
+                t.Fatalf("attempt %d: delay %v outside (0, %v]", tt.attempt, d, tt.max)
+            }
+            seen[d] = true
+        }
+        if len(seen) < 2 {
+            t.Errorf("attempt %d: expected jittered delays, got %v", tt.attempt, seen)
+        }
+    }
+
+    if d := (Policy{}).backoff(3); d != 0 {
+        t.Errorf("expected no delay without a base or max delay, got %v", d)
+    }
+}
+
+func TestParseRetryAfter(t *testing.T) {
+    tests := []struct {
+        name  string
+        value string
+        min   time.Duration
+        max   time.Duration
+    }{
+        {"empty", "", 0, 0},
+        {"seconds", "120", 120 * time.Second, 120 * time.Second},
+        {"zero", "0", 0, 0},
+        {"negative", "-5", 0, 0},
+        {"garbage", "soon", 0, 0},
+        {"http date", time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat), 80 * time.Second, 90 * time.Second},
+        {"past http date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := ParseRetryAfter(tt.value); got < tt.min || got > tt.max {
+                t.Errorf("ParseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+            }
+        })
+    }
+}
+
+func TestStatusRetryable(t *testing.T) {
+    tests := map[int]bool{
+        http.StatusOK:                  false,
+        http.StatusBadRequest:          false,
+        http.StatusUnauthorized:        false,
+        http.StatusNotFound:            false,
+        http.StatusTooManyRequests:     true,
+        http.StatusInternalServerError: true,
+        http.StatusBadGateway:          true,
+        http.StatusServiceUnavailable:  true,
+        http.StatusGatewayTimeout:      true,
+    }
+    for code, want := range tests {
+        if got := StatusRetryable(code); got != want {
+            t.Errorf("StatusRetryable(%d) = %v, want %v", code, got, want)
+        }
+    }
+}
+
+func TestDo(t *testing.T) {
+    p := Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+    transient := errors.New("transient")
+    permanent := errors.New("permanent")
+
+    tests := []struct {
+        name     string
+        errs     []error
+        want     error
+        attempts int
+    }{
+        {"success", []error{nil}, nil, 1},
+        {"retried", []error{Retryable(transient, 0), nil}, nil, 2},
+        {"permanent", []error{permanent}, permanent, 1},
+        {"exhausted", []error{Retryable(transient, 0), Retryable(transient, 0), Retryable(transient, 0)}, transient, 3},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            attempts := 0
+            err := Do(context.Background(), p, func(ctx context.Context) error {
+                err := tt.errs[attempts]
+                attempts++
+                return err
+            })
+            if err != tt.want {
+                t.Errorf("expected %v, got %v", tt.want, err)
+            }
+            if attempts != tt.attempts {
+                t.Errorf("expected %d attempts, got %d", tt.attempts, attempts)
+            }
+        })
+    }
+}
+
+func TestDoCapsRetryAfter(t *testing.T) {
+    p := Policy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+    attempts := 0
+    start := time.Now()
+    err := Do(context.Background(), p, func(ctx context.Context) error {
+        attempts++
+        if attempts == 1 {
+            return Retryable(errors.New("rate limited"), time.Hour)
+        }
+        return nil
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Errorf("expected Retry-After to be capped at MaxDelay, waited %v", elapsed)
+    }
+}
+
+func TestDoCanceledWhileSleeping(t *testing.T) {
+    p := Policy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+    ctx, cancel := context.WithCancel(context.Background())
+    attempts := 0
+    go func() {
+        time.Sleep(20 * time.Millisecond)
+        cancel()
+    }()
+
+    start := time.Now()
+    err := Do(ctx, p, func(ctx context.Context) error {
+        attempts++
+        return Retryable(errors.New("transient"), time.Hour)
+    })
+    if !errors.Is(err, context.Canceled) {
+        t.Errorf("expected context.Canceled, got %v", err)
+    }
+    if attempts != 1 {
+        t.Errorf("expected no attempt after cancellation, got %d", attempts)
+    }
+    if elapsed := time.Since(start); elapsed > 5*time.Second {
+        t.Errorf("expected cancellation to cut the sleep short, waited %v", elapsed)
+    }
+}
@@ -0,0 +1,344 @@
+package rules
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+
+    corev1 "k8s.io/api/core/v1"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// Rule is a deterministic check over an analyzed workload. Check returns one
+// violation per offending container, or a single one with an empty Container
+// for workload-level issues. Skip, when set, returns why the rule doesn't
+// apply to a workload, or "" when it does.
+type Rule struct {
+    ID          string
+    Name        string
+    Description string
+    Severity    analyzer.Severity
+    Check       func(details *analyzer.WorkloadDetails) []Violation
+    Skip        func(details *analyzer.WorkloadDetails) string
+}
+
+type Violation struct {
+    Container string
+    Message   string
+}
+
+// Override enables/disables a rule or changes its severity.
+type Override struct {
+    Enabled  *bool
+    Severity analyzer.Severity
+}
+
+// Options configures the engine. Overrides are keyed by rule ID or name.
+// The usage thresholds are percentages: a container is over-provisioned when
+// it uses less than OverProvisionedBelow of its request, and near its limit
+// above NearLimitAbove.
+type Options struct {
+    Overrides            map[string]Override
+    OverProvisionedBelow float64
+    NearLimitAbove       float64
+}
+
+func DefaultOptions() Options {
+    return Options{OverProvisionedBelow: 50, NearLimitAbove: 90}
+}
+
+// Engine evaluates the built-in rules against workloads.
+type Engine struct {
+    rules []Rule
+}
+
+func NewEngine(opts Options) (*Engine, error) {
+    all := builtinRules(opts.OverProvisionedBelow/100, opts.NearLimitAbove/100)
+
+    known := make(map[string]bool)
+    for _, rule := range all {
+        known[rule.ID] = true
+        known[rule.Name] = true
+    }
+    for key := range opts.Overrides {
+        if !known[key] {
+            return nil, fmt.Errorf("unknown rule %q", key)
+        }
+    }
+
+    var enabled []Rule
+    for _, rule := range all {
+        for _, key := range []string{rule.Name, rule.ID} {
+            override, ok := opts.Overrides[key]
+            if !ok {
+                continue
+            }
+            if override.Enabled != nil && !*override.Enabled {
+                rule.Check = nil
+            }
+            if override.Severity != "" {
+                rule.Severity = override.Severity
+            }
+        }
+        if rule.Check != nil {
+            enabled = append(enabled, rule)
+        }
+    }
+    return &Engine{rules: enabled}, nil
+}
+
+// Rules returns the rules the engine evaluates.
+func (e *Engine) Rules() []Rule {
+    return e.rules
+}
+
+// Evaluate runs every rule that applies and returns findings sorted by
+// severity, most severe first, then by ID.
+func (e *Engine) Evaluate(details *analyzer.WorkloadDetails) []analyzer.Finding {
+    findings := []analyzer.Finding{}
+    for _, rule := range e.rules {
+        if skipReason(rule, details) != "" {
+            continue
+        }
+        for _, v := range rule.Check(details) {
+            findings = append(findings, newFinding(rule, v))
+        }
+    }
+
+    sort.SliceStable(findings, func(i, j int) bool {
+        if findings[i].Severity.Rank() != findings[j].Severity.Rank() {
+            return findings[i].Severity.Rank() > findings[j].Severity.Rank()
+        }
+        return findings[i].ID < findings[j].ID
+    })
+    return findings
+}
+
+// Skipped returns the rules that don't apply to the workload, keyed by ID,
+// with the reason. It is nil when every rule applies.
+func (e *Engine) Skipped(details *analyzer.WorkloadDetails) map[string]string {
+    var skipped map[string]string
+    for _, rule := range e.rules {
+        if reason := skipReason(rule, details); reason != "" {
+            if skipped == nil {
+                skipped = make(map[string]string)
+            }
+            skipped[rule.ID] = reason
+        }
+    }
+    return skipped
+}
+
+func skipReason(rule Rule, details *analyzer.WorkloadDetails) string {
+    if rule.Skip == nil {
+        return ""
+    }
+    return rule.Skip(details)
+}
+
+func newFinding(rule Rule, v Violation) analyzer.Finding {
+    id := rule.ID
+    if v.Container != "" {
+        id = fmt.Sprintf("%s/%s", rule.ID, v.Container)
+    }
+    return analyzer.Finding{
+        ID:        id,
+        RuleID:    rule.ID,
+        Severity:  rule.Severity,
+        Container: v.Container,
+        Message:   v.Message,
+    }
+}
+
+// builtinRules returns every rule. lowUsage is the share of a request below
+// which a container is over-provisioned, highUsage the share of a limit above
+// which it is at risk of OOM kills.
+func builtinRules(lowUsage, highUsage float64) []Rule {
+    return []Rule{
+        {
+            ID:          "KWA001",
+            Name:        "missing-requests",
+            Description: "Containers should set CPU and memory requests so the scheduler can place them.",
+            Severity:    analyzer.SeverityHigh,
+            Skip:        withoutSpec,
+            Check: eachContainer(func(c corev1.Container) string {
+                var missing []string
+                if c.Resources.Requests.Cpu().IsZero() {
+                    missing = append(missing, "cpu")
+                }
+                if c.Resources.Requests.Memory().IsZero() {
+                    missing = append(missing, "memory")
+                }
+                if len(missing) == 0 {
+                    return ""
+                }
+                return fmt.Sprintf("container %q has no %s request", c.Name, strings.Join(missing, " or "))
+            }),
+        },
+        {
+            ID:          "KWA002",
+            Name:        "missing-memory-limit",
+            Description: "Containers should set a memory limit to bound the blast radius of a leak.",
+            Severity:    analyzer.SeverityMedium,
+            Skip:        withoutSpec,
+            Check: eachContainer(func(c corev1.Container) string {
+                if c.Resources.Limits.Memory().IsZero() {
+                    return fmt.Sprintf("container %q has no memory limit", c.Name)
+                }
+                return ""
+            }),
+        },
+        {
+            ID:          "KWA003",
+            Name:        "privileged",
+            Description: "Containers should not run privileged.",
+            Severity:    analyzer.SeverityCritical,
+            Skip:        withoutSpec,
+            Check: eachContainer(func(c corev1.Container) string {
+                if c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged {
+                    return fmt.Sprintf("container %q runs privileged", c.Name)
+                }
+                return ""
+            }),
+        },
+        {
+            ID:          "KWA004",
+            Name:        "missing-readiness-probe",
+            Description: "Containers should define a readiness probe so traffic only reaches ready pods.",
+            Severity:    analyzer.SeverityMedium,
+            Skip:        withoutSpec,
+            Check: eachContainer(func(c corev1.Container) string {
+                if c.ReadinessProbe == nil {
+                    return fmt.Sprintf("container %q has no readiness probe", c.Name)
+                }
+                return ""
+            }),
+        },
+        {
+            ID:          "KWA005",
+            Name:        "mutable-image-tag",
+            Description: "Images should be pinned to a tag other than latest, or a digest.",
+            Severity:    analyzer.SeverityLow,
+            Skip:        withoutSpec,
+            Check: eachContainer(func(c corev1.Container) string {
+                if mutableTag(c.Image) {
+                    return fmt.Sprintf("container %q uses mutable image %q", c.Name, c.Image)
+                }
+                return ""
+            }),
+        },
+        {
+            ID:          "KWA006",
+            Name:        "single-replica",
+            Description: "Deployments and StatefulSets should run more than one replica.",
+            Severity:    analyzer.SeverityMedium,
+            Skip: func(details *analyzer.WorkloadDetails) string {
+                if details.Kind == "daemonset" {
+                    return "daemonsets run one pod per node"
+                }
+                return ""
+            },
+            Check: func(details *analyzer.WorkloadDetails) []Violation {
+                if details.DesiredReplicas > 1 {
+                    return nil
+                }
+                return []Violation{{Message: fmt.Sprintf("%s %q runs %d replica(s)", details.Kind, details.Deployment, details.DesiredReplicas)}}
+            },
+        },
+        {
+            ID:          "KWA007",
+            Name:        "over-provisioned",
+            Description: "Container usage is well below its requests.",
+            Severity:    analyzer.SeverityLow,
+            Skip:        withoutUsage,
+            Check: eachContainerUsage(func(c analyzer.ContainerDetails) string {
+                var low []string
+                if c.CPURequest > 0 && float64(c.CPUUsage) < float64(c.CPURequest)*lowUsage {
+                    low = append(low, fmt.Sprintf("cpu %dm of %dm", c.CPUUsage, c.CPURequest))
+                }
+                if c.MemoryRequest > 0 && float64(c.MemoryUsage) < float64(c.MemoryRequest)*lowUsage {
+                    low = append(low, fmt.Sprintf("memory %dMi of %dMi", c.MemoryUsage>>20, c.MemoryRequest>>20))
+                }
+                if len(low) == 0 {
+                    return ""
+                }
+                return fmt.Sprintf("container %q uses %s requested", c.Name, strings.Join(low, ", "))
+            }),
+        },
+        {
+            ID:          "KWA008",
+            Name:        "near-memory-limit",
+            Description: "Container memory usage is close to its limit and at risk of OOM kills.",
+            Severity:    analyzer.SeverityHigh,
+            Skip:        withoutUsage,
+            Check: eachContainerUsage(func(c analyzer.ContainerDetails) string {
+                if c.MemoryLimit > 0 && float64(c.MemoryUsage) >= float64(c.MemoryLimit)*highUsage {
+                    return fmt.Sprintf("container %q uses %dMi of its %dMi memory limit", c.Name, c.MemoryUsage>>20, c.MemoryLimit>>20)
+                }
+                return ""
+            }),
+        },
+    }
+}
+
+// withoutSpec skips spec rules for workloads without a pod template, e.g.
+// ones loaded from a saved report.
+func withoutSpec(details *analyzer.WorkloadDetails) string {
+    if details.PodSpec == nil {
+        return "no pod template"
+    }
+    return ""
+}
+
+// withoutUsage skips usage rules when no container has metrics.
+func withoutUsage(details *analyzer.WorkloadDetails) string {
+    for _, c := range details.Containers {
+        if c.HasUsage {
+            return ""
+        }
+    }
+    return "no usage metrics"
+}
+
+// eachContainer adapts a per-container spec check into a rule check.
+func eachContainer(check func(c corev1.Container) string) func(*analyzer.WorkloadDetails) []Violation {
+    return func(details *analyzer.WorkloadDetails) []Violation {
+        if details.PodSpec == nil {
+            return nil
+        }
+        var violations []Violation
+        for _, c := range details.PodSpec.Containers {
+            if msg := check(c); msg != "" {
+                violations = append(violations, Violation{Container: c.Name, Message: msg})
+            }
+        }
+        return violations
+    }
+}
+
+// eachContainerUsage adapts a per-container usage check into a rule check,
+// skipping containers without metrics.
+func eachContainerUsage(check func(c analyzer.ContainerDetails) string) func(*analyzer.WorkloadDetails) []Violation {
+    return func(details *analyzer.WorkloadDetails) []Violation {
+        var violations []Violation
+        for _, c := range details.Containers {
+            if !c.HasUsage {
+                continue
+            }
+            if msg := check(c); msg != "" {
+                violations = append(violations, Violation{Container: c.Name, Message: msg})
+            }
+        }
+        return violations
+    }
+}
+
+func mutableTag(image string) bool {
+    if strings.Contains(image, "@") {
+        return false
+    }
+    // A colon after the last slash separates the tag; earlier ones are registry ports
+    name := image[strings.LastIndex(image, "/")+1:]
+    idx := strings.LastIndex(name, ":")
+    return idx < 0 || name[idx+1:] == "latest"
+}
@@ -0,0 +1,221 @@
+package rules
+
+import (
+    "strings"
+    "testing"
+
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/resource"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// healthy returns a deployment that passes every rule: two replicas of a
+// pinned image with requests, a memory limit, a readiness probe, and usage
+// between the over-provisioned and near-limit thresholds.
+func healthy() *analyzer.WorkloadDetails {
+    return &analyzer.WorkloadDetails{
+        Namespace:       "shop",
+        Deployment:      "web",
+        Kind:            "deployment",
+        DesiredReplicas: 2,
+        PodSpec: &corev1.PodSpec{
+            Containers: []corev1.Container{{
+                Name:  "app",
+                Image: "registry.local:5000/shop/web:1.4.2",
+                Resources: corev1.ResourceRequirements{
+                    Requests: corev1.ResourceList{
+                        corev1.ResourceCPU:    resource.MustParse("200m"),
+                        corev1.ResourceMemory: resource.MustParse("256Mi"),
+                    },
+                    Limits: corev1.ResourceList{
+                        corev1.ResourceMemory: resource.MustParse("512Mi"),
+                    },
+                },
+                ReadinessProbe: &corev1.Probe{},
+            }},
+        },
+        Containers: []analyzer.ContainerDetails{{
+            Name:          "app",
+            CPURequest:    200,
+            MemoryRequest: 256 << 20,
+            MemoryLimit:   512 << 20,
+            HasUsage:      true,
+            CPUUsage:      150,
+            MemoryUsage:   200 << 20,
+        }},
+    }
+}
+
+func container(details *analyzer.WorkloadDetails) *corev1.Container {
+    return &details.PodSpec.Containers[0]
+}
+
+func TestRules(t *testing.T) {
+    privileged := true
+    unprivileged := false
+
+    tests := []struct {
+        name   string
+        ruleID string
+        modify func(details *analyzer.WorkloadDetails)
+        want   string // message of the finding; "" for none
+    }{
+        {"missing cpu request", "KWA001", func(d *analyzer.WorkloadDetails) {
+            delete(container(d).Resources.Requests, corev1.ResourceCPU)
+        }, `container "app" has no cpu request`},
+        {"requests set", "KWA001", func(d *analyzer.WorkloadDetails) {}, ""},
+
+        {"missing memory limit", "KWA002", func(d *analyzer.WorkloadDetails) {
+            container(d).Resources.Limits = nil
+        }, `container "app" has no memory limit`},
+        {"memory limit set", "KWA002", func(d *analyzer.WorkloadDetails) {}, ""},
+
+        {"privileged", "KWA003", func(d *analyzer.WorkloadDetails) {
+            container(d).SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+        }, `container "app" runs privileged`},
+        {"explicitly unprivileged", "KWA003", func(d *analyzer.WorkloadDetails) {
+            container(d).SecurityContext = &corev1.SecurityContext{Privileged: &unprivileged}
+        }, ""},
+
+        {"missing readiness probe", "KWA004", func(d *analyzer.WorkloadDetails) {
+            container(d).ReadinessProbe = nil
+        }, `container "app" has no readiness probe`},
+        {"readiness probe set", "KWA004", func(d *analyzer.WorkloadDetails) {}, ""},
+
+        {"latest tag", "KWA005", func(d *analyzer.WorkloadDetails) {
+            container(d).Image = "registry.local:5000/shop/web:latest"
+        }, `container "app" uses mutable image "registry.local:5000/shop/web:latest"`},
+        {"pinned digest", "KWA005", func(d *analyzer.WorkloadDetails) {
+            container(d).Image = "registry.local:5000/shop/web@sha256:0123"
+        }, ""},
+
+        {"single replica", "KWA006", func(d *analyzer.WorkloadDetails) {
+            d.DesiredReplicas = 1
+        }, `deployment "web" runs 1 replica(s)`},
+        {"single daemonset pod", "KWA006", func(d *analyzer.WorkloadDetails) {
+            d.Kind = "daemonset"
+            d.DesiredReplicas = 1
+        }, ""},
+
+        {"over-provisioned", "KWA007", func(d *analyzer.WorkloadDetails) {
+            d.Containers[0].CPUUsage = 50
+        }, `container "app" uses cpu 50m of 200m requested`},
+        {"usage near requests", "KWA007", func(d *analyzer.WorkloadDetails) {}, ""},
+
+        {"near memory limit", "KWA008", func(d *analyzer.WorkloadDetails) {
+            d.Containers[0].MemoryUsage = 480 << 20
+        }, `container "app" uses 480Mi of its 512Mi memory limit`},
+        {"below memory limit", "KWA008", func(d *analyzer.WorkloadDetails) {}, ""},
+    }
+
+    engine, err := NewEngine(DefaultOptions())
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, tt := range tests {
+        t.Run(tt.ruleID+" "+tt.name, func(t *testing.T) {
+            details := healthy()
+            tt.modify(details)
+
+            var got []analyzer.Finding
+            for _, f := range engine.Evaluate(details) {
+                if f.RuleID == tt.ruleID {
+                    got = append(got, f)
+                } else {
+                    t.Errorf("unexpected finding from %s: %s", f.RuleID, f.Message)
+                }
+            }
+            switch {
+            case tt.want == "" && len(got) != 0:
+                t.Errorf("expected no %s finding, got %+v", tt.ruleID, got)
+            case tt.want != "" && (len(got) != 1 || got[0].Message != tt.want):
+                t.Errorf("expected one %s finding %q, got %+v", tt.ruleID, tt.want, got)
+            }
+        })
+    }
+}
+
+func TestEvaluateOrder(t *testing.T) {
+    details := healthy()
+    container(details).Image = "web"
+    container(details).ReadinessProbe = nil
+    container(details).SecurityContext = &corev1.SecurityContext{}
+    details.DesiredReplicas = 1
+
+    engine, err := NewEngine(DefaultOptions())
+    if err != nil {
+        t.Fatal(err)
+    }
+    var ids []string
+    for _, f := range engine.Evaluate(details) {
+        ids = append(ids, f.ID)
+    }
+    want := "KWA004/app,KWA006,KWA005/app"
+    if strings.Join(ids, ",") != want {
+        t.Errorf("expected findings %s, got %s", want, strings.Join(ids, ","))
+    }
+}
+
+func TestEngineOptions(t *testing.T) {
+    disabled := false
+    details := healthy()
+    details.DesiredReplicas = 1
+    details.Containers[0].CPUUsage = 90
+
+    engine, err := NewEngine(Options{
+        Overrides: map[string]Override{
+            "single-replica": {Enabled: &disabled},
+            "KWA007":         {Severity: analyzer.SeverityHigh},
+        },
+        OverProvisionedBelow: 50,
+        NearLimitAbove:       90,
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    findings := engine.Evaluate(details)
+    if len(findings) != 1 || findings[0].RuleID != "KWA007" || findings[0].Severity != analyzer.SeverityHigh {
+        t.Errorf("expected only KWA007 at high severity, got %+v", findings)
+    }
+
+    // Raising the threshold makes the same usage over-provisioned no longer
+    engine, err = NewEngine(Options{OverProvisionedBelow: 40, NearLimitAbove: 90})
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, f := range engine.Evaluate(details) {
+        if f.RuleID == "KWA007" {
+            t.Errorf("expected 90m of 200m to pass a 40%% threshold, got %s", f.Message)
+        }
+    }
+
+    if _, err := NewEngine(Options{Overrides: map[string]Override{"KWA999": {}}}); err == nil {
+        t.Error("expected an unknown rule override to be an error")
+    }
+}
+
+func TestSkipped(t *testing.T) {
+    engine, err := NewEngine(DefaultOptions())
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    details := healthy()
+    details.PodSpec = nil
+    details.Containers[0].HasUsage = false
+    details.Kind = "daemonset"
+
+    skipped := engine.Skipped(details)
+    if len(skipped) != 8 {
+        t.Errorf("expected every rule to be skipped, got %v", skipped)
+    }
+    if skipped["KWA001"] != "no pod template" || skipped["KWA006"] != "daemonsets run one pod per node" || skipped["KWA008"] != "no usage metrics" {
+        t.Errorf("unexpected skip reasons: %v", skipped)
+    }
+    if findings := engine.Evaluate(details); len(findings) != 0 {
+        t.Errorf("expected skipped rules to produce no findings, got %+v", findings)
+    }
+    if skipped := engine.Skipped(healthy()); skipped != nil {
+        t.Errorf("expected no rules to be skipped, got %v", skipped)
+    }
+}
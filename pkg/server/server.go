@@ -0,0 +1,414 @@
+package server
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log/slog"
+    "maps"
+    "net/http"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+
+    "go.opentelemetry.io/otel/attribute"
+    apierrors "k8s.io/apimachinery/pkg/api/errors"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/client-go/kubernetes"
+    metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/logging"
+    "k8s-workload-analyzer/pkg/manifest"
+    "k8s-workload-analyzer/pkg/report"
+    "k8s-workload-analyzer/pkg/tracing"
+)
+
+// maxBodySize bounds POST /analyze bodies, inline manifests included.
+const maxBodySize = 1 << 20
+
+// readyTimeout bounds the Kubernetes API call of a readiness probe, so a
+// hung API server fails the probe instead of hanging it.
+var readyTimeout = 5 * time.Second
+
+// Config is what the server needs to analyze workloads in one cluster.
+type Config struct {
+    Cluster   string
+    Namespace string // used when a request doesn't name one
+    Client    kubernetes.Interface
+    Metrics   metricsv.Interface // nil disables usage collection
+
+    // Finish fills in findings and cost, like the CLI does for every
+    // analyzed workload. NewReport wraps results in the CLI's JSON schema.
+    Finish    func(ctx context.Context, details *analyzer.WorkloadDetails, cluster string)
+    NewReport func(results ...analyzer.ClusterResult) *report.Report
+
+    // Enrich adds the AI analysis of a workload in the cluster. Nil unless
+    // AI requests are enabled, since any caller can spend the credits.
+    Enrich func(ctx context.Context, details *analyzer.WorkloadDetails) error
+
+    // Timeout bounds the work of one request; 0 disables.
+    Timeout time.Duration
+
+    // Refresh rescans Namespace on this interval so /metrics stays current
+    // without clients calling /scan; 0 disables.
+    Refresh time.Duration
+}
+
+// Server serves analyses over HTTP and caches the latest result of every
+// workload it analyzed.
+type Server struct {
+    cfg   Config
+    mux   *http.ServeMux
+    mu    sync.RWMutex
+    cache map[string]*report.Report
+}
+
+func New(cfg Config) *Server {
+    s := &Server{
+        cfg:   cfg,
+        mux:   http.NewServeMux(),
+        cache: make(map[string]*report.Report),
+    }
+    s.mux.HandleFunc("POST /analyze", s.handleAnalyze)
+    s.mux.HandleFunc("GET /workloads/{namespace}/{kind}/{name}", s.handleWorkload)
+    s.mux.HandleFunc("GET /scan", s.handleScan)
+    s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+    s.mux.HandleFunc("GET /healthz", s.handleHealth)
+    s.mux.HandleFunc("GET /readyz", s.handleReady)
+    return s
+}
+
+// ServeHTTP tags each request with an ID, returned in X-Request-Id, that its
+// Kubernetes and AI calls are logged with, and traces it as its own trace.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    id := logging.NewRequestID()
+    w.Header().Set("X-Request-Id", id)
+    ctx := logging.WithRequestID(r.Context(), id)
+    ctx, span := tracing.StartRoot(ctx, r.Method+" "+r.URL.Path, tracing.RequestID.String(id))
+    defer span.End()
+
+    start := time.Now()
+    sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+    s.mux.ServeHTTP(sw, r.WithContext(ctx))
+    span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+
+    // Probes would drown out the requests worth reading
+    level := slog.LevelInfo
+    if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+        level = slog.LevelDebug
+    }
+    slog.Log(ctx, level, "http request", "method", r.Method, "path", r.URL.Path, "status", sw.status, "duration", time.Since(start))
+}
+
+// statusWriter remembers the status code written for the request log.
+type statusWriter struct {
+    http.ResponseWriter
+    status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+    w.status = status
+    w.ResponseWriter.WriteHeader(status)
+}
+
+// ListenAndServe serves on addr until ctx is done, then shuts down
+// gracefully, giving in-flight requests up to shutdownTimeout to finish.
+func (s *Server) ListenAndServe(ctx context.Context, addr string, shutdownTimeout time.Duration) error {
+    srv := &http.Server{
+        Addr:              addr,
+        Handler:           s,
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+
+    errc := make(chan error, 1)
+    go func() {
+        errc <- srv.ListenAndServe()
+    }()
+    if s.cfg.Refresh > 0 {
+        go s.refresh(ctx)
+    }
+
+    select {
+    case err := <-errc:
+        return fmt.Errorf("failed to serve: %v", err)
+    case <-ctx.Done():
+    }
+
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+    defer cancel()
+    if err := srv.Shutdown(shutdownCtx); err != nil {
+        return fmt.Errorf("failed to shut down: %v", err)
+    }
+    return nil
+}
+
+// AnalyzeRequest is the body of POST /analyze: either a workload in the
+// cluster, or an inline manifest checked without the cluster.
+type AnalyzeRequest struct {
+    Namespace string `json:"namespace"`
+    Kind      string `json:"kind"`
+    Name      string `json:"name"`
+    Manifest  string `json:"manifest"`
+    AI        bool   `json:"ai"`
+}
+
+func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+    var req AnalyzeRequest
+    decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+    decoder.DisallowUnknownFields()
+    if err := decoder.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+        writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+        return
+    }
+
+    ctx, cancel := s.requestContext(r)
+    defer cancel()
+
+    if req.Manifest != "" {
+        if req.Name != "" || req.AI {
+            writeError(w, http.StatusBadRequest, errors.New("manifest can't be combined with name or ai"))
+            return
+        }
+        s.analyzeManifest(ctx, w, req)
+        return
+    }
+
+    if req.Name == "" {
+        writeError(w, http.StatusBadRequest, errors.New("name or manifest is required"))
+        return
+    }
+    req.Kind = strings.ToLower(req.Kind)
+    if req.Kind == "" {
+        req.Kind = "deployment"
+    }
+    if !slices.Contains(analyzer.WorkloadTypes, req.Kind) {
+        writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported kind %q (want %s)", req.Kind, strings.Join(analyzer.WorkloadTypes, ", ")))
+        return
+    }
+    if req.Namespace == "" {
+        req.Namespace = s.cfg.Namespace
+    }
+    if req.AI && s.cfg.Enrich == nil {
+        writeError(w, http.StatusBadRequest, errors.New("AI analysis is not enabled on this server"))
+        return
+    }
+
+    details, err := analyzer.AnalyzeWorkload(ctx, s.cfg.Client, s.cfg.Metrics, req.Namespace, req.Kind, req.Name)
+    if err != nil {
+        if apierrors.IsNotFound(err) {
+            s.forget(req.Namespace, req.Kind, req.Name)
+        }
+        writeError(w, statusFor(err), err)
+        return
+    }
+    s.cfg.Finish(ctx, details, s.cfg.Cluster)
+    if req.AI {
+        if err := s.cfg.Enrich(ctx, details); err != nil {
+            writeError(w, http.StatusBadGateway, fmt.Errorf("failed to analyze workload: %v", err))
+            return
+        }
+    }
+
+    rep := s.store(details)
+    writeJSON(w, http.StatusOK, rep)
+}
+
+func (s *Server) analyzeManifest(ctx context.Context, w http.ResponseWriter, req AnalyzeRequest) {
+    namespace := req.Namespace
+    if namespace == "" {
+        namespace = "default"
+    }
+    var workloads []*analyzer.WorkloadDetails
+    for _, doc := range manifest.SplitDocuments("request", []byte(req.Manifest)) {
+        details, err := manifest.Decode(doc, namespace)
+        if err != nil {
+            writeError(w, http.StatusBadRequest, err)
+            return
+        }
+        if details != nil {
+            s.cfg.Finish(ctx, details, "offline")
+            workloads = append(workloads, details)
+        }
+    }
+    if len(workloads) == 0 {
+        writeError(w, http.StatusBadRequest, errors.New("manifest has no Deployment, StatefulSet or DaemonSet"))
+        return
+    }
+    writeJSON(w, http.StatusOK, s.cfg.NewReport(analyzer.ClusterResult{Cluster: "offline", Workloads: workloads}))
+}
+
+func (s *Server) handleWorkload(w http.ResponseWriter, r *http.Request) {
+    key := cacheKey(r.PathValue("namespace"), strings.ToLower(r.PathValue("kind")), r.PathValue("name"))
+    s.mu.RLock()
+    rep, ok := s.cache[key]
+    s.mu.RUnlock()
+    if !ok {
+        writeError(w, http.StatusNotFound, fmt.Errorf("no cached result for %s; POST /analyze or GET /scan first", key))
+        return
+    }
+    writeJSON(w, http.StatusOK, rep)
+}
+
+// handleScan analyzes every workload in ?namespace= (or the default) and
+// caches each result.
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+    namespace := r.URL.Query().Get("namespace")
+    if namespace == "" {
+        namespace = s.cfg.Namespace
+    }
+
+    workloads, err := s.scan(r.Context(), namespace)
+    if err != nil {
+        writeError(w, statusFor(err), err)
+        return
+    }
+    writeJSON(w, http.StatusOK, s.cfg.NewReport(analyzer.ClusterResult{Cluster: s.cfg.Cluster, Workloads: workloads}))
+}
+
+// handleMetrics exposes the cached results in the Prometheus text format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+    s.mu.RLock()
+    var workloads []*analyzer.WorkloadDetails
+    for _, rep := range s.cache {
+        workloads = append(workloads, rep.Workloads()...)
+    }
+    s.mu.RUnlock()
+
+    w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+    rep := s.cfg.NewReport(analyzer.ClusterResult{Cluster: s.cfg.Cluster, Workloads: workloads})
+    // Results are as old as their last scan; the scrape time says when
+    rep.GeneratedAt = time.Time{}
+    rep.WritePrometheus(w)
+}
+
+// refresh scans the default namespace on every tick until ctx is done. A
+// failed scan keeps the previous results.
+func (s *Server) refresh(ctx context.Context) {
+    ticker := time.NewTicker(s.cfg.Refresh)
+    defer ticker.Stop()
+    for {
+        s.refreshOnce(ctx)
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+func (s *Server) refreshOnce(ctx context.Context) {
+    id := logging.NewRequestID()
+    ctx = logging.WithRequestID(ctx, id)
+    ctx, span := tracing.StartRoot(ctx, "refresh", tracing.RequestID.String(id), tracing.Namespace.String(s.cfg.Namespace))
+    _, err := s.scan(ctx, s.cfg.Namespace)
+    if err != nil {
+        slog.WarnContext(ctx, "failed to refresh scan", "namespace", s.cfg.Namespace, "error", err)
+    }
+    tracing.End(span, err)
+}
+
+func (s *Server) scan(ctx context.Context, namespace string) ([]*analyzer.WorkloadDetails, error) {
+    if s.cfg.Timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+        defer cancel()
+    }
+    workloads, err := analyzer.AnalyzeNamespace(ctx, s.cfg.Client, s.cfg.Metrics, namespace)
+    if err != nil {
+        return nil, err
+    }
+    reports := make(map[string]*report.Report, len(workloads))
+    for _, details := range workloads {
+        s.cfg.Finish(ctx, details, s.cfg.Cluster)
+        reports[cacheKey(details.Namespace, details.Kind, details.Deployment)] = s.newReport(details)
+    }
+
+    // Replace the namespace wholesale so deleted workloads stop being exported
+    s.mu.Lock()
+    for key := range s.cache {
+        if strings.HasPrefix(key, namespace+"/") {
+            delete(s.cache, key)
+        }
+    }
+    maps.Copy(s.cache, reports)
+    s.mu.Unlock()
+    return workloads, nil
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+    writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady reports whether the Kubernetes API is reachable and lets us
+// list workloads in the default namespace.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+    ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+    defer cancel()
+    if _, err := s.cfg.Client.AppsV1().Deployments(s.cfg.Namespace).List(ctx, metav1.ListOptions{Limit: 1}); err != nil {
+        writeError(w, http.StatusServiceUnavailable, fmt.Errorf("kubernetes API unreachable: %v", err))
+        return
+    }
+    writeJSON(w, http.StatusOK, map[string]string{"status": "ready", "cluster": s.cfg.Cluster})
+}
+
+// store caches the result of one workload and returns it as a report.
+func (s *Server) store(details *analyzer.WorkloadDetails) *report.Report {
+    rep := s.newReport(details)
+    s.mu.Lock()
+    s.cache[cacheKey(details.Namespace, details.Kind, details.Deployment)] = rep
+    s.mu.Unlock()
+    return rep
+}
+
+// forget drops the cached result of a workload that no longer exists.
+func (s *Server) forget(namespace, kind, name string) {
+    s.mu.Lock()
+    delete(s.cache, cacheKey(namespace, kind, name))
+    s.mu.Unlock()
+}
+
+func (s *Server) newReport(details *analyzer.WorkloadDetails) *report.Report {
+    return s.cfg.NewReport(analyzer.ClusterResult{
+        Cluster:   s.cfg.Cluster,
+        Workloads: []*analyzer.WorkloadDetails{details},
+    })
+}
+
+func (s *Server) requestContext(r *http.Request) (context.Context, context.CancelFunc) {
+    if s.cfg.Timeout > 0 {
+        return context.WithTimeout(r.Context(), s.cfg.Timeout)
+    }
+    return context.WithCancel(r.Context())
+}
+
+func cacheKey(namespace, kind, name string) string {
+    return namespace + "/" + kind + "/" + name
+}
+
+// statusFor maps analysis errors to HTTP status codes.
+func statusFor(err error) int {
+    switch {
+    case apierrors.IsNotFound(err):
+        return http.StatusNotFound
+    case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err):
+        return http.StatusForbidden
+    case errors.Is(err, context.DeadlineExceeded):
+        return http.StatusGatewayTimeout
+    }
+    return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+    writeJSON(w, status, map[string]string{"error": err.Error()})
+}
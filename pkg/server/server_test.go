@@ -0,0 +1,312 @@
+package server
+
+import (
+    "context"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/client-go/kubernetes"
+    "k8s.io/client-go/kubernetes/fake"
+    "k8s.io/client-go/rest"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/report"
+)
+
+func deployment(namespace, name string) *appsv1.Deployment {
+    replicas := int32(2)
+    return &appsv1.Deployment{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+        Spec: appsv1.DeploymentSpec{
+            Replicas: &replicas,
+            Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+            Template: corev1.PodTemplateSpec{
+                ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+                Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: name + ":1.0"}}},
+            },
+        },
+    }
+}
+
+// newTestServer serves a cluster with a deployment web in default. The
+// client is returned to change the cluster under the server.
+func newTestServer(t *testing.T) (*httptest.Server, *fake.Clientset) {
+    t.Helper()
+    client := fake.NewSimpleClientset(deployment("default", "web"))
+
+    srv := httptest.NewServer(New(Config{
+        Cluster:   "test",
+        Namespace: "default",
+        Client:    client,
+        Finish: func(ctx context.Context, details *analyzer.WorkloadDetails, cluster string) {
+            details.Cluster = cluster
+            details.Findings = []analyzer.Finding{}
+        },
+        NewReport: func(results ...analyzer.ClusterResult) *report.Report {
+            return report.New(results...)
+        },
+    }))
+    t.Cleanup(srv.Close)
+    return srv, client
+}
+
+func decodeReport(t *testing.T, resp *http.Response) *report.Report {
+    t.Helper()
+    defer resp.Body.Close()
+    rep, err := report.Decode(resp.Body)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return rep
+}
+
+func TestAnalyzeAndCache(t *testing.T) {
+    srv, _ := newTestServer(t)
+
+    resp, err := http.Get(srv.URL + "/workloads/default/deployment/web")
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusNotFound {
+        t.Fatalf("expected 404 before analysis, got %d", resp.StatusCode)
+    }
+
+    resp, err = http.Post(srv.URL+"/analyze", "application/json", strings.NewReader(`{"name": "web"}`))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        t.Fatalf("expected 200, got %d", resp.StatusCode)
+    }
+    rep := decodeReport(t, resp)
+    if workloads := rep.Workloads(); len(workloads) != 1 || workloads[0].Cluster != "test" || workloads[0].DesiredReplicas != 2 {
+        t.Fatalf("unexpected report: %+v", rep)
+    }
+
+    resp, err = http.Get(srv.URL + "/workloads/default/deployment/web")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        t.Fatalf("expected cached result, got %d", resp.StatusCode)
+    }
+    if workloads := decodeReport(t, resp).Workloads(); len(workloads) != 1 || workloads[0].Deployment != "web" {
+        t.Errorf("unexpected cached workloads: %+v", workloads)
+    }
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+    srv, _ := newTestServer(t)
+
+    tests := []struct {
+        name   string
+        body   string
+        status int
+    }{
+        {"missing workload", `{"name": "missing"}`, http.StatusNotFound},
+        {"no name", `{}`, http.StatusBadRequest},
+        {"bad kind", `{"name": "web", "kind": "cronjob"}`, http.StatusBadRequest},
+        {"unknown field", `{"workload": "web"}`, http.StatusBadRequest},
+        {"ai not configured", `{"name": "web", "ai": true}`, http.StatusBadRequest},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            resp, err := http.Post(srv.URL+"/analyze", "application/json", strings.NewReader(tt.body))
+            if err != nil {
+                t.Fatal(err)
+            }
+            defer resp.Body.Close()
+            var body map[string]string
+            json.NewDecoder(resp.Body).Decode(&body)
+            if resp.StatusCode != tt.status || body["error"] == "" {
+                t.Errorf("expected %d with an error, got %d %v", tt.status, resp.StatusCode, body)
+            }
+        })
+    }
+}
+
+func TestAnalyzeManifest(t *testing.T) {
+    srv, _ := newTestServer(t)
+
+    manifest := `{"manifest": "apiVersion: apps/v1\nkind: StatefulSet\nmetadata:\n  name: db\nspec:\n  selector:\n    matchLabels: {app: db}\n  template:\n    metadata:\n      labels: {app: db}\n    spec:\n      containers:\n      - name: db\n        image: postgres:16\n"}`
+    resp, err := http.Post(srv.URL+"/analyze", "application/json", strings.NewReader(manifest))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        t.Fatalf("expected 200, got %d", resp.StatusCode)
+    }
+    workloads := decodeReport(t, resp).Workloads()
+    if len(workloads) != 1 || workloads[0].Kind != "statefulset" || workloads[0].Source == nil || workloads[0].Source.Containers["db"] != 13 {
+        t.Errorf("unexpected workloads: %+v", workloads)
+    }
+}
+
+func TestScanAndHealth(t *testing.T) {
+    srv, _ := newTestServer(t)
+
+    for _, path := range []string{"/healthz", "/readyz", "/scan", "/scan?namespace=default", "/workloads/default/deployment/web"} {
+        resp, err := http.Get(srv.URL + path)
+        if err != nil {
+            t.Fatal(err)
+        }
+        resp.Body.Close()
+        if resp.StatusCode != http.StatusOK {
+            t.Errorf("GET %s: expected 200, got %d", path, resp.StatusCode)
+        }
+    }
+}
+
+func TestMetrics(t *testing.T) {
+    srv, _ := newTestServer(t)
+
+    resp, err := http.Get(srv.URL + "/scan")
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+
+    resp, err = http.Get(srv.URL + "/metrics")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, want := range []string{
+        "# TYPE kwa_workload_desired_replicas gauge",
+        `kwa_workload_desired_replicas{cluster="test",namespace="default",kind="deployment",workload="web"} 2`,
+        `kwa_workload_findings{cluster="test",namespace="default",kind="deployment",workload="web",severity="high"} 0`,
+        `kwa_container_cpu_requested_cores{cluster="test",namespace="default",kind="deployment",workload="web",container="app"} 0`,
+    } {
+        if !strings.Contains(string(body), want) {
+            t.Errorf("metrics missing %q:\n%s", want, body)
+        }
+    }
+}
+
+func get(t *testing.T, url string) (int, string) {
+    t.Helper()
+    resp, err := http.Get(url)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return resp.StatusCode, string(body)
+}
+
+func TestScanDropsDeletedWorkloads(t *testing.T) {
+    srv, client := newTestServer(t)
+    ctx := context.Background()
+    deployments := client.AppsV1().Deployments("default")
+    if _, err := deployments.Create(ctx, deployment("default", "api"), metav1.CreateOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := client.AppsV1().Deployments("batch").Create(ctx, deployment("batch", "worker"), metav1.CreateOptions{}); err != nil {
+        t.Fatal(err)
+    }
+
+    get(t, srv.URL+"/scan")
+    get(t, srv.URL+"/scan?namespace=batch")
+    _, body := get(t, srv.URL+"/metrics")
+    for _, name := range []string{"web", "api", "worker"} {
+        if !strings.Contains(body, `workload="`+name+`"`) {
+            t.Fatalf("expected %s in the metrics:\n%s", name, body)
+        }
+    }
+
+    if err := deployments.Delete(ctx, "web", metav1.DeleteOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    get(t, srv.URL+"/scan")
+
+    _, body = get(t, srv.URL+"/metrics")
+    if strings.Contains(body, `workload="web"`) {
+        t.Errorf("expected the deleted workload to stop being exported:\n%s", body)
+    }
+    for _, name := range []string{"api", "worker"} {
+        if !strings.Contains(body, `workload="`+name+`"`) {
+            t.Errorf("expected %s to still be exported:\n%s", name, body)
+        }
+    }
+    if status, _ := get(t, srv.URL+"/workloads/default/deployment/web"); status != http.StatusNotFound {
+        t.Errorf("expected no cached result for the deleted workload, got %d", status)
+    }
+
+    // Analyzing a workload that is gone forgets it too
+    resp, err := http.Post(srv.URL+"/analyze", "application/json", strings.NewReader(`{"name": "api"}`))
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    if err := deployments.Delete(ctx, "api", metav1.DeleteOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    resp, err = http.Post(srv.URL+"/analyze", "application/json", strings.NewReader(`{"name": "api"}`))
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    if status, _ := get(t, srv.URL+"/workloads/default/deployment/api"); resp.StatusCode != http.StatusNotFound || status != http.StatusNotFound {
+        t.Errorf("expected a 404 and no cached result for the deleted workload, got %d and %d", resp.StatusCode, status)
+    }
+}
+
+func TestAnalyzeKindCase(t *testing.T) {
+    srv, _ := newTestServer(t)
+
+    resp, err := http.Post(srv.URL+"/analyze", "application/json", strings.NewReader(`{"name": "web", "kind": "Deployment"}`))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        t.Fatalf("expected the kind to be case-insensitive, got %d", resp.StatusCode)
+    }
+    if workloads := decodeReport(t, resp).Workloads(); len(workloads) != 1 || workloads[0].Kind != "deployment" {
+        t.Errorf("unexpected workloads: %+v", workloads)
+    }
+    if status, _ := get(t, srv.URL+"/workloads/default/Deployment/web"); status != http.StatusOK {
+        t.Errorf("expected the cached result under either case, got %d", status)
+    }
+}
+
+func TestReadyTimesOut(t *testing.T) {
+    // An API server that accepts requests but never answers
+    hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        <-r.Context().Done()
+    }))
+    defer hung.Close()
+    client, err := kubernetes.NewForConfig(&rest.Config{Host: hung.URL})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    defer func(timeout time.Duration) { readyTimeout = timeout }(readyTimeout)
+    readyTimeout = 50 * time.Millisecond
+
+    srv := httptest.NewServer(New(Config{Cluster: "test", Namespace: "default", Client: client}))
+    defer srv.Close()
+
+    start := time.Now()
+    status, body := get(t, srv.URL+"/readyz")
+    if status != http.StatusServiceUnavailable || !strings.Contains(body, "kubernetes API unreachable") {
+        t.Errorf("expected 503 from a hung API server, got %d %s", status, body)
+    }
+    if elapsed := time.Since(start); elapsed > 5*time.Second {
+        t.Errorf("expected the probe to give up after its timeout, took %s", elapsed)
+    }
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+    "sort"
+    "time"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// FindingSpan is the lifetime of a finding across a workload's history.
+// Resolved is nil while the finding is still present in the latest record.
+// A finding that disappears and comes back starts a new span.
+type FindingSpan struct {
+    Finding   analyzer.Finding `json:"finding"`
+    FirstSeen time.Time        `json:"first_seen"`
+    LastSeen  time.Time        `json:"last_seen"`
+    Resolved  *time.Time       `json:"resolved,omitempty"`
+}
+
+// FindingSpans walks records oldest first and reports when each finding
+// first appeared and when it was resolved. Open findings sort first.
+func FindingSpans(records []Record) []FindingSpan {
+    var spans []FindingSpan
+    open := make(map[string]int) // finding ID -> index into spans
+
+    for _, rec := range records {
+        present := make(map[string]bool)
+        for _, f := range rec.Workload.Findings {
+            present[f.ID] = true
+            if i, ok := open[f.ID]; ok {
+                spans[i].Finding = f
+                spans[i].LastSeen = rec.Timestamp
+                continue
+            }
+            open[f.ID] = len(spans)
+            spans = append(spans, FindingSpan{Finding: f, FirstSeen: rec.Timestamp, LastSeen: rec.Timestamp})
+        }
+        for id, i := range open {
+            if !present[id] {
+                resolved := rec.Timestamp
+                spans[i].Resolved = &resolved
+                delete(open, id)
+            }
+        }
+    }
+
+    sort.SliceStable(spans, func(i, j int) bool {
+        if (spans[i].Resolved == nil) != (spans[j].Resolved == nil) {
+            return spans[i].Resolved == nil
+        }
+        return spans[i].FirstSeen.Before(spans[j].FirstSeen)
+    })
+    return spans
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+    "crypto/sha256"
+    "encoding/binary"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+
+    bolt "go.etcd.io/bbolt"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// workloadsBucket holds one nested bucket per workload key, with records
+// keyed by a big-endian sequence number so they iterate oldest first.
+var workloadsBucket = []byte("workloads")
+
+// Record is one stored analysis of a workload.
+type Record struct {
+    ID              uint64                            `json:"id"`
+    Timestamp       time.Time                         `json:"timestamp"`
+    SpecHash        string                            `json:"spec_hash"`
+    Workload        *analyzer.WorkloadDetails         `json:"workload"`
+    Recommendations []analyzer.ResourceRecommendation `json:"recommendations,omitempty"`
+}
+
+// Store is the local history database. bbolt locks the file, so only one
+// process can have it open at a time.
+type Store struct {
+    db *bolt.DB
+}
+
+// DefaultPath is $XDG_DATA_HOME/kwa/history.db, or ~/.local/share/kwa/history.db.
+func DefaultPath() string {
+    dir := os.Getenv("XDG_DATA_HOME")
+    if dir == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return "history.db"
+        }
+        dir = filepath.Join(home, ".local", "share")
+    }
+    return filepath.Join(dir, "kwa", "history.db")
+}
+
+func Open(path string) (*Store, error) {
+    if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+        return nil, fmt.Errorf("failed to create history directory: %v", err)
+    }
+    db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+    if err != nil {
+        return nil, fmt.Errorf("failed to open history %s: %v", path, err)
+    }
+    return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+    return s.db.Close()
+}
+
+// Key identifies a workload across runs.
+func Key(cluster, namespace, kind, name string) string {
+    return fmt.Sprintf("%s/%s/%s/%s", cluster, namespace, kind, name)
+}
+
+// KeyOf returns the key of analyzed workload details.
+func KeyOf(details *analyzer.WorkloadDetails) string {
+    return Key(details.Cluster, details.Namespace, details.Kind, details.Deployment)
+}
+
+// SpecHash fingerprints the parts of a workload a change to the manifest
+// would touch: the pod template and the desired replicas.
+func SpecHash(details *analyzer.WorkloadDetails) string {
+    data, _ := json.Marshal(struct {
+        Replicas int32       `json:"replicas"`
+        PodSpec  interface{} `json:"pod_spec"`
+    }{details.DesiredReplicas, details.PodSpec})
+    sum := sha256.Sum256(data)
+    return hex.EncodeToString(sum[:])[:12]
+}
+
+// Save appends a record for the workload and returns it with its ID set.
+func (s *Store) Save(rec Record) (Record, error) {
+    err := s.db.Update(func(tx *bolt.Tx) error {
+        root, err := tx.CreateBucketIfNotExists(workloadsBucket)
+        if err != nil {
+            return err
+        }
+        bucket, err := root.CreateBucketIfNotExists([]byte(KeyOf(rec.Workload)))
+        if err != nil {
+            return err
+        }
+        if rec.ID, err = bucket.NextSequence(); err != nil {
+            return err
+        }
+        data, err := json.Marshal(rec)
+        if err != nil {
+            return err
+        }
+        return bucket.Put(itob(rec.ID), data)
+    })
+    if err != nil {
+        return rec, fmt.Errorf("failed to save history: %v", err)
+    }
+    return rec, nil
+}
+
+// History returns every record of a workload, oldest first.
+func (s *Store) History(key string) ([]Record, error) {
+    var records []Record
+    err := s.db.View(func(tx *bolt.Tx) error {
+        bucket := workloadBucket(tx, key)
+        if bucket == nil {
+            return nil
+        }
+        return bucket.ForEach(func(_, data []byte) error {
+            var rec Record
+            if err := json.Unmarshal(data, &rec); err != nil {
+                return err
+            }
+            records = append(records, rec)
+            return nil
+        })
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to read history: %v", err)
+    }
+    return records, nil
+}
+
+// Get returns one record of a workload by ID.
+func (s *Store) Get(key string, id uint64) (*Record, error) {
+    var rec *Record
+    err := s.db.View(func(tx *bolt.Tx) error {
+        bucket := workloadBucket(tx, key)
+        if bucket == nil {
+            return nil
+        }
+        data := bucket.Get(itob(id))
+        if data == nil {
+            return nil
+        }
+        rec = &Record{}
+        return json.Unmarshal(data, rec)
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to read history: %v", err)
+    }
+    if rec == nil {
+        return nil, fmt.Errorf("no record %d for %s", id, key)
+    }
+    return rec, nil
+}
+
+// Workloads lists the keys of every workload with history.
+func (s *Store) Workloads() ([]string, error) {
+    var keys []string
+    err := s.db.View(func(tx *bolt.Tx) error {
+        root := tx.Bucket(workloadsBucket)
+        if root == nil {
+            return nil
+        }
+        return root.ForEachBucket(func(k []byte) error {
+            keys = append(keys, string(k))
+            return nil
+        })
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to read history: %v", err)
+    }
+    return keys, nil
+}
+
+func workloadBucket(tx *bolt.Tx, key string) *bolt.Bucket {
+    root := tx.Bucket(workloadsBucket)
+    if root == nil {
+        return nil
+    }
+    return root.Bucket([]byte(key))
+}
+
+func itob(id uint64) []byte {
+    b := make([]byte, 8)
+    binary.BigEndian.PutUint64(b, id)
+    return b
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+    "path/filepath"
+    "testing"
+    "time"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+func workload(name string, replicas int32, findings ...string) *analyzer.WorkloadDetails {
+    details := &analyzer.WorkloadDetails{
+        Cluster:         "prod",
+        Namespace:       "shop",
+        Kind:            "deployment",
+        Deployment:      name,
+        DesiredReplicas: replicas,
+    }
+    for _, id := range findings {
+        details.Findings = append(details.Findings, analyzer.Finding{ID: id, RuleID: id, Severity: analyzer.SeverityMedium})
+    }
+    return details
+}
+
+func openStore(t *testing.T) *Store {
+    t.Helper()
+    s, err := Open(filepath.Join(t.TempDir(), "kwa", "history.db"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { s.Close() })
+    return s
+}
+
+func TestSaveAndHistory(t *testing.T) {
+    s := openStore(t)
+    start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+    for i, details := range []*analyzer.WorkloadDetails{workload("web", 2), workload("api", 1), workload("web", 3)} {
+        rec, err := s.Save(Record{Timestamp: start.Add(time.Duration(i) * time.Hour), SpecHash: SpecHash(details), Workload: details})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if rec.ID == 0 {
+            t.Errorf("expected record %d to get an ID", i)
+        }
+    }
+
+    key := Key("prod", "shop", "deployment", "web")
+    records, err := s.History(key)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(records) != 2 {
+        t.Fatalf("expected 2 records of web, got %d", len(records))
+    }
+    if records[0].Workload.DesiredReplicas != 2 || records[1].Workload.DesiredReplicas != 3 {
+        t.Errorf("expected records oldest first, got replicas %d then %d", records[0].Workload.DesiredReplicas, records[1].Workload.DesiredReplicas)
+    }
+    if records[0].SpecHash == records[1].SpecHash {
+        t.Error("expected a replica change to change the spec hash")
+    }
+    if !records[1].Timestamp.Equal(start.Add(2 * time.Hour)) {
+        t.Errorf("timestamp didn't round-trip: %v", records[1].Timestamp)
+    }
+
+    rec, err := s.Get(key, records[1].ID)
+    if err != nil || rec.Workload.DesiredReplicas != 3 {
+        t.Errorf("expected Get to return the latest record, got %+v, %v", rec, err)
+    }
+    if _, err := s.Get(key, 99); err == nil {
+        t.Error("expected a missing record to be an error")
+    }
+
+    keys, err := s.Workloads()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(keys) != 2 || keys[0] != Key("prod", "shop", "deployment", "api") || keys[1] != key {
+        t.Errorf("unexpected workload keys: %v", keys)
+    }
+
+    if records, err := s.History(Key("prod", "shop", "deployment", "missing")); err != nil || len(records) != 0 {
+        t.Errorf("expected no history for an unknown workload, got %v, %v", records, err)
+    }
+}
+
+func TestHistoryPersists(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "history.db")
+    s, err := Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := s.Save(Record{Timestamp: time.Now(), Workload: workload("web", 2)}); err != nil {
+        t.Fatal(err)
+    }
+    s.Close()
+
+    s, err = Open(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer s.Close()
+    rec, err := s.Save(Record{Timestamp: time.Now(), Workload: workload("web", 2)})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if rec.ID != 2 {
+        t.Errorf("expected IDs to continue after reopening, got %d", rec.ID)
+    }
+}
+
+func TestFindingSpans(t *testing.T) {
+    s := openStore(t)
+    start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+    at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Hour) }
+
+    runs := [][]string{
+        {"KWA001/app", "KWA006"},
+        {"KWA001/app", "KWA006"},
+        {"KWA006"},
+        {"KWA006", "KWA001/app"},
+        {"KWA001/app", "KWA004/app"},
+    }
+    for i, findings := range runs {
+        if _, err := s.Save(Record{Timestamp: at(i), Workload: workload("web", 2, findings...)}); err != nil {
+            t.Fatal(err)
+        }
+    }
+    records, err := s.History(Key("prod", "shop", "deployment", "web"))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    spans := FindingSpans(records)
+    type span struct {
+        id        string
+        firstSeen time.Time
+        lastSeen  time.Time
+        resolved  time.Time
+    }
+    want := []span{
+        {"KWA001/app", at(3), at(4), time.Time{}},
+        {"KWA004/app", at(4), at(4), time.Time{}},
+        {"KWA001/app", at(0), at(1), at(2)},
+        {"KWA006", at(0), at(3), at(4)},
+    }
+    if len(spans) != len(want) {
+        t.Fatalf("expected %d spans, got %d: %+v", len(want), len(spans), spans)
+    }
+    for i, w := range want {
+        got := spans[i]
+        var resolved time.Time
+        if got.Resolved != nil {
+            resolved = *got.Resolved
+        }
+        if got.Finding.ID != w.id || !got.FirstSeen.Equal(w.firstSeen) || !got.LastSeen.Equal(w.lastSeen) || !resolved.Equal(w.resolved) {
+            t.Errorf("span %d: got %s %v-%v resolved %v, want %s %v-%v resolved %v",
+                i, got.Finding.ID, got.FirstSeen, got.LastSeen, resolved, w.id, w.firstSeen, w.lastSeen, w.resolved)
+        }
+    }
+}
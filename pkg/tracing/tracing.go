@@ -0,0 +1,99 @@
+// Package tracing instruments the analysis pipeline with OpenTelemetry spans
+// and installs the exporter they are sent to.
+package tracing
+
+import (
+    "context"
+    "fmt"
+    "io"
+
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+    "go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+    "go.opentelemetry.io/otel/sdk/resource"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+    "go.opentelemetry.io/otel/trace"
+)
+
+// Name is the instrumentation scope and the default service name.
+const Name = "kwa"
+
+// Exporters accepted by Setup.
+const (
+    ExporterNone   = "none"
+    ExporterOTLP   = "otlp"
+    ExporterStdout = "stdout"
+)
+
+// Attribute keys of the pipeline's spans.
+const (
+    Namespace        = attribute.Key("k8s.namespace.name")
+    Pod              = attribute.Key("k8s.pod.name")
+    Kind             = attribute.Key("kwa.workload.kind")
+    Workload         = attribute.Key("kwa.workload.name")
+    Cluster          = attribute.Key("kwa.cluster")
+    RequestID        = attribute.Key("kwa.request_id")
+    Findings         = attribute.Key("kwa.findings")
+    Pods             = attribute.Key("kwa.pods")
+    Model            = attribute.Key("gen_ai.request.model")
+    PromptVersion    = attribute.Key("kwa.prompt.version")
+    Focus            = attribute.Key("kwa.prompt.focus")
+    CacheHit         = attribute.Key("kwa.ai.cache_hit")
+    PromptTokens     = attribute.Key("gen_ai.usage.input_tokens")
+    CompletionTokens = attribute.Key("gen_ai.usage.output_tokens")
+)
+
+// Setup installs the global tracer provider. otlp sends spans over OTLP/HTTP,
+// configured by the standard OTEL_EXPORTER_OTLP_* variables; stdout writes
+// them as JSON to w. Call shutdown before exiting to flush pending spans.
+func Setup(ctx context.Context, exporter string, w io.Writer) (shutdown func(context.Context) error, err error) {
+    var exp sdktrace.SpanExporter
+    switch exporter {
+    case "", ExporterNone:
+        return func(context.Context) error { return nil }, nil
+    case ExporterOTLP:
+        exp, err = otlptracehttp.New(ctx)
+    case ExporterStdout:
+        exp, err = stdouttrace.New(stdouttrace.WithWriter(w))
+    default:
+        return nil, fmt.Errorf("unsupported trace exporter %q (want none, otlp, stdout)", exporter)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to create %s trace exporter: %v", exporter, err)
+    }
+
+    // The default resource reads OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+    res, err := resource.Merge(
+        resource.NewSchemaless(semconv.ServiceName(Name)),
+        resource.Default(),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to create trace resource: %v", err)
+    }
+    tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+    otel.SetTracerProvider(tp)
+    return tp.Shutdown, nil
+}
+
+// Start starts a span of the pipeline under the span in ctx, if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+    return otel.Tracer(Name).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartRoot starts a span that begins a new trace, e.g. for each request of
+// a long-running server, even when ctx carries a span.
+func StartRoot(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+    return otel.Tracer(Name).Start(ctx, name, trace.WithNewRoot(), trace.WithAttributes(attrs...))
+}
+
+// End records err, if any, on span and ends it.
+func End(span trace.Span, err error) {
+    if err != nil {
+        span.RecordError(err)
+        span.SetStatus(codes.Error, err.Error())
+    }
+    span.End()
+}
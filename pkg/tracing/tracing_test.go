@@ -0,0 +1,69 @@
+package tracing
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "testing"
+
+    "go.opentelemetry.io/otel"
+)
+
+func TestSetupStdout(t *testing.T) {
+    previous := otel.GetTracerProvider()
+    defer otel.SetTracerProvider(previous)
+
+    var buf bytes.Buffer
+    shutdown, err := Setup(context.Background(), ExporterStdout, &buf)
+    if err != nil {
+        t.Fatal(err)
+    }
+    ctx, root := Start(context.Background(), "analyze", Namespace.String("default"))
+    _, child := Start(ctx, "pods.list")
+    End(child, errors.New("forbidden"))
+    _, other := StartRoot(ctx, "reconcile")
+    End(other, nil)
+    End(root, nil)
+    if err := shutdown(context.Background()); err != nil {
+        t.Fatal(err)
+    }
+
+    // The fields of stdouttrace's JSON the test looks at
+    type exportedSpan struct {
+        Name        string
+        SpanContext struct{ TraceID string }
+        Parent      struct{ TraceID string }
+        Status      struct{ Code string }
+    }
+    spans := make(map[string]exportedSpan)
+    decoder := json.NewDecoder(&buf)
+    for decoder.More() {
+        var span exportedSpan
+        if err := decoder.Decode(&span); err != nil {
+            t.Fatalf("invalid span output: %v\n%s", err, buf.String())
+        }
+        spans[span.Name] = span
+    }
+    if len(spans) != 3 {
+        t.Fatalf("expected 3 spans, got %d:\n%s", len(spans), buf.String())
+    }
+    if spans["pods.list"].Status.Code != "Error" || spans["pods.list"].Parent.TraceID != spans["analyze"].SpanContext.TraceID {
+        t.Errorf("expected an errored child of analyze, got %+v", spans["pods.list"])
+    }
+    if spans["reconcile"].SpanContext.TraceID == spans["analyze"].SpanContext.TraceID {
+        t.Error("expected StartRoot to begin a new trace")
+    }
+}
+
+func TestSetupNone(t *testing.T) {
+    for _, exporter := range []string{"", ExporterNone} {
+        shutdown, err := Setup(context.Background(), exporter, nil)
+        if err != nil || shutdown(context.Background()) != nil {
+            t.Errorf("exporter %q: %v", exporter, err)
+        }
+    }
+    if _, err := Setup(context.Background(), "jaeger", nil); err == nil {
+        t.Error("expected an unknown exporter to fail")
+    }
+}
@@ -0,0 +1,464 @@
+package ui
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+    "github.com/charmbracelet/lipgloss/table"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// BrowserConfig wires the browser to a cluster. The callbacks run outside
+// the UI loop, so they may take as long as an analysis takes.
+type BrowserConfig struct {
+    Cluster   string
+    Namespace string // opened on start; empty starts at the namespace list
+
+    Namespaces func(ctx context.Context) ([]string, error)
+    Scan       func(ctx context.Context, namespace string) ([]*analyzer.WorkloadDetails, error)
+
+    // Enrich adds the AI analysis of a workload. Nil when no AI provider
+    // is configured.
+    Enrich func(ctx context.Context, details *analyzer.WorkloadDetails) error
+
+    // Export writes workloads of a namespace and returns where to.
+    Export func(namespace string, workloads []*analyzer.WorkloadDetails) (string, error)
+}
+
+// Browse runs the interactive browser until the user quits or ctx is done.
+func Browse(ctx context.Context, cfg BrowserConfig) error {
+    m := &browser{cfg: cfg, ctx: ctx, view: viewNamespaces, loading: "Loading namespaces…"}
+    if cfg.Namespace != "" {
+        m.namespace = cfg.Namespace
+        m.view = viewWorkloads
+        m.loading = "Scanning " + cfg.Namespace + "…"
+    }
+    _, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+    if ctx.Err() != nil {
+        return ctx.Err()
+    }
+    return err
+}
+
+type browserView int
+
+const (
+    viewNamespaces browserView = iota
+    viewWorkloads
+    viewDetails
+)
+
+// Workload orders, cycled with "s".
+var sortOrders = []string{"name", "waste", "risk"}
+
+var (
+    cursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("87")).Bold(true)
+    selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+    helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+)
+
+// Messages delivered when background work finishes.
+type (
+    namespacesMsg struct {
+        namespaces []string
+        err        error
+    }
+    scanMsg struct {
+        namespace string
+        workloads []*analyzer.WorkloadDetails
+        err       error
+    }
+    enrichMsg struct {
+        details *analyzer.WorkloadDetails
+        err     error
+    }
+    exportMsg struct {
+        path string
+        err  error
+    }
+)
+
+type browser struct {
+    cfg    BrowserConfig
+    ctx    context.Context
+    view   browserView
+    width  int
+    height int
+
+    namespaces []string
+    nsCursor   int
+
+    namespace string
+    workloads []*analyzer.WorkloadDetails
+    cursor    int
+    selected  map[*analyzer.WorkloadDetails]bool
+    sortBy    int
+
+    details *analyzer.WorkloadDetails
+    scroll  int
+
+    loading string // shown instead of the view while work runs
+    status  string // result of the last action
+}
+
+func (m *browser) Init() tea.Cmd {
+    if m.view == viewWorkloads {
+        return m.scan(m.namespace)
+    }
+    return m.loadNamespaces()
+}
+
+func (m *browser) loadNamespaces() tea.Cmd {
+    return func() tea.Msg {
+        namespaces, err := m.cfg.Namespaces(m.ctx)
+        return namespacesMsg{namespaces: namespaces, err: err}
+    }
+}
+
+func (m *browser) scan(namespace string) tea.Cmd {
+    return func() tea.Msg {
+        workloads, err := m.cfg.Scan(m.ctx, namespace)
+        return scanMsg{namespace: namespace, workloads: workloads, err: err}
+    }
+}
+
+func (m *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+    switch msg := msg.(type) {
+    case tea.WindowSizeMsg:
+        m.width, m.height = msg.Width, msg.Height
+        return m, nil
+
+    case namespacesMsg:
+        m.loading = ""
+        if msg.err != nil {
+            m.status = errorStyle.Render(fmt.Sprintf("Error: %v", msg.err))
+            return m, nil
+        }
+        m.namespaces = msg.namespaces
+        for i, ns := range m.namespaces {
+            if ns == m.namespace {
+                m.nsCursor = i
+            }
+        }
+        return m, nil
+
+    case scanMsg:
+        m.loading = ""
+        if msg.err != nil {
+            m.status = errorStyle.Render(fmt.Sprintf("Error: %v", msg.err))
+            return m, nil
+        }
+        m.namespace = msg.namespace
+        m.workloads = msg.workloads
+        m.selected = make(map[*analyzer.WorkloadDetails]bool)
+        m.sortWorkloads()
+        m.cursor = 0
+        m.status = fmt.Sprintf("%d workload(s)", len(m.workloads))
+        return m, nil
+
+    case enrichMsg:
+        m.loading = ""
+        if msg.err != nil {
+            m.status = errorStyle.Render(fmt.Sprintf("Error: %v", msg.err))
+        } else {
+            m.status = successStyle.Render("AI analysis added")
+        }
+        return m, nil
+
+    case exportMsg:
+        m.loading = ""
+        if msg.err != nil {
+            m.status = errorStyle.Render(fmt.Sprintf("Error: %v", msg.err))
+        } else {
+            m.status = successStyle.Render("Exported to " + msg.path)
+        }
+        return m, nil
+
+    case tea.KeyMsg:
+        if msg.String() == "ctrl+c" || (msg.String() == "q" && m.loading == "") {
+            return m, tea.Quit
+        }
+        if m.loading != "" {
+            return m, nil
+        }
+        switch m.view {
+        case viewNamespaces:
+            return m.updateNamespaces(msg)
+        case viewWorkloads:
+            return m.updateWorkloads(msg)
+        case viewDetails:
+            return m.updateDetails(msg)
+        }
+    }
+    return m, nil
+}
+
+func (m *browser) updateNamespaces(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    switch msg.String() {
+    case "up", "k":
+        m.nsCursor = max(m.nsCursor-1, 0)
+    case "down", "j":
+        m.nsCursor = max(min(m.nsCursor+1, len(m.namespaces)-1), 0)
+    case "r":
+        m.loading = "Loading namespaces…"
+        return m, m.loadNamespaces()
+    case "enter":
+        if len(m.namespaces) == 0 {
+            return m, nil
+        }
+        m.view = viewWorkloads
+        m.status = ""
+        m.loading = "Scanning " + m.namespaces[m.nsCursor] + "…"
+        return m, m.scan(m.namespaces[m.nsCursor])
+    }
+    return m, nil
+}
+
+func (m *browser) updateWorkloads(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    switch msg.String() {
+    case "up", "k":
+        m.cursor = max(m.cursor-1, 0)
+    case "down", "j":
+        m.cursor = max(min(m.cursor+1, len(m.workloads)-1), 0)
+    case "s":
+        m.sortBy = (m.sortBy + 1) % len(sortOrders)
+        m.sortWorkloads()
+        m.status = "Sorted by " + sortOrders[m.sortBy]
+    case " ":
+        if len(m.workloads) > 0 {
+            d := m.workloads[m.cursor]
+            m.selected[d] = !m.selected[d]
+        }
+    case "r":
+        m.loading = "Scanning " + m.namespace + "…"
+        return m, m.scan(m.namespace)
+    case "x":
+        return m, m.export()
+    case "enter":
+        if len(m.workloads) == 0 {
+            return m, nil
+        }
+        m.details = m.workloads[m.cursor]
+        m.scroll = 0
+        m.view = viewDetails
+        m.status = ""
+    case "esc", "backspace":
+        m.view = viewNamespaces
+        m.status = ""
+        if m.namespaces == nil {
+            m.loading = "Loading namespaces…"
+            return m, m.loadNamespaces()
+        }
+    }
+    return m, nil
+}
+
+func (m *browser) updateDetails(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    switch msg.String() {
+    case "up", "k":
+        m.scroll = max(m.scroll-1, 0)
+    case "down", "j":
+        m.scroll++
+    case "pgup":
+        m.scroll = max(m.scroll-m.pageSize(), 0)
+    case "pgdown":
+        m.scroll += m.pageSize()
+    case "a":
+        if m.cfg.Enrich == nil {
+            m.status = warningStyle.Render("No AI provider configured (set ai.api_key or $OPENAI_API_KEY)")
+            return m, nil
+        }
+        details := m.details
+        m.loading = "Running AI analysis of " + details.Deployment + "…"
+        return m, func() tea.Msg {
+            return enrichMsg{details: details, err: m.cfg.Enrich(m.ctx, details)}
+        }
+    case "x":
+        return m, m.export()
+    case "esc", "backspace":
+        m.view = viewWorkloads
+        m.status = ""
+    }
+    return m, nil
+}
+
+// export writes the selected workloads, or the one under the cursor when
+// none are selected.
+func (m *browser) export() tea.Cmd {
+    var workloads []*analyzer.WorkloadDetails
+    for _, d := range m.workloads {
+        if m.selected[d] {
+            workloads = append(workloads, d)
+        }
+    }
+    if len(workloads) == 0 {
+        switch {
+        case m.view == viewDetails:
+            workloads = []*analyzer.WorkloadDetails{m.details}
+        case len(m.workloads) > 0:
+            workloads = []*analyzer.WorkloadDetails{m.workloads[m.cursor]}
+        default:
+            return nil
+        }
+    }
+    namespace := m.namespace
+    m.loading = fmt.Sprintf("Exporting %d workload(s)…", len(workloads))
+    return func() tea.Msg {
+        path, err := m.cfg.Export(namespace, workloads)
+        return exportMsg{path: path, err: err}
+    }
+}
+
+func (m *browser) sortWorkloads() {
+    var cursor *analyzer.WorkloadDetails
+    if m.cursor >= 0 && m.cursor < len(m.workloads) {
+        cursor = m.workloads[m.cursor]
+    }
+    sort.SliceStable(m.workloads, func(i, j int) bool {
+        a, b := m.workloads[i], m.workloads[j]
+        switch sortOrders[m.sortBy] {
+        case "waste":
+            if wa, wb := monthlyWaste(a), monthlyWaste(b); wa != wb {
+                return wa > wb
+            }
+        case "risk":
+            if ra, rb := risk(a), risk(b); ra != rb {
+                return ra > rb
+            }
+        }
+        return a.Deployment < b.Deployment
+    })
+    for i, d := range m.workloads {
+        if d == cursor {
+            m.cursor = i
+        }
+    }
+}
+
+func monthlyWaste(d *analyzer.WorkloadDetails) float64 {
+    if d.Cost == nil {
+        return 0
+    }
+    return d.Cost.MonthlyWaste
+}
+
+// risk ranks workloads by their most severe finding, then by how many
+// findings they have.
+func risk(d *analyzer.WorkloadDetails) int {
+    worst := 0
+    for _, f := range d.Findings {
+        worst = max(worst, f.Severity.Rank())
+    }
+    return worst*1000 + len(d.Findings)
+}
+
+func (m *browser) pageSize() int {
+    return max(m.height-4, 1)
+}
+
+func (m *browser) View() string {
+    var b strings.Builder
+    b.WriteString(titleStyle.Render("Workload Browser") + "\n")
+    header := helpStyle.Render("Cluster ") + valueStyle.Render(m.cfg.Cluster)
+    if m.namespace != "" && m.view != viewNamespaces {
+        header += helpStyle.Render("  Namespace ") + valueStyle.Render(m.namespace)
+    }
+    b.WriteString(header + "\n\n")
+
+    if m.loading != "" {
+        b.WriteString(warningStyle.Render(m.loading) + "\n")
+        return b.String()
+    }
+
+    var help string
+    switch m.view {
+    case viewNamespaces:
+        b.WriteString(m.viewNamespaces())
+        help = "↑/↓ move • enter scan • r reload • q quit"
+    case viewWorkloads:
+        b.WriteString(m.viewWorkloads())
+        help = "↑/↓ move • enter open • space select • s sort (" + sortOrders[m.sortBy] + ") • x export • r rescan • esc namespaces • q quit"
+    case viewDetails:
+        b.WriteString(m.viewDetails())
+        help = "↑/↓/pgup/pgdn scroll • a AI analysis • x export • esc back • q quit"
+    }
+
+    if m.status != "" {
+        b.WriteString("\n" + m.status)
+    }
+    b.WriteString("\n" + helpStyle.Render(help))
+    return b.String()
+}
+
+func (m *browser) viewNamespaces() string {
+    if len(m.namespaces) == 0 {
+        return "No namespaces\n"
+    }
+    start, end := visible(m.nsCursor, len(m.namespaces), m.pageSize()-4)
+    var b strings.Builder
+    for i := start; i < end; i++ {
+        if i == m.nsCursor {
+            b.WriteString(cursorStyle.Render("> "+m.namespaces[i]) + "\n")
+        } else {
+            b.WriteString("  " + m.namespaces[i] + "\n")
+        }
+    }
+    return b.String()
+}
+
+func (m *browser) viewWorkloads() string {
+    if len(m.workloads) == 0 {
+        return "No workloads found\n"
+    }
+    start, end := visible(m.cursor, len(m.workloads), m.pageSize()-8)
+
+    t := newTable("", "Workload", "Kind", "Replicas", "Efficiency", "Waste/mo", "Findings")
+    for i := start; i < end; i++ {
+        d := m.workloads[i]
+        mark := " "
+        if m.selected[d] {
+            mark = "✓"
+        }
+        if i == m.cursor {
+            mark = ">" + mark
+        }
+        waste := "-"
+        if d.Cost != nil {
+            waste = fmt.Sprintf("%.2f", d.Cost.MonthlyWaste)
+        }
+        t.Row(mark, d.Deployment, d.Kind, d.ReplicaCount, d.EfficiencyRate, waste, summarizeFindings(d.Findings))
+    }
+    t.StyleFunc(func(row, col int) lipgloss.Style {
+        switch {
+        case row == table.HeaderRow:
+            return headerStyle
+        case start+row == m.cursor:
+            return cellStyle.Inherit(cursorStyle)
+        case m.selected[m.workloads[start+row]]:
+            return cellStyle.Inherit(selectedStyle)
+        }
+        return cellStyle
+    })
+    return t.Render() + "\n"
+}
+
+func (m *browser) viewDetails() string {
+    lines := strings.Split(RenderAnalysis(m.details), "\n")
+    page := m.pageSize() - 2
+    m.scroll = min(m.scroll, max(len(lines)-page, 0))
+    end := min(m.scroll+page, len(lines))
+    return strings.Join(lines[m.scroll:end], "\n") + "\n"
+}
+
+// visible returns the window of n rows of at most size that keeps cursor
+// in view.
+func visible(cursor, n, size int) (start, end int) {
+    size = max(size, 1)
+    start = max(cursor-size+1, 0)
+    end = min(start+size, n)
+    return start, end
+}
@@ -0,0 +1,224 @@
+package ui
+
+import (
+    "context"
+    "strings"
+    "testing"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+func key(s string) tea.KeyMsg {
+    switch s {
+    case "up":
+        return tea.KeyMsg{Type: tea.KeyUp}
+    case "down":
+        return tea.KeyMsg{Type: tea.KeyDown}
+    case "enter":
+        return tea.KeyMsg{Type: tea.KeyEnter}
+    case "esc":
+        return tea.KeyMsg{Type: tea.KeyEsc}
+    case " ":
+        return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+    }
+    return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// press sends keys to the browser and runs the command of the last one,
+// feeding its message back in like the tea loop does.
+func press(t *testing.T, m *browser, keys ...string) {
+    t.Helper()
+    var cmd tea.Cmd
+    for _, k := range keys {
+        _, cmd = m.Update(key(k))
+    }
+    if cmd != nil {
+        m.Update(cmd())
+    }
+}
+
+func workload(name string, waste float64, severities ...analyzer.Severity) *analyzer.WorkloadDetails {
+    d := &analyzer.WorkloadDetails{Namespace: "shop", Deployment: name, Kind: "deployment", Cost: &analyzer.Cost{MonthlyWaste: waste}}
+    for _, s := range severities {
+        d.Findings = append(d.Findings, analyzer.Finding{RuleID: "KWA001", Severity: s})
+    }
+    return d
+}
+
+// newBrowser returns a browser showing the workloads of shop, with the
+// namespaces and exports it was asked for recorded in calls.
+func newBrowser(t *testing.T, workloads []*analyzer.WorkloadDetails, calls *[]string) *browser {
+    t.Helper()
+    m := &browser{
+        ctx:       context.Background(),
+        view:      viewWorkloads,
+        namespace: "shop",
+        cfg: BrowserConfig{
+            Namespaces: func(ctx context.Context) ([]string, error) {
+                *calls = append(*calls, "namespaces")
+                return []string{"default", "shop"}, nil
+            },
+            Scan: func(ctx context.Context, namespace string) ([]*analyzer.WorkloadDetails, error) {
+                *calls = append(*calls, "scan "+namespace)
+                return workloads, nil
+            },
+            Export: func(namespace string, workloads []*analyzer.WorkloadDetails) (string, error) {
+                var names []string
+                for _, d := range workloads {
+                    names = append(names, d.Deployment)
+                }
+                *calls = append(*calls, "export "+namespace+" "+strings.Join(names, ","))
+                return "/tmp/out.json", nil
+            },
+        },
+    }
+    m.Update(m.Init()())
+    return m
+}
+
+func TestBrowserEmptyNamespace(t *testing.T) {
+    var calls []string
+    m := newBrowser(t, nil, &calls)
+
+    for _, k := range []string{"down", "j", "s", "down", "up", "k", " ", "enter", "x", "s"} {
+        m.Update(key(k))
+        if m.cursor != 0 {
+            t.Fatalf("after %q: expected the cursor to stay at 0, got %d", k, m.cursor)
+        }
+    }
+    if m.view != viewWorkloads {
+        t.Errorf("expected enter on an empty list to stay in the workload list, got view %d", m.view)
+    }
+    if len(calls) != 1 {
+        t.Errorf("expected nothing but the initial scan, got %v", calls)
+    }
+    if !strings.Contains(m.View(), "Sorted by") {
+        t.Errorf("expected the sort status in the view:\n%s", m.View())
+    }
+}
+
+func TestBrowserMovesAndSortsKeepingCursor(t *testing.T) {
+    var calls []string
+    m := newBrowser(t, []*analyzer.WorkloadDetails{
+        workload("cart", 10, analyzer.SeverityLow),
+        workload("api", 5, analyzer.SeverityCritical),
+        workload("web", 50),
+    }, &calls)
+
+    names := func() string {
+        var names []string
+        for _, d := range m.workloads {
+            names = append(names, d.Deployment)
+        }
+        return strings.Join(names, ",")
+    }
+    if names() != "api,cart,web" || m.cursor != 0 {
+        t.Fatalf("expected workloads sorted by name with the cursor on top, got %s at %d", names(), m.cursor)
+    }
+
+    press(t, m, "down", "down", "down", "j")
+    if m.cursor != 2 {
+        t.Errorf("expected the cursor to stop at the last workload, got %d", m.cursor)
+    }
+    press(t, m, "up", "k", "k")
+    if m.cursor != 0 {
+        t.Errorf("expected the cursor to stop at the first workload, got %d", m.cursor)
+    }
+
+    press(t, m, "down") // cart
+    tests := []struct {
+        order string
+        want  string
+    }{
+        {"waste", "web,cart,api"},
+        {"risk", "api,cart,web"},
+        {"name", "api,cart,web"},
+    }
+    for _, tt := range tests {
+        press(t, m, "s")
+        if sortOrders[m.sortBy] != tt.order || names() != tt.want {
+            t.Errorf("expected %s order %s, got %s order %s", tt.order, tt.want, sortOrders[m.sortBy], names())
+        }
+        if m.workloads[m.cursor].Deployment != "cart" {
+            t.Errorf("expected sorting by %s to keep the cursor on cart, got %s", tt.order, m.workloads[m.cursor].Deployment)
+        }
+    }
+}
+
+func TestBrowserSelectAndExport(t *testing.T) {
+    var calls []string
+    m := newBrowser(t, []*analyzer.WorkloadDetails{workload("api", 0), workload("cart", 0), workload("web", 0)}, &calls)
+
+    // Without a selection the workload under the cursor is exported
+    press(t, m, "down", "x")
+    // Space toggles the selection
+    press(t, m, " ", "down", " ", "up", " ", "down")
+    press(t, m, "x")
+
+    want := []string{"scan shop", "export shop cart", "export shop web"}
+    if strings.Join(calls, "|") != strings.Join(want, "|") {
+        t.Errorf("expected calls %v, got %v", want, calls)
+    }
+    if !strings.Contains(m.status, "Exported to /tmp/out.json") {
+        t.Errorf("expected the export path in the status, got %q", m.status)
+    }
+
+    // From the details view the open workload is exported
+    calls = nil
+    m.selected = make(map[*analyzer.WorkloadDetails]bool)
+    press(t, m, "up", "enter")
+    press(t, m, "x")
+    if len(calls) != 1 || calls[0] != "export shop cart" {
+        t.Errorf("expected the open workload to be exported, got %v", calls)
+    }
+}
+
+func TestBrowserNavigation(t *testing.T) {
+    var calls []string
+    m := newBrowser(t, []*analyzer.WorkloadDetails{workload("api", 0), workload("web", 0)}, &calls)
+
+    press(t, m, "down", "enter")
+    if m.view != viewDetails || m.details.Deployment != "web" {
+        t.Fatalf("expected the details of web, got view %d", m.view)
+    }
+    press(t, m, "down", "down")
+    if m.scroll != 2 {
+        t.Errorf("expected to scroll the details, got %d", m.scroll)
+    }
+
+    press(t, m, "esc")
+    if m.view != viewWorkloads || m.cursor != 1 {
+        t.Errorf("expected esc to return to the workload list at web, got view %d cursor %d", m.view, m.cursor)
+    }
+
+    // The browser started in shop, so namespaces load on the way out
+    press(t, m, "esc")
+    if m.view != viewNamespaces || m.nsCursor != 1 {
+        t.Errorf("expected the namespace list with shop under the cursor, got view %d cursor %d", m.view, m.nsCursor)
+    }
+    press(t, m, "down", "down", "up", "enter")
+    if m.view != viewWorkloads || m.namespace != "default" {
+        t.Errorf("expected a scan of default, got view %d namespace %s", m.view, m.namespace)
+    }
+
+    want := []string{"scan shop", "namespaces", "scan default"}
+    if strings.Join(calls, "|") != strings.Join(want, "|") {
+        t.Errorf("expected calls %v, got %v", want, calls)
+    }
+
+    if _, cmd := m.Update(key("q")); cmd == nil {
+        t.Error("expected q to quit")
+    }
+}
+
+func TestBrowserEmptyNamespaceList(t *testing.T) {
+    m := &browser{ctx: context.Background(), view: viewNamespaces}
+    m.Update(namespacesMsg{})
+    for _, k := range []string{"down", "j", "enter", "up"} {
+        m.Update(key(k))
+        if m.nsCursor != 0 || m.view != viewNamespaces {
+            t.Fatalf("after %q: expected to stay on an empty namespace list, got view %d cursor %d", k, m.view, m.nsCursor)
+        }
+    }
+}
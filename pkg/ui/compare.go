@@ -0,0 +1,105 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/charmbracelet/lipgloss"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+var headerStyle = lipgloss.NewStyle().
+    Foreground(lipgloss.Color("87")).
+    Bold(true).
+    Padding(0, 1)
+
+var cellStyle = lipgloss.NewStyle().Padding(0, 1)
+
+// RenderComparison renders a multi-cluster comparison: one table per
+// workload with a row per cluster, followed by any configuration drift.
+func RenderComparison(c *analyzer.Comparison) string {
+    var b strings.Builder
+    b.WriteString("\n" + titleStyle.Render("Cross-Cluster Comparison") + "\n")
+
+    for _, cluster := range c.Clusters {
+        if msg, ok := c.Errors[cluster]; ok {
+            b.WriteString("\n" + errorStyle.Render(fmt.Sprintf("%s: %s", cluster, msg)) + "\n")
+        }
+    }
+
+    if len(c.Workloads) == 0 {
+        b.WriteString("\nNo workloads found\n")
+        return b.String()
+    }
+
+    for _, wc := range c.Workloads {
+        title := fmt.Sprintf("%s/%s %s", wc.Namespace, wc.Kind, wc.Name)
+        if len(wc.Drift) > 0 {
+            title += " " + warningStyle.Render(fmt.Sprintf("(%d drifted)", len(wc.Drift)))
+        } else {
+            title += " " + successStyle.Render("(consistent)")
+        }
+        b.WriteString("\n" + title + "\n")
+        b.WriteString(comparisonTable(c.Clusters, wc) + "\n")
+
+        for _, d := range wc.Drift {
+            b.WriteString(warningStyle.Render("  ≠ "+d.Field) + ": ")
+            var values []string
+            for _, cluster := range c.Clusters {
+                if v, ok := d.Values[cluster]; ok {
+                    values = append(values, fmt.Sprintf("%s=%s", cluster, v))
+                }
+            }
+            b.WriteString(valueStyle.Render(strings.Join(values, ", ")) + "\n")
+        }
+    }
+    return b.String()
+}
+
+func comparisonTable(clusters []string, wc analyzer.WorkloadComparison) string {
+    t := newTable("Cluster", "Replicas", "CPU Req", "Mem Req", "CPU Used", "Mem Used", "Efficiency", "Findings")
+
+    for _, cluster := range clusters {
+        d, ok := wc.Clusters[cluster]
+        if !ok {
+            t.Row(cluster, "-", "-", "-", "-", "-", "-", "-")
+            continue
+        }
+        var cpuReq, memReq, cpuUsed, memUsed int64
+        for _, c := range d.Containers {
+            cpuReq += c.CPURequest
+            memReq += c.MemoryRequest
+            cpuUsed += c.CPUUsage
+            memUsed += c.MemoryUsage
+        }
+        t.Row(
+            cluster,
+            d.ReplicaCount,
+            analyzer.FormatCPU(cpuReq),
+            analyzer.FormatMemory(memReq),
+            analyzer.FormatCPU(cpuUsed),
+            analyzer.FormatMemory(memUsed),
+            d.EfficiencyRate,
+            summarizeFindings(d.Findings),
+        )
+    }
+    return t.Render()
+}
+
+// summarizeFindings counts findings per severity, most severe first.
+func summarizeFindings(findings []analyzer.Finding) string {
+    if len(findings) == 0 {
+        return "0"
+    }
+    counts := make(map[analyzer.Severity]int)
+    for _, f := range findings {
+        counts[f.Severity]++
+    }
+    var parts []string
+    for _, s := range []analyzer.Severity{analyzer.SeverityCritical, analyzer.SeverityHigh, analyzer.SeverityMedium, analyzer.SeverityLow, analyzer.SeverityInfo} {
+        if counts[s] > 0 {
+            parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+        }
+    }
+    return strings.Join(parts, ", ")
+}
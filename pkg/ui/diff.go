@@ -0,0 +1,119 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// RenderDiff renders what changed between two runs. Unchanged workloads
+// are only counted.
+func RenderDiff(before, after string, diff *analyzer.RunDiff) string {
+    var b strings.Builder
+    b.WriteString("\n" + titleStyle.Render("Analysis Diff") + "\n\n")
+    b.WriteString(fmt.Sprintf("%s: %s\n%s: %s\n",
+        labelStyle.Render("Before"), valueStyle.Render(before),
+        labelStyle.Render("After"), valueStyle.Render(after),
+    ))
+
+    unchanged := 0
+    for _, wd := range diff.Workloads {
+        if wd.Status == analyzer.DiffUnchanged {
+            unchanged++
+            continue
+        }
+        b.WriteString("\n" + sectionStyle.Render(renderWorkloadDiff(wd)) + "\n")
+    }
+    if unchanged > 0 {
+        b.WriteString(fmt.Sprintf("\n%d workload(s) unchanged\n", unchanged))
+    }
+    if len(diff.Workloads) == 0 {
+        b.WriteString("\nNo workloads in either run\n")
+    }
+    return b.String()
+}
+
+func renderWorkloadDiff(wd analyzer.WorkloadDiff) string {
+    name := fmt.Sprintf("%s/%s/%s", wd.Namespace, wd.Kind, wd.Name)
+    if wd.Cluster != "" {
+        name = wd.Cluster + "/" + name
+    }
+    switch wd.Status {
+    case analyzer.DiffAdded:
+        return successStyle.Render("+ "+name) + " (new)"
+    case analyzer.DiffRemoved:
+        return errorStyle.Render("- "+name) + " (removed)"
+    }
+
+    lines := []string{valueStyle.Render("~ " + name)}
+    row := func(label, value string) {
+        lines = append(lines, fmt.Sprintf("%s: %s", labelStyle.Render(label), value))
+    }
+
+    if wd.Replicas != nil {
+        row("Replicas", fmt.Sprintf("%d → %d", wd.Replicas.Before, wd.Replicas.After))
+    }
+    if e := wd.Efficiency; e != nil {
+        value := fmt.Sprintf("%s → %s", e.Before, e.After)
+        if e.Delta != nil {
+            style := successStyle
+            if *e.Delta < 0 {
+                style = errorStyle
+            }
+            value += " " + style.Render(fmt.Sprintf("(%+.1f pts)", *e.Delta))
+        }
+        row("Efficiency", value)
+    }
+    for _, c := range wd.Containers {
+        switch c.Status {
+        case analyzer.DiffAdded:
+            row("Container "+c.Name, successStyle.Render("added"))
+            continue
+        case analyzer.DiffRemoved:
+            row("Container "+c.Name, errorStyle.Render("removed"))
+            continue
+        }
+        var changes []string
+        if c.Image != nil {
+            changes = append(changes, fmt.Sprintf("image %s → %s", c.Image.Before, c.Image.After))
+        }
+        cpu := func(label string, ch *analyzer.Change) {
+            if ch != nil {
+                changes = append(changes, fmt.Sprintf("%s %s → %s", label, analyzer.FormatCPU(ch.Before), analyzer.FormatCPU(ch.After)))
+            }
+        }
+        memory := func(label string, ch *analyzer.Change) {
+            if ch != nil {
+                changes = append(changes, fmt.Sprintf("%s %s → %s", label, analyzer.FormatMemory(ch.Before), analyzer.FormatMemory(ch.After)))
+            }
+        }
+        cpu("cpu request", c.CPURequest)
+        cpu("cpu limit", c.CPULimit)
+        memory("memory request", c.MemoryRequest)
+        memory("memory limit", c.MemoryLimit)
+        cpu("cpu used", c.CPUUsage)
+        memory("memory used", c.MemoryUsage)
+        row("Container "+c.Name, strings.Join(changes, "\n"+strings.Repeat(" ", 22)))
+    }
+    for _, f := range wd.NewFindings {
+        row("New Finding", severityStyle(f.Severity).Render(fmt.Sprintf("[%s %s]", f.Severity, f.RuleID))+" "+f.Message)
+    }
+    for _, f := range wd.ResolvedFindings {
+        row("Resolved", successStyle.Render(fmt.Sprintf("[%s %s]", f.Severity, f.RuleID))+" "+f.Message)
+    }
+    if c := wd.Cost; c != nil {
+        row("Monthly Cost", fmt.Sprintf("%s → %s %s", formatMoney(c.RequestedBefore, c.Currency), formatMoney(c.RequestedAfter, c.Currency), moneyDelta(c.RequestedDelta(), c.Currency)))
+        row("Monthly Waste", fmt.Sprintf("%s → %s %s", formatMoney(c.WasteBefore, c.Currency), formatMoney(c.WasteAfter, c.Currency), moneyDelta(c.WasteDelta(), c.Currency)))
+    }
+    return strings.Join(lines, "\n")
+}
+
+// moneyDelta renders a cost change; savings are good.
+func moneyDelta(delta float64, currency string) string {
+    style := errorStyle
+    if delta <= 0 {
+        style = successStyle
+    }
+    return style.Render(fmt.Sprintf("(%+.2f %s)", delta, currency))
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "k8s-workload-analyzer/pkg/eval"
+)
+
+// RenderEval renders the scores of each variant side by side, followed by
+// the expected issues each variant missed.
+func RenderEval(summaries []*eval.Summary) string {
+    var b strings.Builder
+    b.WriteString("\n" + titleStyle.Render("Prompt Evaluation") + "\n\n")
+
+    t := newTable("Variant", "Prompts", "Cases", "Errors", "Valid JSON", "Precision", "Recall", "p50", "p95", "Tokens in/out", "Cost")
+    for _, s := range summaries {
+        cost := "-"
+        if s.Cost != nil {
+            cost = fmt.Sprintf("$%.4f", *s.Cost)
+        }
+        t.Row(
+            s.Variant,
+            orDash(s.PromptVersion),
+            fmt.Sprint(s.Cases),
+            fmt.Sprint(s.Errors),
+            percent(s.ValidRate),
+            percent(s.Precision),
+            percent(s.Recall),
+            s.LatencyP50.Round(time.Millisecond).String(),
+            s.LatencyP95.Round(time.Millisecond).String(),
+            fmt.Sprintf("%d/%d", s.PromptTokens, s.CompletionTokens),
+            cost,
+        )
+    }
+    b.WriteString(t.Render() + "\n")
+
+    for _, s := range summaries {
+        var lines []string
+        for _, r := range s.Results {
+            switch {
+            case r.Error != "":
+                lines = append(lines, errorStyle.Render(fmt.Sprintf("  ✗ %s: %s", r.Case, r.Error)))
+            case len(r.Missed) > 0:
+                lines = append(lines, warningStyle.Render(fmt.Sprintf("  ✗ %s missed %s", r.Case, strings.Join(r.Missed, ", "))))
+            }
+        }
+        if len(lines) > 0 {
+            b.WriteString("\n" + s.Variant + "\n" + strings.Join(lines, "\n") + "\n")
+        }
+    }
+    return b.String()
+}
+
+func percent(ratio float64) string {
+    return fmt.Sprintf("%.0f%%", ratio*100)
+}
+
+func orDash(s string) string {
+    if s == "" {
+        return "-"
+    }
+    return s
+}
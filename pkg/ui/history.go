@@ -0,0 +1,168 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/charmbracelet/lipgloss"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/store"
+)
+
+const timeFormat = "2006-01-02 15:04"
+
+// RenderHistory renders one row per stored analysis of a workload.
+func RenderHistory(key string, records []store.Record) string {
+    var b strings.Builder
+    b.WriteString("\n" + titleStyle.Render("Workload History") + "\n\n")
+    b.WriteString(fmt.Sprintf("%s: %s\n", labelStyle.Render("Workload"), valueStyle.Render(key)))
+
+    if len(records) == 0 {
+        b.WriteString("\nNo history\n")
+        return b.String()
+    }
+
+    t := newTable("ID", "Time", "Spec", "Replicas", "CPU Used", "Mem Used", "Efficiency", "Waste/mo", "Findings")
+    for _, rec := range records {
+        d := rec.Workload
+        waste := "-"
+        if d.Cost != nil {
+            waste = fmt.Sprintf("%.2f", d.Cost.MonthlyWaste)
+        }
+        t.Row(
+            fmt.Sprintf("%d", rec.ID),
+            rec.Timestamp.Local().Format(timeFormat),
+            rec.SpecHash,
+            d.ReplicaCount,
+            d.CPUUtilization,
+            d.MemoryUtilization,
+            d.EfficiencyRate,
+            waste,
+            summarizeFindings(d.Findings),
+        )
+    }
+    b.WriteString("\n" + t.Render() + "\n")
+    return b.String()
+}
+
+// trendHeight is the number of terminal rows of the efficiency chart, and
+// trendWidth the most recent records it shows.
+const (
+    trendHeight = 10
+    trendWidth  = 60
+)
+
+var trendBlocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// RenderTrend charts the efficiency rate of the most recent records as
+// vertical bars, colored by the given efficiency bands. Records without a rate (e.g.
+// no running pods) leave a gap, and ▲ marks runs where the spec changed.
+func RenderTrend(key string, records []store.Record, bands analyzer.EfficiencyBands) string {
+    var b strings.Builder
+    b.WriteString("\n" + titleStyle.Render("Efficiency Trend") + "\n\n")
+    b.WriteString(fmt.Sprintf("%s: %s\n\n", labelStyle.Render("Workload"), valueStyle.Render(key)))
+
+    if len(records) > trendWidth {
+        records = records[len(records)-trendWidth:]
+    }
+    if len(records) == 0 {
+        b.WriteString("No history\n")
+        return b.String()
+    }
+
+    percents := make([]float64, len(records))
+    known := make([]bool, len(records))
+    for i, rec := range records {
+        percents[i], known[i] = analyzer.EfficiencyPercent(rec.Workload.EfficiencyRate)
+    }
+
+    // Rows are drawn top down; each row covers 100/trendHeight percent
+    step := 100.0 / trendHeight
+    for row := trendHeight - 1; row >= 0; row-- {
+        axis := "    "
+        switch row {
+        case trendHeight - 1:
+            axis = "100%"
+        case trendHeight / 2:
+            axis = " 50%"
+        case 0:
+            axis = "  0%"
+        }
+        b.WriteString(labelStyle.Width(0).Render(axis) + " │")
+
+        for i, p := range percents {
+            cell := " "
+            if known[i] {
+                fill := (min(p, 100) - float64(row)*step) / step
+                if fill > 0 {
+                    cell = string(trendBlocks[int(min(fill, 1)*8)])
+                }
+            }
+            b.WriteString(trendStyle(p, bands).Render(cell))
+        }
+        b.WriteString("\n")
+    }
+    b.WriteString("     └" + strings.Repeat("─", len(records)) + "\n")
+
+    var markers strings.Builder
+    changed := false
+    for i, rec := range records {
+        if i > 0 && rec.SpecHash != records[i-1].SpecHash {
+            markers.WriteString("▲")
+            changed = true
+        } else {
+            markers.WriteString(" ")
+        }
+    }
+    if changed {
+        b.WriteString("      " + warningStyle.Render(markers.String()) + "\n")
+    }
+
+    first := records[0].Timestamp.Local().Format(timeFormat)
+    last := records[len(records)-1].Timestamp.Local().Format(timeFormat)
+    b.WriteString(fmt.Sprintf("      %s → %s (%d runs)\n", first, last, len(records)))
+    if changed {
+        b.WriteString("      " + warningStyle.Render("▲") + " spec changed\n")
+    }
+    return b.String()
+}
+
+func trendStyle(percent float64, bands analyzer.EfficiencyBands) lipgloss.Style {
+    switch bands.Level(percent) {
+    case "High":
+        return successStyle
+    case "Medium":
+        return warningStyle
+    }
+    return errorStyle
+}
+
+// RenderFindingHistory renders when each finding first appeared and when
+// it was resolved.
+func RenderFindingHistory(key string, spans []store.FindingSpan) string {
+    var b strings.Builder
+    b.WriteString("\n" + titleStyle.Render("Finding History") + "\n\n")
+    b.WriteString(fmt.Sprintf("%s: %s\n", labelStyle.Render("Workload"), valueStyle.Render(key)))
+
+    if len(spans) == 0 {
+        b.WriteString("\nNo findings recorded\n")
+        return b.String()
+    }
+
+    t := newTable("Finding", "Severity", "First Seen", "Resolved", "Message")
+    for _, span := range spans {
+        resolved := "open"
+        if span.Resolved != nil {
+            resolved = span.Resolved.Local().Format(timeFormat)
+        }
+        t.Row(
+            span.Finding.ID,
+            string(span.Finding.Severity),
+            span.FirstSeen.Local().Format(timeFormat),
+            resolved,
+            span.Finding.Message,
+        )
+    }
+    b.WriteString("\n" + t.Render() + "\n")
+    return b.String()
+}
@@ -38,7 +38,9 @@ var (
 
 func RenderAnalysis(details *analyzer.WorkloadDetails) string {
     // Format basic info
-    basicInfo := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s",
+    basicInfo := fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n%s: %s",
+        labelStyle.Render("Cluster"),
+        valueStyle.Render(details.Cluster),
         labelStyle.Render("Namespace"),
         valueStyle.Render(details.Namespace),
         labelStyle.Render("Deployment"),
@@ -62,6 +64,14 @@ func RenderAnalysis(details *analyzer.WorkloadDetails) string {
         labelStyle.Render("Efficiency Rate"),
         formatEfficiencyRate(details.EfficiencyRate),
     )
+    if details.Cost != nil {
+        metrics += fmt.Sprintf("\n%s: %s\n%s: %s",
+            labelStyle.Render("Monthly Cost"),
+            valueStyle.Render(formatMoney(details.Cost.MonthlyRequested, details.Cost.Currency)),
+            labelStyle.Render("Monthly Waste"),
+            warningStyle.Render(formatMoney(details.Cost.MonthlyWaste, details.Cost.Currency)),
+        )
+    }
 
     // Format analysis sections
     analysis := fmt.Sprintf("%s: %s\n%s: %s",
@@ -70,6 +80,12 @@ func RenderAnalysis(details *analyzer.WorkloadDetails) string {
         labelStyle.Render("Analysis"),
         valueStyle.Render(details.Analysis),
     )
+    if details.DroppedClaims > 0 {
+        analysis += fmt.Sprintf("\n%s: %s",
+            labelStyle.Render("Dropped Claims"),
+            warningStyle.Render(fmt.Sprintf("%d citing evidence that doesn't exist", details.DroppedClaims)),
+        )
+    }
 
     return fmt.Sprintf(`
 %s
@@ -86,13 +102,16 @@ func RenderAnalysis(details *analyzer.WorkloadDetails) string {
 
 %s
 
+%s
+
 %s`,
         titleStyle.Render("Workload Analysis"),
         sectionStyle.Render(basicInfo),
         sectionStyle.Render(metrics),
         sectionStyle.Render(analysis),
-        formatSection("Opportunities", details.Opportunities, successStyle),
-        formatSection("Cautions", details.Cautions, warningStyle),
+        formatFindings(details.Findings),
+        formatClaims("Opportunities", details.Opportunities, successStyle),
+        formatClaims("Cautions", details.Cautions, warningStyle),
         formatSection("Blockers", details.Blockers, errorStyle),
         formatSection("Recommendations", details.Recommendations, successStyle),
     )
@@ -128,6 +147,56 @@ func formatSection(title string, items []string, style lipgloss.Style) string {
     return sectionStyle.Render(content)
 }
 
+// formatClaims lists AI claims with the findings and metric values they
+// cite underneath, flagging those that cite nothing verifiable.
+func formatClaims(title string, claims []analyzer.Claim, style lipgloss.Style) string {
+    if len(claims) == 0 {
+        return sectionStyle.Render(fmt.Sprintf("%s:\nNone", labelStyle.Render(title)))
+    }
+
+    content := fmt.Sprintf("%s:", labelStyle.Render(title))
+    for _, c := range claims {
+        content += fmt.Sprintf("\n• %s", style.Render(c.Text))
+        if c.Unverified {
+            content += " " + errorStyle.Render("(unverified)")
+        }
+        if citations := formatCitations(c); citations != "" {
+            content += "\n  " + labelStyle.UnsetWidth().Render("↳ "+citations)
+        }
+    }
+    return sectionStyle.Render(content)
+}
+
+func formatCitations(c analyzer.Claim) string {
+    return strings.Join(c.Citations(), ", ")
+}
+
+func formatMoney(amount float64, currency string) string {
+    return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+func formatFindings(findings []analyzer.Finding) string {
+    if len(findings) == 0 {
+        return sectionStyle.Render(fmt.Sprintf("%s:\nNone", labelStyle.Render("Findings")))
+    }
+
+    content := fmt.Sprintf("%s:", labelStyle.Render("Findings"))
+    for _, f := range findings {
+        content += fmt.Sprintf("\n• %s %s", severityStyle(f.Severity).Render(fmt.Sprintf("[%s %s]", f.Severity, f.RuleID)), valueStyle.Render(f.Message))
+    }
+    return sectionStyle.Render(content)
+}
+
+func severityStyle(severity analyzer.Severity) lipgloss.Style {
+    switch {
+    case severity.AtLeast(analyzer.SeverityHigh):
+        return errorStyle
+    case severity.AtLeast(analyzer.SeverityMedium):
+        return warningStyle
+    }
+    return valueStyle
+}
+
 func renderBasicInfo(details *analyzer.WorkloadDetails) string {
     return fmt.Sprintf(`
 Namespace           : %s
@@ -136,7 +205,7 @@ Kind               : %s
 Main Container     : %s
 Pod QoS Class      : %s
 Average Replica Count: %s
-Container Count    : %d`,
+Container Count    : %s`,
         details.Namespace,
         details.Deployment,
         details.Kind,
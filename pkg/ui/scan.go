@@ -0,0 +1,108 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/charmbracelet/lipgloss"
+    "github.com/charmbracelet/lipgloss/table"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// RenderScan renders a one-line-per-workload summary of a namespace scan.
+func RenderScan(cluster, namespace string, workloads []*analyzer.WorkloadDetails) string {
+    var b strings.Builder
+    b.WriteString("\n" + titleStyle.Render("Namespace Scan") + "\n\n")
+    b.WriteString(fmt.Sprintf("%s: %s\n%s: %s\n",
+        labelStyle.Render("Cluster"), valueStyle.Render(cluster),
+        labelStyle.Render("Namespace"), valueStyle.Render(namespace),
+    ))
+
+    if len(workloads) == 0 {
+        b.WriteString("\nNo workloads found\n")
+        return b.String()
+    }
+
+    t := newTable("Workload", "Kind", "Replicas", "CPU Req", "CPU Used", "Mem Req", "Mem Used", "Efficiency", "Waste/mo", "Findings")
+    var totalWaste float64
+    var currency string
+    for _, d := range workloads {
+        var cpuReq, memReq, cpuUsed, memUsed int64
+        for _, c := range d.Containers {
+            cpuReq += c.CPURequest
+            memReq += c.MemoryRequest
+            cpuUsed += c.CPUUsage
+            memUsed += c.MemoryUsage
+        }
+        waste := "-"
+        if d.Cost != nil {
+            waste = fmt.Sprintf("%.2f", d.Cost.MonthlyWaste)
+            totalWaste += d.Cost.MonthlyWaste
+            currency = d.Cost.Currency
+        }
+        t.Row(
+            d.Deployment,
+            d.Kind,
+            d.ReplicaCount,
+            analyzer.FormatCPU(cpuReq),
+            analyzer.FormatCPU(cpuUsed),
+            analyzer.FormatMemory(memReq),
+            analyzer.FormatMemory(memUsed),
+            d.EfficiencyRate,
+            waste,
+            summarizeFindings(d.Findings),
+        )
+    }
+    b.WriteString("\n" + t.Render() + "\n")
+    if currency != "" {
+        b.WriteString(fmt.Sprintf("%s: %s\n", labelStyle.Render("Total Waste/mo"), warningStyle.Render(formatMoney(totalWaste, currency))))
+    }
+    return b.String()
+}
+
+// RenderRecommendations renders right-sizing recommendations as a table.
+func RenderRecommendations(cluster string, recommendations []analyzer.ResourceRecommendation) string {
+    var b strings.Builder
+    b.WriteString("\n" + titleStyle.Render("Right-Sizing Recommendations") + "\n\n")
+    b.WriteString(fmt.Sprintf("%s: %s\n", labelStyle.Render("Cluster"), valueStyle.Render(cluster)))
+
+    if len(recommendations) == 0 {
+        b.WriteString("\nNo recommendations (no usage data)\n")
+        return b.String()
+    }
+
+    t := newTable("Workload", "Container", "CPU Req", "→", "Mem Req", "→", "Mem Limit", "→", "Savings/mo")
+    var total float64
+    var currency string
+    for _, r := range recommendations {
+        t.Row(
+            r.Kind+"/"+r.Name,
+            r.Container,
+            analyzer.FormatCPU(r.CPURequest),
+            analyzer.FormatCPU(r.RecommendedCPURequest),
+            analyzer.FormatMemory(r.MemoryRequest),
+            analyzer.FormatMemory(r.RecommendedMemoryRequest),
+            analyzer.FormatMemory(r.MemoryLimit),
+            analyzer.FormatMemory(r.RecommendedMemoryLimit),
+            fmt.Sprintf("%.2f", r.MonthlySavings),
+        )
+        total += r.MonthlySavings
+        currency = r.Currency
+    }
+    b.WriteString("\n" + t.Render() + "\n")
+    b.WriteString(fmt.Sprintf("%s: %s\n", labelStyle.Render("Total Savings/mo"), successStyle.Render(formatMoney(total, currency))))
+    return b.String()
+}
+
+func newTable(headers ...string) *table.Table {
+    return table.New().
+        Border(lipgloss.RoundedBorder()).
+        BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("240"))).
+        Headers(headers...).
+        StyleFunc(func(row, col int) lipgloss.Style {
+            if row == table.HeaderRow {
+                return headerStyle
+            }
+            return cellStyle
+        })
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/charmbracelet/lipgloss"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/watch"
+)
+
+// RenderWatch renders the live dashboard of a watched workload.
+func RenderWatch(snap *watch.Snapshot, interval time.Duration) string {
+    d := snap.Details
+    var b strings.Builder
+    b.WriteString("\n" + titleStyle.Render("Watching "+d.Kind+"/"+d.Deployment) + "\n\n")
+    b.WriteString(fmt.Sprintf("%s: %s\n%s: %s\n%s: %s\n%s: %s\n",
+        labelStyle.Render("Cluster"), valueStyle.Render(d.Cluster),
+        labelStyle.Render("Namespace"), valueStyle.Render(d.Namespace),
+        labelStyle.Render("Replicas"), replicaStyle(snap).Render(fmt.Sprintf("%d/%d ready, %d pod(s)", snap.Ready, snap.Desired, snap.Pods)),
+        labelStyle.Render("Efficiency Rate"), formatEfficiencyRate(d.EfficiencyRate),
+    ))
+    if d.Cost != nil {
+        b.WriteString(fmt.Sprintf("%s: %s\n", labelStyle.Render("Monthly Waste"), warningStyle.Render(formatMoney(d.Cost.MonthlyWaste, d.Cost.Currency))))
+    }
+    b.WriteString(fmt.Sprintf("%s: %s\n", labelStyle.Render("Findings"), valueStyle.Render(summarizeFindings(d.Findings))))
+
+    requests := make(map[string]analyzer.ContainerDetails)
+    for _, c := range d.Containers {
+        requests[c.Name] = c
+    }
+    if len(snap.Stats) == 0 {
+        b.WriteString("\nNo usage samples yet\n")
+    } else {
+        t := newTable("Container", "CPU Req", "CPU Min", "CPU Avg", "CPU Max", "Mem Req", "Mem Min", "Mem Avg", "Mem Max", "Restarts")
+        for _, s := range snap.Stats {
+            c := requests[s.Name]
+            t.Row(
+                s.Name,
+                analyzer.FormatCPU(c.CPURequest),
+                analyzer.FormatCPU(s.CPU.Min),
+                analyzer.FormatCPU(s.CPU.Avg),
+                analyzer.FormatCPU(s.CPU.Max),
+                analyzer.FormatMemory(c.MemoryRequest),
+                analyzer.FormatMemory(s.Memory.Min),
+                analyzer.FormatMemory(s.Memory.Avg),
+                analyzer.FormatMemory(s.Memory.Max),
+                restartCell(snap.Restarts[s.Name]),
+            )
+        }
+        b.WriteString("\n" + t.Render() + "\n")
+    }
+
+    if len(snap.Events) > 0 {
+        b.WriteString("\n" + labelStyle.Render("Recent Changes") + "\n")
+        for _, e := range snap.Events {
+            b.WriteString(fmt.Sprintf("  %s  %s\n", e.Time.Format("15:04:05"), valueStyle.Render(e.Message)))
+        }
+    }
+    if snap.Err != nil {
+        b.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Error: %v", snap.Err)) + "\n")
+    }
+
+    b.WriteString(fmt.Sprintf("\n%d sample(s) every %s, last at %s, watching since %s. Ctrl-C to stop.\n",
+        snap.Samples, interval, snap.Sampled.Format("15:04:05"), snap.Started.Format("15:04:05")))
+    return b.String()
+}
+
+func replicaStyle(snap *watch.Snapshot) lipgloss.Style {
+    if snap.Ready < snap.Desired {
+        return warningStyle
+    }
+    return successStyle
+}
+
+func restartCell(count int32) string {
+    if count == 0 {
+        return "0"
+    }
+    return errorStyle.Render(fmt.Sprintf("%d", count))
+}
@@ -0,0 +1,351 @@
+package watch
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "time"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/meta"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/apimachinery/pkg/fields"
+    "k8s.io/apimachinery/pkg/labels"
+    "k8s.io/client-go/informers"
+    "k8s.io/client-go/kubernetes"
+    "k8s.io/client-go/tools/cache"
+    metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+// maxEvents is how many recent changes a snapshot keeps.
+const maxEvents = 8
+
+// Config is the workload to watch and how.
+type Config struct {
+    Client    kubernetes.Interface
+    Metrics   metricsv.Interface // nil disables usage sampling
+    Namespace string
+    Type      string
+    Name      string
+
+    // Interval is how often usage is sampled; Window how far back the
+    // rolling min/avg/max go.
+    Interval time.Duration
+    Window   time.Duration
+
+    // Analyze runs the full analysis, AI included. It is called at the
+    // start and again only when the workload's spec changes.
+    Analyze func(ctx context.Context) (*analyzer.WorkloadDetails, error)
+
+    // Render is called with a snapshot whenever something changed.
+    Render func(snap *Snapshot)
+}
+
+// Snapshot is what the dashboard shows at one point in time.
+type Snapshot struct {
+    Details  *analyzer.WorkloadDetails
+    Started  time.Time
+    Sampled  time.Time
+    Desired  int32
+    Ready    int32
+    Pods     int
+    Samples  int
+    Stats    []analyzer.ContainerStats
+    Restarts map[string]int32 // per container, since the watch started
+    Events   []Event          // most recent last
+    Err      error            // of the last sample or analysis
+}
+
+type Event struct {
+    Time    time.Time
+    Message string
+}
+
+// Watcher keeps a workload and its pods in informer caches and samples
+// their usage, so only metrics are polled.
+type Watcher struct {
+    cfg    Config
+    window *analyzer.UsageWindow
+
+    mu         sync.Mutex
+    snap       Snapshot
+    generation int64
+    restarts   map[string]int32 // restart counts when the watch started, per pod/container
+    pods       cache.Indexer
+
+    changed     chan struct{}
+    specChanged chan struct{}
+}
+
+func New(cfg Config) *Watcher {
+    return &Watcher{
+        cfg:         cfg,
+        window:      &analyzer.UsageWindow{Window: cfg.Window},
+        changed:     make(chan struct{}, 1),
+        specChanged: make(chan struct{}, 1),
+    }
+}
+
+// Run watches until ctx is done.
+func (w *Watcher) Run(ctx context.Context) error {
+    selector, err := w.selector(ctx)
+    if err != nil {
+        return err
+    }
+    details, err := w.cfg.Analyze(ctx)
+    if err != nil {
+        return err
+    }
+    w.snap = Snapshot{Details: details, Started: time.Now(), Restarts: map[string]int32{}}
+
+    workloads := informers.NewSharedInformerFactoryWithOptions(w.cfg.Client, 0,
+        informers.WithNamespace(w.cfg.Namespace),
+        informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+            opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", w.cfg.Name).String()
+        }),
+    )
+    pods := informers.NewSharedInformerFactoryWithOptions(w.cfg.Client, 0,
+        informers.WithNamespace(w.cfg.Namespace),
+        informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+            opts.LabelSelector = selector.String()
+        }),
+    )
+
+    var workloadInformer cache.SharedIndexInformer
+    switch w.cfg.Type {
+    case "deployment":
+        workloadInformer = workloads.Apps().V1().Deployments().Informer()
+    case "statefulset":
+        workloadInformer = workloads.Apps().V1().StatefulSets().Informer()
+    default:
+        workloadInformer = workloads.Apps().V1().DaemonSets().Informer()
+    }
+    podInformer := pods.Core().V1().Pods().Informer()
+    w.pods = podInformer.GetIndexer()
+
+    onWorkload := func(obj interface{}) { w.workloadChanged(obj) }
+    if _, err := workloadInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+        AddFunc:    onWorkload,
+        UpdateFunc: func(_, obj interface{}) { onWorkload(obj) },
+    }); err != nil {
+        return fmt.Errorf("failed to watch %s: %v", w.cfg.Type, err)
+    }
+    onPod := func(interface{}) { w.podsChanged() }
+    if _, err := podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+        AddFunc:    onPod,
+        UpdateFunc: func(_, obj interface{}) { onPod(obj) },
+        DeleteFunc: onPod,
+    }); err != nil {
+        return fmt.Errorf("failed to watch pods: %v", err)
+    }
+
+    workloads.Start(ctx.Done())
+    pods.Start(ctx.Done())
+    defer workloads.Shutdown()
+    defer pods.Shutdown()
+    if !cache.WaitForCacheSync(ctx.Done(), workloadInformer.HasSynced, podInformer.HasSynced) {
+        return ctx.Err()
+    }
+
+    ticker := time.NewTicker(w.cfg.Interval)
+    defer ticker.Stop()
+    w.sample(ctx, selector)
+    for {
+        w.render()
+        select {
+        case <-ctx.Done():
+            return nil
+        case <-w.specChanged:
+            w.reanalyze(ctx)
+        case <-ticker.C:
+            w.sample(ctx, selector)
+        case <-w.changed:
+        }
+    }
+}
+
+// selector returns the workload's pod selector, which apps/v1 makes
+// immutable, so it is read once.
+func (w *Watcher) selector(ctx context.Context) (labels.Selector, error) {
+    var selector *metav1.LabelSelector
+    apps := w.cfg.Client.AppsV1()
+    switch w.cfg.Type {
+    case "deployment":
+        d, err := apps.Deployments(w.cfg.Namespace).Get(ctx, w.cfg.Name, metav1.GetOptions{})
+        if err != nil {
+            return nil, fmt.Errorf("failed to get deployment: %v", err)
+        }
+        selector = d.Spec.Selector
+    case "statefulset":
+        s, err := apps.StatefulSets(w.cfg.Namespace).Get(ctx, w.cfg.Name, metav1.GetOptions{})
+        if err != nil {
+            return nil, fmt.Errorf("failed to get statefulset: %v", err)
+        }
+        selector = s.Spec.Selector
+    case "daemonset":
+        d, err := apps.DaemonSets(w.cfg.Namespace).Get(ctx, w.cfg.Name, metav1.GetOptions{})
+        if err != nil {
+            return nil, fmt.Errorf("failed to get daemonset: %v", err)
+        }
+        selector = d.Spec.Selector
+    default:
+        return nil, fmt.Errorf("unsupported workload type: %s", w.cfg.Type)
+    }
+    s, err := metav1.LabelSelectorAsSelector(selector)
+    if err != nil {
+        return nil, fmt.Errorf("invalid selector: %v", err)
+    }
+    return s, nil
+}
+
+// workloadChanged tracks replica counts and queues a new analysis when the
+// spec generation moves.
+func (w *Watcher) workloadChanged(obj interface{}) {
+    o, err := meta.Accessor(obj)
+    if err != nil {
+        return
+    }
+    desired, ready := replicas(obj)
+
+    w.mu.Lock()
+    if w.generation != 0 && o.GetGeneration() != w.generation {
+        w.event("spec changed (generation %d), re-analyzing", o.GetGeneration())
+        notify(w.specChanged)
+    }
+    w.generation = o.GetGeneration()
+    if w.snap.Desired != desired && !w.snap.Sampled.IsZero() {
+        w.event("desired replicas %d → %d", w.snap.Desired, desired)
+    }
+    w.snap.Desired, w.snap.Ready = desired, ready
+    w.mu.Unlock()
+    notify(w.changed)
+}
+
+func replicas(obj interface{}) (desired, ready int32) {
+    switch o := obj.(type) {
+    case *appsv1.Deployment:
+        return valueOr(o.Spec.Replicas, 1), o.Status.ReadyReplicas
+    case *appsv1.StatefulSet:
+        return valueOr(o.Spec.Replicas, 1), o.Status.ReadyReplicas
+    case *appsv1.DaemonSet:
+        return o.Status.DesiredNumberScheduled, o.Status.NumberReady
+    }
+    return 0, 0
+}
+
+func valueOr(p *int32, value int32) int32 {
+    if p == nil {
+        return value
+    }
+    return *p
+}
+
+// podsChanged recounts pods and container restarts since the watch started.
+func (w *Watcher) podsChanged() {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+
+    if w.restarts == nil {
+        w.restarts = make(map[string]int32)
+    }
+    restarts := make(map[string]int32)
+    pods := w.pods.List()
+    for _, obj := range pods {
+        pod := obj.(*corev1.Pod)
+        for _, status := range pod.Status.ContainerStatuses {
+            key := pod.Name + "/" + status.Name
+            base, seen := w.restarts[key]
+            if !seen {
+                // Restarts before the watch started, or a new pod's first count
+                if w.snap.Sampled.IsZero() {
+                    base = status.RestartCount
+                }
+                w.restarts[key] = base
+            }
+            restarts[status.Name] += status.RestartCount - base
+        }
+    }
+
+    for name, count := range restarts {
+        if count > w.snap.Restarts[name] {
+            w.event("container %q restarted (%d since start)", name, count)
+        }
+    }
+    if len(pods) != w.snap.Pods && !w.snap.Sampled.IsZero() {
+        w.event("pods %d → %d", w.snap.Pods, len(pods))
+    }
+    w.snap.Pods = len(pods)
+    w.snap.Restarts = restarts
+    notify(w.changed)
+}
+
+func (w *Watcher) sample(ctx context.Context, selector labels.Selector) {
+    var stats []analyzer.ContainerStats
+    var err error
+    if w.cfg.Metrics != nil {
+        var sample analyzer.UsageSample
+        sample, err = analyzer.SampleUsage(ctx, w.cfg.Metrics, w.cfg.Namespace, selector.String())
+        if err == nil && sample.Pods > 0 {
+            w.window.Add(sample)
+        }
+        stats = w.window.Stats()
+    }
+
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    if ctx.Err() != nil {
+        return
+    }
+    if w.snap.Sampled.IsZero() {
+        // Pod counts and restarts seen from now on are changes
+        w.snap.Pods = len(w.pods.List())
+    }
+    w.snap.Sampled = time.Now()
+    w.snap.Samples = w.window.Len()
+    w.snap.Stats = stats
+    w.snap.Err = err
+}
+
+func (w *Watcher) reanalyze(ctx context.Context) {
+    details, err := w.cfg.Analyze(ctx)
+
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    if err != nil {
+        w.snap.Err = err
+        return
+    }
+    w.snap.Details = details
+    w.event("re-analyzed: efficiency %s, %d finding(s)", details.EfficiencyRate, len(details.Findings))
+}
+
+// event appends to the recent changes; w.mu must be held.
+func (w *Watcher) event(format string, args ...interface{}) {
+    w.snap.Events = append(w.snap.Events, Event{Time: time.Now(), Message: fmt.Sprintf(format, args...)})
+    if len(w.snap.Events) > maxEvents {
+        w.snap.Events = w.snap.Events[len(w.snap.Events)-maxEvents:]
+    }
+}
+
+func (w *Watcher) render() {
+    w.mu.Lock()
+    snap := w.snap
+    snap.Events = append([]Event(nil), w.snap.Events...)
+    restarts := make(map[string]int32, len(w.snap.Restarts))
+    for name, count := range w.snap.Restarts {
+        restarts[name] = count
+    }
+    snap.Restarts = restarts
+    w.mu.Unlock()
+    w.cfg.Render(&snap)
+}
+
+// notify signals ch without blocking; one pending signal is enough.
+func notify(ch chan struct{}) {
+    select {
+    case ch <- struct{}{}:
+    default:
+    }
+}
@@ -0,0 +1,279 @@
+package watch
+
+import (
+    "context"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    appsv1 "k8s.io/api/apps/v1"
+    corev1 "k8s.io/api/core/v1"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/client-go/kubernetes/fake"
+    "k8s.io/client-go/tools/cache"
+    "k8s-workload-analyzer/pkg/analyzer"
+)
+
+const testNamespace = "shop"
+
+func deployment(generation int64, replicas int32) *appsv1.Deployment {
+    return &appsv1.Deployment{
+        ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace, Generation: generation},
+        Spec: appsv1.DeploymentSpec{
+            Replicas: &replicas,
+            Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+        },
+        Status: appsv1.DeploymentStatus{ReadyReplicas: replicas},
+    }
+}
+
+func pod(name string, restarts int32) *corev1.Pod {
+    return &corev1.Pod{
+        ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: map[string]string{"app": "web"}},
+        Status: corev1.PodStatus{
+            ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: restarts}},
+        },
+    }
+}
+
+func messages(events []Event) string {
+    var msgs []string
+    for _, e := range events {
+        msgs = append(msgs, e.Message)
+    }
+    return strings.Join(msgs, "; ")
+}
+
+func signaled(ch chan struct{}) bool {
+    select {
+    case <-ch:
+        return true
+    default:
+        return false
+    }
+}
+
+func TestWorkloadChanged(t *testing.T) {
+    w := New(Config{})
+
+    w.workloadChanged(deployment(1, 2))
+    if signaled(w.specChanged) {
+        t.Error("expected the first generation seen not to trigger an analysis")
+    }
+    if w.snap.Desired != 2 || w.snap.Ready != 2 || len(w.snap.Events) != 0 {
+        t.Errorf("expected 2/2 replicas and no events before the first sample, got %+v", w.snap)
+    }
+
+    // Scaling doesn't change the generation of the spec we analyzed
+    w.snap.Sampled = time.Now()
+    scaled := deployment(1, 3)
+    scaled.Status.ReadyReplicas = 2
+    w.workloadChanged(scaled)
+    if signaled(w.specChanged) {
+        t.Error("expected a status-only change not to trigger an analysis")
+    }
+    if w.snap.Desired != 3 || w.snap.Ready != 2 {
+        t.Errorf("expected 2 of 3 replicas ready, got %d/%d", w.snap.Ready, w.snap.Desired)
+    }
+
+    w.workloadChanged(deployment(2, 3))
+    if !signaled(w.specChanged) {
+        t.Error("expected a new generation to trigger an analysis")
+    }
+    want := "desired replicas 2 → 3; spec changed (generation 2), re-analyzing"
+    if got := messages(w.snap.Events); got != want {
+        t.Errorf("expected events %q, got %q", want, got)
+    }
+    if !signaled(w.changed) {
+        t.Error("expected a redraw to be queued")
+    }
+}
+
+func TestReplicas(t *testing.T) {
+    var unset *int32
+    daemonSet := &appsv1.DaemonSet{Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 4, NumberReady: 3}}
+
+    tests := []struct {
+        name           string
+        obj            interface{}
+        desired, ready int32
+    }{
+        {"deployment", deployment(1, 2), 2, 2},
+        {"unset replicas", &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Replicas: unset}}, 1, 0},
+        {"daemonset", daemonSet, 4, 3},
+        {"other", &corev1.Pod{}, 0, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            desired, ready := replicas(tt.obj)
+            if desired != tt.desired || ready != tt.ready {
+                t.Errorf("expected %d/%d, got %d/%d", tt.ready, tt.desired, ready, desired)
+            }
+        })
+    }
+}
+
+func TestPodsChanged(t *testing.T) {
+    w := New(Config{})
+    w.snap.Restarts = map[string]int32{}
+    w.pods = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+    set := func(p *corev1.Pod) {
+        if err := w.pods.Update(p); err != nil {
+            t.Fatal(err)
+        }
+        w.podsChanged()
+    }
+
+    // Restarts before the first sample are the baseline
+    set(pod("web-1", 5))
+    if w.snap.Restarts["app"] != 0 || w.snap.Pods != 1 || len(w.snap.Events) != 0 {
+        t.Errorf("expected earlier restarts to be ignored, got %+v", w.snap)
+    }
+
+    w.snap.Sampled = time.Now()
+    set(pod("web-1", 7))
+    if w.snap.Restarts["app"] != 2 {
+        t.Errorf("expected 2 restarts since start, got %d", w.snap.Restarts["app"])
+    }
+
+    // A pod created during the watch counts all its restarts
+    set(pod("web-2", 1))
+    if w.snap.Restarts["app"] != 3 || w.snap.Pods != 2 {
+        t.Errorf("expected 3 restarts over 2 pods, got %d over %d", w.snap.Restarts["app"], w.snap.Pods)
+    }
+
+    // An unchanged count is not a new restart
+    set(pod("web-2", 1))
+    if err := w.pods.Delete(pod("web-1", 7)); err != nil {
+        t.Fatal(err)
+    }
+    w.podsChanged()
+
+    want := `container "app" restarted (2 since start); container "app" restarted (3 since start); pods 1 → 2; pods 2 → 1`
+    if got := messages(w.snap.Events); got != want {
+        t.Errorf("expected events %q, got %q", want, got)
+    }
+}
+
+func TestEventsKeepMostRecent(t *testing.T) {
+    w := New(Config{})
+    for i := 0; i < maxEvents+3; i++ {
+        w.event("event %d", i)
+    }
+    if len(w.snap.Events) != maxEvents || w.snap.Events[0].Message != "event 3" {
+        t.Errorf("expected the last %d events, got %q", maxEvents, messages(w.snap.Events))
+    }
+}
+
+// TestRun drives a watch through pod restarts, a scale and a spec change
+// with a fake clientset.
+func TestRun(t *testing.T) {
+    client := fake.NewSimpleClientset(deployment(1, 2), pod("web-1", 4))
+
+    var analyses atomic.Int32
+    var mu sync.Mutex
+    var last *Snapshot
+    w := New(Config{
+        Client:    client,
+        Namespace: testNamespace,
+        Type:      "deployment",
+        Name:      "web",
+        Interval:  10 * time.Millisecond,
+        Window:    time.Minute,
+        Analyze: func(ctx context.Context) (*analyzer.WorkloadDetails, error) {
+            n := analyses.Add(1)
+            return &analyzer.WorkloadDetails{Deployment: "web", EfficiencyRate: "Medium (60.0%)", DesiredReplicas: n}, nil
+        },
+        Render: func(snap *Snapshot) {
+            mu.Lock()
+            last = snap
+            mu.Unlock()
+        },
+    })
+
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan error)
+    go func() { done <- w.Run(ctx) }()
+    defer func() {
+        cancel()
+        if err := <-done; err != nil {
+            t.Errorf("Run() error = %v", err)
+        }
+    }()
+
+    waitFor := func(what string, ok func(snap *Snapshot) bool) {
+        t.Helper()
+        deadline := time.Now().Add(5 * time.Second)
+        for time.Now().Before(deadline) {
+            mu.Lock()
+            snap := last
+            mu.Unlock()
+            if snap != nil && ok(snap) {
+                return
+            }
+            time.Sleep(5 * time.Millisecond)
+        }
+        mu.Lock()
+        defer mu.Unlock()
+        t.Fatalf("timed out waiting for %s, last snapshot %+v", what, last)
+    }
+
+    waitFor("the first sample", func(snap *Snapshot) bool {
+        return !snap.Sampled.IsZero() && snap.Pods == 1 && snap.Desired == 2
+    })
+    mu.Lock()
+    if restarts := last.Restarts["app"]; restarts != 0 {
+        t.Errorf("expected restarts before the watch to be ignored, got %d", restarts)
+    }
+    mu.Unlock()
+
+    pods := client.CoreV1().Pods(testNamespace)
+    if _, err := pods.UpdateStatus(ctx, pod("web-1", 6), metav1.UpdateOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    waitFor("the restarts", func(snap *Snapshot) bool { return snap.Restarts["app"] == 2 })
+
+    deployments := client.AppsV1().Deployments(testNamespace)
+    if _, err := deployments.Update(ctx, deployment(1, 3), metav1.UpdateOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := pods.Create(ctx, pod("web-2", 0), metav1.CreateOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    waitFor("the scale-up", func(snap *Snapshot) bool { return snap.Desired == 3 && snap.Pods == 2 })
+    if n := analyses.Load(); n != 1 {
+        t.Errorf("expected scaling not to re-analyze, got %d analyses", n)
+    }
+
+    if _, err := deployments.Update(ctx, deployment(2, 3), metav1.UpdateOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    waitFor("the re-analysis", func(snap *Snapshot) bool { return snap.Details.DesiredReplicas == 2 })
+    if n := analyses.Load(); n != 2 {
+        t.Errorf("expected one re-analysis after the spec change, got %d analyses", n)
+    }
+
+    mu.Lock()
+    got := messages(last.Events)
+    mu.Unlock()
+    for _, want := range []string{
+        `container "app" restarted (2 since start)`,
+        "desired replicas 2 → 3",
+        "pods 1 → 2",
+        "spec changed (generation 2), re-analyzing",
+        "re-analyzed: efficiency Medium (60.0%), 0 finding(s)",
+    } {
+        if !strings.Contains(got, want) {
+            t.Errorf("expected event %q in %q", want, got)
+        }
+    }
+}
+
+func TestRunUnknownWorkload(t *testing.T) {
+    w := New(Config{Client: fake.NewSimpleClientset(), Namespace: testNamespace, Type: "deployment", Name: "web"})
+    if err := w.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to get deployment") {
+        t.Errorf("expected a missing workload to fail, got %v", err)
+    }
+}
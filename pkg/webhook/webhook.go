@@ -0,0 +1,286 @@
+package webhook
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log/slog"
+    "net/http"
+    "strings"
+    "time"
+
+    admissionv1 "k8s.io/api/admission/v1"
+    corev1 "k8s.io/api/core/v1"
+    "k8s.io/apimachinery/pkg/api/resource"
+    metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+    "k8s.io/client-go/kubernetes/scheme"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/gate"
+    "k8s-workload-analyzer/pkg/store"
+)
+
+// maxBodySize bounds AdmissionReview bodies; the API server sends whole
+// objects, which stay well below this.
+const maxBodySize = 3 << 20
+
+// Config is the policy the webhook enforces.
+type Config struct {
+    // Cluster names the cluster in history keys, matching the context
+    // name the history was recorded under.
+    Cluster string
+
+    // Finish evaluates the rules, like the CLI does for every analyzed
+    // workload.
+    Finish func(ctx context.Context, details *analyzer.WorkloadDetails, cluster string)
+
+    // Deny rejects workloads with a finding at or above this severity that
+    // Baseline doesn't accept. Other findings are returned as warnings.
+    // Empty only warns.
+    Deny     analyzer.Severity
+    Baseline *gate.Baseline
+
+    // Recommendations returns the latest stored recommendations for a
+    // history key, used by /mutate to fill in missing requests. Nil
+    // disables mutation.
+    Recommendations func(key string) ([]analyzer.ResourceRecommendation, error)
+
+    // Timeout bounds one review; 0 disables. The API server has its own
+    // timeout, after which it applies the webhook's failurePolicy.
+    Timeout time.Duration
+}
+
+// Webhook serves validating (/validate) and mutating (/mutate) admission
+// reviews for Deployments, StatefulSets and DaemonSets.
+type Webhook struct {
+    cfg     Config
+    mux     *http.ServeMux
+    handler http.Handler
+}
+
+func New(cfg Config) *Webhook {
+    if cfg.Baseline == nil {
+        cfg.Baseline = &gate.Baseline{}
+    }
+    wh := &Webhook{cfg: cfg, mux: http.NewServeMux()}
+    wh.mux.HandleFunc("POST /validate", wh.handle(wh.validate))
+    wh.mux.HandleFunc("POST /mutate", wh.handle(wh.mutate))
+    wh.mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte("ok\n"))
+    })
+    wh.handler = wh.mux
+    if cfg.Timeout > 0 {
+        wh.handler = http.TimeoutHandler(wh.mux, cfg.Timeout, "review timed out")
+    }
+    return wh
+}
+
+func (wh *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    wh.handler.ServeHTTP(w, r)
+}
+
+// ListenAndServeTLS serves on addr until ctx is done, then shuts down
+// gracefully. The API server only calls webhooks over TLS.
+func (wh *Webhook) ListenAndServeTLS(ctx context.Context, addr, certFile, keyFile string, shutdownTimeout time.Duration) error {
+    srv := &http.Server{Addr: addr, Handler: wh, ReadHeaderTimeout: 10 * time.Second}
+    errc := make(chan error, 1)
+    go func() { errc <- srv.ListenAndServeTLS(certFile, keyFile) }()
+
+    select {
+    case err := <-errc:
+        return fmt.Errorf("failed to serve: %v", err)
+    case <-ctx.Done():
+    }
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+    defer cancel()
+    if err := srv.Shutdown(shutdownCtx); err != nil {
+        return fmt.Errorf("failed to shut down: %v", err)
+    }
+    if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+        return fmt.Errorf("failed to serve: %v", err)
+    }
+    return nil
+}
+
+// handle decodes an AdmissionReview, passes the workload to review and
+// writes the response. Objects the rules don't understand, or that fail to
+// decode, are allowed.
+func (wh *Webhook) handle(review func(req *admissionv1.AdmissionRequest, details *analyzer.WorkloadDetails) *admissionv1.AdmissionResponse) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in admissionv1.AdmissionReview
+        if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&in); err != nil {
+            http.Error(w, fmt.Sprintf("invalid AdmissionReview: %v", err), http.StatusBadRequest)
+            return
+        }
+        if in.Request == nil {
+            http.Error(w, "AdmissionReview has no request", http.StatusBadRequest)
+            return
+        }
+
+        var resp *admissionv1.AdmissionResponse
+        details, err := decode(in.Request)
+        switch {
+        case err != nil:
+            // Never block a deploy on an object the rules can't read
+            slog.WarnContext(r.Context(), "admitting object that failed to decode",
+                "kind", in.Request.Kind.Kind, "namespace", in.Request.Namespace, "name", in.Request.Name, "error", err)
+            resp = &admissionv1.AdmissionResponse{
+                Allowed:  true,
+                Warnings: []string{fmt.Sprintf("kwa: not checked: %v", err)},
+            }
+        case details == nil:
+            resp = &admissionv1.AdmissionResponse{Allowed: true}
+        default:
+            if wh.cfg.Finish != nil {
+                wh.cfg.Finish(r.Context(), details, wh.cfg.Cluster)
+            }
+            details.Cluster = wh.cfg.Cluster
+            resp = review(in.Request, details)
+        }
+        resp.UID = in.Request.UID
+
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(admissionv1.AdmissionReview{
+            TypeMeta: in.TypeMeta,
+            Response: resp,
+        })
+    }
+}
+
+// decode returns the details of the workload under review, or nil for
+// other kinds and deletes.
+func decode(req *admissionv1.AdmissionRequest) (*analyzer.WorkloadDetails, error) {
+    if len(req.Object.Raw) == 0 {
+        return nil, nil
+    }
+    obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(req.Object.Raw, nil, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to decode %s: %v", req.Kind.Kind, err)
+    }
+    details, ok := analyzer.DetailsFromObject(obj, req.Namespace)
+    if !ok {
+        return nil, nil
+    }
+    if details.Deployment == "" {
+        details.Deployment = req.Name
+    }
+    return details, nil
+}
+
+// validate rejects workloads violating the policy and warns about any
+// other findings.
+func (wh *Webhook) validate(req *admissionv1.AdmissionRequest, details *analyzer.WorkloadDetails) *admissionv1.AdmissionResponse {
+    resp := &admissionv1.AdmissionResponse{Allowed: true}
+    workload := gate.WorkloadKey(details)
+
+    var denied []string
+    for _, f := range details.Findings {
+        msg := fmt.Sprintf("%s (%s): %s", f.ID, f.Severity, f.Message)
+        if wh.cfg.Deny != "" && f.Severity.AtLeast(wh.cfg.Deny) && !wh.cfg.Baseline.Accepts(workload, f) {
+            denied = append(denied, msg)
+            continue
+        }
+        resp.Warnings = append(resp.Warnings, msg)
+    }
+
+    if len(denied) > 0 {
+        resp.Allowed = false
+        resp.Result = &metav1.Status{
+            Code:    http.StatusForbidden,
+            Reason:  metav1.StatusReasonForbidden,
+            Message: fmt.Sprintf("%s %q violates resource policy: %s", details.Kind, details.Deployment, strings.Join(denied, "; ")),
+        }
+    }
+    return resp
+}
+
+// patchOp is one JSON patch operation.
+type patchOp struct {
+    Op    string      `json:"op"`
+    Path  string      `json:"path"`
+    Value interface{} `json:"value,omitempty"`
+}
+
+// mutate fills in the CPU and memory requests containers don't set with
+// the latest recommendation stored for the workload. Workloads without
+// history are admitted unchanged.
+func (wh *Webhook) mutate(req *admissionv1.AdmissionRequest, details *analyzer.WorkloadDetails) *admissionv1.AdmissionResponse {
+    resp := &admissionv1.AdmissionResponse{Allowed: true}
+    if wh.cfg.Recommendations == nil || details.PodSpec == nil {
+        return resp
+    }
+
+    recommendations, err := wh.cfg.Recommendations(store.KeyOf(details))
+    if err != nil {
+        // Never block a deploy on history; admit without defaults
+        resp.Warnings = []string{fmt.Sprintf("kwa: no default requests: %v", err)}
+        return resp
+    }
+    byContainer := make(map[string]analyzer.ResourceRecommendation)
+    for _, rec := range recommendations {
+        byContainer[rec.Container] = rec
+    }
+
+    var patch []patchOp
+    for i, c := range details.PodSpec.Containers {
+        rec, ok := byContainer[c.Name]
+        if !ok {
+            continue
+        }
+        resources, injected := withDefaultRequests(c.Resources, rec)
+        if len(injected) == 0 {
+            continue
+        }
+        patch = append(patch, patchOp{
+            Op:    "add",
+            Path:  fmt.Sprintf("/spec/template/spec/containers/%d/resources", i),
+            Value: resources,
+        })
+        resp.Warnings = append(resp.Warnings, fmt.Sprintf("kwa: set %s on container %q from recommendations", strings.Join(injected, ", "), c.Name))
+    }
+    if len(patch) == 0 {
+        return resp
+    }
+
+    data, err := json.Marshal(patch)
+    if err != nil {
+        resp.Warnings = []string{fmt.Sprintf("kwa: no default requests: %v", err)}
+        return resp
+    }
+    patchType := admissionv1.PatchTypeJSONPatch
+    resp.Patch = data
+    resp.PatchType = &patchType
+    return resp
+}
+
+// withDefaultRequests returns the resources with missing requests set from
+// the recommendation, and which requests were set.
+func withDefaultRequests(resources corev1.ResourceRequirements, rec analyzer.ResourceRecommendation) (corev1.ResourceRequirements, []string) {
+    resources = *resources.DeepCopy()
+    if resources.Requests == nil {
+        resources.Requests = corev1.ResourceList{}
+    }
+
+    var injected []string
+    if resources.Requests.Cpu().IsZero() && rec.RecommendedCPURequest > 0 {
+        cpu := belowLimit(*resource.NewMilliQuantity(rec.RecommendedCPURequest, resource.DecimalSI), resources.Limits.Cpu())
+        resources.Requests[corev1.ResourceCPU] = cpu
+        injected = append(injected, "cpu request "+cpu.String())
+    }
+    if resources.Requests.Memory().IsZero() && rec.RecommendedMemoryRequest > 0 {
+        memory := belowLimit(*resource.NewQuantity(rec.RecommendedMemoryRequest, resource.BinarySI), resources.Limits.Memory())
+        resources.Requests[corev1.ResourceMemory] = memory
+        injected = append(injected, "memory request "+memory.String())
+    }
+    return resources, injected
+}
+
+// belowLimit caps a request at the limit, since the API server rejects
+// requests above it.
+func belowLimit(request resource.Quantity, limit *resource.Quantity) resource.Quantity {
+    if !limit.IsZero() && request.Cmp(*limit) > 0 {
+        return limit.DeepCopy()
+    }
+    return request
+}
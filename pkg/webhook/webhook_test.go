@@ -0,0 +1,201 @@
+package webhook
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "strings"
+    "testing"
+
+    admissionv1 "k8s.io/api/admission/v1"
+    corev1 "k8s.io/api/core/v1"
+    "k8s-workload-analyzer/pkg/analyzer"
+    "k8s-workload-analyzer/pkg/gate"
+    "k8s-workload-analyzer/pkg/rules"
+)
+
+func newTestServer(t *testing.T, cfg Config) *httptest.Server {
+    t.Helper()
+    engine, err := rules.NewEngine(rules.DefaultOptions())
+    if err != nil {
+        t.Fatal(err)
+    }
+    cfg.Cluster = "test"
+    cfg.Finish = func(ctx context.Context, details *analyzer.WorkloadDetails, cluster string) {
+        details.Findings = engine.Evaluate(details)
+    }
+
+    // The API server only talks TLS to webhooks
+    srv := httptest.NewTLSServer(New(cfg))
+    t.Cleanup(srv.Close)
+    return srv
+}
+
+// review posts an AdmissionReview fixture and returns the response.
+func review(t *testing.T, srv *httptest.Server, path, fixture string) *admissionv1.AdmissionResponse {
+    t.Helper()
+    data, err := os.ReadFile("testdata/" + fixture)
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp, err := srv.Client().Post(srv.URL+path, "application/json", bytes.NewReader(data))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        t.Fatalf("expected 200, got %d", resp.StatusCode)
+    }
+
+    var out admissionv1.AdmissionReview
+    if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+        t.Fatal(err)
+    }
+    var in admissionv1.AdmissionReview
+    json.Unmarshal(data, &in)
+    if out.Kind != "AdmissionReview" || out.Response == nil || out.Response.UID != in.Request.UID {
+        t.Fatalf("response doesn't answer the request: %+v", out)
+    }
+    return out.Response
+}
+
+func TestValidate(t *testing.T) {
+    srv := newTestServer(t, Config{Deny: analyzer.SeverityCritical})
+
+    resp := review(t, srv, "/validate", "privileged-deployment.json")
+    if resp.Allowed || resp.Result.Code != http.StatusForbidden || !strings.Contains(resp.Result.Message, "KWA003/app") {
+        t.Errorf("expected privileged deployment to be denied, got %+v", resp)
+    }
+    // Findings below the deny threshold are only warnings
+    if len(resp.Warnings) != 2 || !strings.HasPrefix(resp.Warnings[0], "KWA001/app (high)") {
+        t.Errorf("unexpected warnings: %q", resp.Warnings)
+    }
+
+    resp = review(t, srv, "/validate", "compliant-statefulset.json")
+    if !resp.Allowed || len(resp.Warnings) != 0 {
+        t.Errorf("expected compliant statefulset to be allowed without warnings, got %+v", resp)
+    }
+
+    resp = review(t, srv, "/validate", "configmap.json")
+    if !resp.Allowed {
+        t.Errorf("expected other kinds to be allowed, got %+v", resp)
+    }
+}
+
+func TestValidateWarnOnly(t *testing.T) {
+    srv := newTestServer(t, Config{})
+
+    resp := review(t, srv, "/validate", "privileged-deployment.json")
+    if !resp.Allowed || len(resp.Warnings) != 3 {
+        t.Errorf("expected an allowed response with 3 warnings, got %+v", resp)
+    }
+}
+
+func TestValidateBaseline(t *testing.T) {
+    srv := newTestServer(t, Config{
+        Deny: analyzer.SeverityHigh,
+        Baseline: &gate.Baseline{Accepted: []gate.Entry{
+            {ID: "KWA003/app", Workload: "default/deployment/web"},
+            {ID: "KWA001/*", Workload: "*"},
+        }},
+    })
+
+    resp := review(t, srv, "/validate", "privileged-deployment.json")
+    if !resp.Allowed || len(resp.Warnings) != 3 {
+        t.Errorf("expected baselined findings to be allowed as warnings, got %+v", resp)
+    }
+}
+
+func TestMutate(t *testing.T) {
+    var key string
+    srv := newTestServer(t, Config{
+        Recommendations: func(k string) ([]analyzer.ResourceRecommendation, error) {
+            key = k
+            return []analyzer.ResourceRecommendation{
+                {Container: "app", RecommendedCPURequest: 120, RecommendedMemoryRequest: 300 << 20},
+                {Container: "sidecar", RecommendedCPURequest: 10, RecommendedMemoryRequest: 32 << 20},
+            }, nil
+        },
+    })
+
+    resp := review(t, srv, "/mutate", "privileged-deployment.json")
+    if key != "test/default/deployment/web" {
+        t.Errorf("unexpected history key %q", key)
+    }
+    if !resp.Allowed || resp.PatchType == nil || *resp.PatchType != admissionv1.PatchTypeJSONPatch {
+        t.Fatalf("expected a JSON patch, got %+v", resp)
+    }
+
+    var patch []struct {
+        Op    string                      `json:"op"`
+        Path  string                      `json:"path"`
+        Value corev1.ResourceRequirements `json:"value"`
+    }
+    if err := json.Unmarshal(resp.Patch, &patch); err != nil {
+        t.Fatal(err)
+    }
+    if len(patch) != 2 {
+        t.Fatalf("expected 2 patch operations, got %s", resp.Patch)
+    }
+
+    app := patch[0].Value
+    if patch[0].Path != "/spec/template/spec/containers/0/resources" || app.Requests.Cpu().String() != "120m" {
+        t.Errorf("unexpected patch for app: %s", resp.Patch)
+    }
+    // Capped at the 256Mi limit
+    if app.Requests.Memory().String() != "256Mi" || app.Limits.Memory().String() != "256Mi" {
+        t.Errorf("expected app memory request capped at its limit, got %s", resp.Patch)
+    }
+
+    // The sidecar keeps its own CPU request
+    sidecar := patch[1].Value
+    if sidecar.Requests.Cpu().String() != "50m" || sidecar.Requests.Memory().String() != "32Mi" {
+        t.Errorf("unexpected patch for sidecar: %s", resp.Patch)
+    }
+}
+
+func TestMutateWithoutHistory(t *testing.T) {
+    srv := newTestServer(t, Config{
+        Recommendations: func(string) ([]analyzer.ResourceRecommendation, error) {
+            return nil, nil
+        },
+    })
+    if resp := review(t, srv, "/mutate", "privileged-deployment.json"); !resp.Allowed || resp.Patch != nil {
+        t.Errorf("expected no patch without history, got %+v", resp)
+    }
+
+    srv = newTestServer(t, Config{
+        Recommendations: func(string) ([]analyzer.ResourceRecommendation, error) {
+            return nil, fmt.Errorf("history locked")
+        },
+    })
+    if resp := review(t, srv, "/mutate", "privileged-deployment.json"); !resp.Allowed || resp.Patch != nil || len(resp.Warnings) != 1 {
+        t.Errorf("expected history errors to admit unchanged, got %+v", resp)
+    }
+}
+
+func TestMalformedObject(t *testing.T) {
+    srv := newTestServer(t, Config{Deny: analyzer.SeverityLow})
+    for _, path := range []string{"/validate", "/mutate"} {
+        resp := review(t, srv, path, "malformed-deployment.json")
+        if !resp.Allowed || len(resp.Warnings) != 1 || !strings.HasPrefix(resp.Warnings[0], "kwa: not checked: failed to decode Deployment") {
+            t.Errorf("%s: expected an object that fails to decode to be admitted with a warning, got %+v", path, resp)
+        }
+    }
+}
+
+func TestInvalidReview(t *testing.T) {
+    srv := newTestServer(t, Config{})
+    resp, err := srv.Client().Post(srv.URL+"/validate", "application/json", strings.NewReader(`{"kind": "AdmissionReview"}`))
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusBadRequest {
+        t.Errorf("expected 400 without a request, got %d", resp.StatusCode)
+    }
+}